@@ -0,0 +1,268 @@
+package accesslog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Format of access log entries
+type Format string
+
+// Access Log Formats
+const (
+	FormatCombined Format = "combined"
+	FormatJSON     Format = "json"
+)
+
+// Access Log Destinations besides a file path
+const (
+	DestinationStdout = "stdout"
+	DestinationStderr = "stderr"
+	DestinationNone   = "none"
+)
+
+// combinedTimeLayout used by the Combined Log Format
+const combinedTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// random for mocking out the 2xx sampling decision
+var random = rand.Float64
+
+// Entry of a single request, annotated by handlers while the request is served
+type Entry struct {
+	Time            time.Time
+	RemoteAddr      string
+	Method          string
+	Path            string
+	Protocol        string
+	Status          int
+	Bytes           int64
+	Duration        time.Duration
+	Locale          string
+	RequestID       string
+	Client          string
+	Referer         string
+	UserAgent       string
+	upstreamLookups int64
+}
+
+// AddUpstreamLookup counts a call to an upstream service
+func (e *Entry) AddUpstreamLookup() {
+	atomic.AddInt64(&e.upstreamLookups, 1)
+}
+
+// UpstreamLookups made while serving the request
+func (e *Entry) UpstreamLookups() int64 {
+	return atomic.LoadInt64(&e.upstreamLookups)
+}
+
+type entryKey struct{}
+
+// NewContext returns a copy of ctx carrying the Entry
+func NewContext(ctx context.Context, entry *Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, entry)
+}
+
+// FromContext returns the Entry carried by ctx, or a detached Entry so callers never need a nil check
+func FromContext(ctx context.Context) *Entry {
+	if entry, ok := ctx.Value(entryKey{}).(*Entry); ok {
+		return entry
+	}
+
+	return &Entry{}
+}
+
+// Redactor of the request URI and header values written to the access log
+type Redactor interface {
+	Value(name string, value string) string
+	URL(rawURL string) string
+}
+
+// Logger writing one line per request
+type Logger struct {
+	mu        sync.Mutex
+	out       io.Writer
+	format    Format
+	sample2xx float64
+	redactor  Redactor
+}
+
+// New Logger writing to out; sample2xx is the fraction of 2xx responses logged, every other status is always logged
+func New(out io.Writer, format Format, sample2xx float64, redactor Redactor) *Logger {
+	if format != FormatJSON {
+		format = FormatCombined
+	}
+
+	return &Logger{out: out, format: format, sample2xx: sample2xx, redactor: redactor}
+}
+
+// Open the destination, appending to the file when it is not stdout, stderr, or none
+func Open(destination string) (io.Writer, error) {
+	switch destination {
+	case DestinationStdout, "":
+		return os.Stdout, nil
+	case DestinationStderr:
+		return os.Stderr, nil
+	case DestinationNone:
+		return ioutil.Discard, nil
+	default:
+		return os.OpenFile(destination, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	}
+}
+
+// Sampled reports whether a response with the status should be logged
+func (l *Logger) Sampled(status int) bool {
+	if status < 200 || status > 299 || l.sample2xx >= 1 {
+		return true
+	}
+
+	return random() < l.sample2xx
+}
+
+// Log the Entry in the configured format
+func (l *Logger) Log(entry *Entry) {
+	if !l.Sampled(entry.Status) {
+		return
+	}
+
+	var line []byte
+	if l.format == FormatJSON {
+		line = jsonLine(entry)
+	} else {
+		line = combinedLine(entry)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out.Write(line)
+}
+
+// Middleware records an Entry for every request served by handler
+func (l *Logger) Middleware(handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Query parameters and the Referer may carry credentials, e.g. tokens a client put in a URL
+		entry := &Entry{
+			Time:       time.Now(),
+			RemoteAddr: r.RemoteAddr,
+			Method:     r.Method,
+			Path:       l.redactor.URL(r.URL.RequestURI()),
+			Protocol:   r.Proto,
+			Referer:    l.redactor.URL(l.redactor.Value("Referer", r.Referer())),
+			UserAgent:  l.redactor.Value("User-Agent", r.UserAgent()),
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(recorder, r.WithContext(NewContext(r.Context(), entry)))
+
+		entry.Status = recorder.status
+		entry.Bytes = recorder.bytes
+		entry.Duration = time.Since(entry.Time)
+		l.Log(entry)
+	}
+}
+
+// responseRecorder captures the status code and body size written by a handler
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+func combinedLine(e *Entry) []byte {
+	size := "-"
+	if e.Bytes > 0 {
+		size = strconv.FormatInt(e.Bytes, 10)
+	}
+
+	return []byte(fmt.Sprintf("%s - %s [%s] %s %d %s %s %s duration_ms=%s locale=%s upstream_lookups=%d request_id=%s\n",
+		dash(host(e.RemoteAddr)),
+		dash(strings.Replace(e.Client, " ", "_", -1)),
+		e.Time.Format(combinedTimeLayout),
+		strconv.Quote(e.Method+" "+e.Path+" "+e.Protocol),
+		e.Status,
+		size,
+		strconv.Quote(dash(e.Referer)),
+		strconv.Quote(dash(e.UserAgent)),
+		durationMs(e.Duration),
+		dash(e.Locale),
+		e.UpstreamLookups(),
+		dash(e.RequestID)))
+}
+
+func jsonLine(e *Entry) []byte {
+	line, _ := json.Marshal(struct {
+		Time            string  `json:"time"`
+		RemoteAddr      string  `json:"remoteAddr"`
+		Method          string  `json:"method"`
+		Path            string  `json:"path"`
+		Protocol        string  `json:"protocol"`
+		Status          int     `json:"status"`
+		Bytes           int64   `json:"bytes"`
+		DurationMs      float64 `json:"durationMs"`
+		Locale          string  `json:"locale,omitempty"`
+		UpstreamLookups int64   `json:"upstreamLookups"`
+		RequestID       string  `json:"requestId,omitempty"`
+		Client          string  `json:"client,omitempty"`
+		Referer         string  `json:"referer,omitempty"`
+		UserAgent       string  `json:"userAgent,omitempty"`
+	}{
+		Time:            e.Time.UTC().Format(time.RFC3339Nano),
+		RemoteAddr:      host(e.RemoteAddr),
+		Method:          e.Method,
+		Path:            e.Path,
+		Protocol:        e.Protocol,
+		Status:          e.Status,
+		Bytes:           e.Bytes,
+		DurationMs:      float64(e.Duration) / float64(time.Millisecond),
+		Locale:          e.Locale,
+		UpstreamLookups: e.UpstreamLookups(),
+		RequestID:       e.RequestID,
+		Client:          e.Client,
+		Referer:         e.Referer,
+		UserAgent:       e.UserAgent,
+	})
+
+	return append(line, '\n')
+}
+
+func durationMs(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
+
+func host(remoteAddr string) string {
+	if h, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return h
+	}
+
+	return remoteAddr
+}
+
+func dash(value string) string {
+	if len(value) == 0 {
+		return "-"
+	}
+
+	return value
+}
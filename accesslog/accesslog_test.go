@@ -0,0 +1,175 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dukeluke16/sample-golang-webservice/redact"
+)
+
+func serve(l *Logger, status int) {
+	handler := l.Middleware(func(w http.ResponseWriter, r *http.Request) {
+		entry := FromContext(r.Context())
+		entry.Locale = "fr"
+		entry.RequestID = "booking-123"
+		entry.AddUpstreamLookup()
+		entry.AddUpstreamLookup()
+
+		w.WriteHeader(status)
+		io.WriteString(w, "hello")
+	})
+
+	r, _ := http.NewRequest(http.MethodPost, "/policy/hazardousgoods/evaluate?debug=1&access_token=abc123", nil)
+	r.RemoteAddr = "192.0.2.1:54321"
+	r.Header.Set("User-Agent", "curl/7.54.0")
+	r.Header.Set("Referer", "https://book.example.com/cart?session=abc123")
+	handler(httptest.NewRecorder(), r)
+}
+
+func TestCombinedFormat(t *testing.T) {
+	var output bytes.Buffer
+	serve(New(&output, FormatCombined, 1, redact.NewPolicy(nil, nil)), http.StatusCreated)
+
+	expected := `192.0.2.1 - - [`
+	if !strings.HasPrefix(output.String(), expected) {
+		t.Errorf("Logger wrote unexpected prefix: got %v want %v", output.String(), expected)
+	}
+
+	expected = `] "POST /policy/hazardousgoods/evaluate?debug=1&access_token=[REDACTED] HTTP/1.1" 201 5 "https://book.example.com/cart?session=[REDACTED]" "curl/7.54.0" duration_ms=`
+	if !strings.Contains(output.String(), expected) {
+		t.Errorf("Logger wrote unexpected request: got %v want %v", output.String(), expected)
+	}
+
+	expected = ` locale=fr upstream_lookups=2 request_id=booking-123` + "\n"
+	if !strings.HasSuffix(output.String(), expected) {
+		t.Errorf("Logger wrote unexpected fields: got %v want %v", output.String(), expected)
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	var output bytes.Buffer
+	serve(New(&output, FormatJSON, 1, redact.NewPolicy(nil, nil)), http.StatusOK)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &entry); err != nil {
+		t.Fatalf("Logger wrote invalid JSON: %v", output.String())
+	}
+
+	expected := map[string]interface{}{
+		"remoteAddr":      "192.0.2.1",
+		"method":          http.MethodPost,
+		"path":            "/policy/hazardousgoods/evaluate?debug=1&access_token=[REDACTED]",
+		"referer":         "https://book.example.com/cart?session=[REDACTED]",
+		"status":          float64(http.StatusOK),
+		"bytes":           float64(5),
+		"locale":          "fr",
+		"upstreamLookups": float64(2),
+		"requestId":       "booking-123",
+		"userAgent":       "curl/7.54.0",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("Logger wrote wrong %v: got %v want %v", key, entry[key], value)
+		}
+	}
+
+	if _, err := time.Parse(time.RFC3339Nano, entry["time"].(string)); err != nil {
+		t.Errorf("Logger wrote invalid time: %v", entry["time"])
+	}
+}
+
+func TestClient(t *testing.T) {
+	for format, expected := range map[Format]string{
+		FormatCombined: "192.0.2.1 - booking_engine [",
+		FormatJSON:     `"client":"booking engine"`,
+	} {
+		var output bytes.Buffer
+		handler := New(&output, format, 1, redact.NewPolicy(nil, nil)).Middleware(func(w http.ResponseWriter, r *http.Request) {
+			FromContext(r.Context()).Client = "booking engine"
+		})
+
+		r, _ := http.NewRequest(http.MethodPost, "/v1/policy/hazardousgoods/evaluate", nil)
+		r.RemoteAddr = "192.0.2.1:54321"
+		handler(httptest.NewRecorder(), r)
+
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Logger wrote no %v client: got %v want %v", format, output.String(), expected)
+		}
+	}
+}
+
+func TestSampling(t *testing.T) {
+	defer func(original func() float64) { random = original }(random)
+	random = func() float64 { return 0.5 }
+
+	var output bytes.Buffer
+	l := New(&output, FormatCombined, 0.25, redact.NewPolicy(nil, nil))
+
+	serve(l, http.StatusOK)
+	if output.Len() != 0 {
+		t.Errorf("Logger wrote an unsampled 2xx response: %v", output.String())
+	}
+
+	serve(l, http.StatusServiceUnavailable)
+	if !strings.Contains(output.String(), `" 503 `) {
+		t.Errorf("Logger should always write non 2xx responses: got %v", output.String())
+	}
+
+	random = func() float64 { return 0.1 }
+	output.Reset()
+	serve(l, http.StatusOK)
+	if !strings.Contains(output.String(), `" 200 `) {
+		t.Errorf("Logger did not write a sampled 2xx response: got %v", output.String())
+	}
+}
+
+func TestFromContextDetached(t *testing.T) {
+	entry := FromContext(context.Background())
+	entry.AddUpstreamLookup()
+
+	if FromContext(context.Background()).UpstreamLookups() != 0 {
+		t.Errorf("FromContext should not share a detached Entry!")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	expected := map[string]io.Writer{
+		DestinationStdout: os.Stdout,
+		DestinationStderr: os.Stderr,
+		DestinationNone:   ioutil.Discard,
+	}
+	for destination, writer := range expected {
+		if actual, _ := Open(destination); actual != writer {
+			t.Errorf("Open returned wrong writer for %v: got %v want %v", destination, actual, writer)
+		}
+	}
+
+	dir, _ := ioutil.TempDir("", "accesslog")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "access.log")
+	out, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open returned an error: %v", err)
+	}
+	io.WriteString(out, "line\n")
+	out.(io.Closer).Close()
+
+	if contents, _ := ioutil.ReadFile(path); string(contents) != "line\n" {
+		t.Errorf("Open wrote unexpected contents: got %v", string(contents))
+	}
+
+	if _, err := Open(filepath.Join(dir, "missing", "access.log")); err == nil {
+		t.Errorf("Open should fail for a missing directory!")
+	}
+}
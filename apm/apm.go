@@ -0,0 +1,124 @@
+package apm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Providers selectable through configuration
+const (
+	ProviderNone          = "none"
+	ProviderNewRelic      = "newrelic"
+	ProviderOpenTelemetry = "opentelemetry"
+)
+
+// Span of work within a traced request
+type Span interface {
+	// SetAttribute adds a key value pair to the Span
+	SetAttribute(key string, value interface{})
+
+	// RecordError marks the Span as failed
+	RecordError(err error)
+
+	// StartChild starts a Span nested within this Span
+	StartChild(name string) Span
+
+	// End finishes the Span
+	End()
+}
+
+// Tracer for an APM backend
+type Tracer interface {
+	// WrapHandleFunc starts a root Span for every request and stores it in the request context
+	WrapHandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) (string, func(http.ResponseWriter, *http.Request))
+
+	// Transport instruments outbound requests carrying a Span in their context
+	Transport(base http.RoundTripper) http.RoundTripper
+
+	// Shutdown flushes any buffered telemetry
+	Shutdown() error
+}
+
+// OpenTelemetry exporters selectable through configuration
+const (
+	ExporterOTLP    = "otlp"
+	ExporterConsole = "console"
+)
+
+// Options for building a Tracer
+type Options struct {
+	Provider      string
+	ServiceName   string
+	CertDirectory string
+	Exporter      string
+	OTLPEndpoint  string
+}
+
+// New Tracer for the configured provider
+func New(options Options) (Tracer, error) {
+	switch options.Provider {
+	case ProviderNone, "":
+		return NewNoopTracer(), nil
+	case ProviderNewRelic:
+		return NewNewRelicTracer(options.CertDirectory)
+	case ProviderOpenTelemetry:
+		exporter, err := newExporter(options)
+		if err != nil {
+			return nil, err
+		}
+		return NewOpenTelemetryTracer(options.ServiceName, exporter), nil
+	default:
+		return nil, fmt.Errorf("unknown APM provider %q", options.Provider)
+	}
+}
+
+func newExporter(options Options) (Exporter, error) {
+	switch options.Exporter {
+	case ExporterOTLP, "":
+		return NewBatchExporter(NewOTLPExporter(options.OTLPEndpoint), 512, 5*time.Second), nil
+	case ExporterConsole:
+		return NewWriterExporter(stdout), nil
+	default:
+		return nil, fmt.Errorf("unknown OpenTelemetry exporter %q", options.Exporter)
+	}
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying the Span
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the Span carried by ctx, or a no-op Span
+func SpanFromContext(ctx context.Context) Span {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		return span
+	}
+
+	return noopSpan{}
+}
+
+// StartSpan starts a child of the Span carried by ctx
+func StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	span := SpanFromContext(ctx).StartChild(name)
+	return ContextWithSpan(ctx, span), span
+}
+
+// roundTripperFunc adapts a function to an http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
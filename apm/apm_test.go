@@ -0,0 +1,73 @@
+package apm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewProviders(t *testing.T) {
+	for _, provider := range []string{"", ProviderNone, ProviderOpenTelemetry} {
+		tracer, err := New(Options{Provider: provider, ServiceName: "test"})
+		if err != nil || tracer == nil {
+			t.Errorf("New failed for provider %q: %v", provider, err)
+		}
+	}
+}
+
+func TestNewUnknownProvider(t *testing.T) {
+	_, err := New(Options{Provider: "appdynamics"})
+	if err == nil {
+		t.Errorf("New failed to detect an unknown provider!")
+	}
+}
+
+func TestSpanFromContextDefaultsToNoop(t *testing.T) {
+	span := SpanFromContext(context.Background())
+	if _, ok := span.(noopSpan); !ok {
+		t.Errorf("SpanFromContext returned unexpected span: got %T", span)
+	}
+}
+
+func TestStartSpanCarriesChild(t *testing.T) {
+	exporter := &recordingExporter{}
+	root := NewOpenTelemetryTracer("test", exporter).(*otelTracer).startSpan("root", SpanKindServer, spanContext{})
+
+	ctx, child := StartSpan(ContextWithSpan(context.Background(), root), "child")
+	if SpanFromContext(ctx) != child {
+		t.Errorf("StartSpan did not carry the child span!")
+	}
+
+	child.End()
+	if len(exporter.spans) != 1 || exporter.spans[0].ParentSpanID != root.data.SpanID {
+		t.Errorf("StartSpan returned unexpected child: got %v", exporter.spans)
+	}
+}
+
+func TestNoopTracer(t *testing.T) {
+	tracer := NewNoopTracer()
+
+	called := false
+	path, handler := tracer.WrapHandleFunc("/testPath", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	if path != "/testPath" {
+		t.Errorf("handler returned wrong path: got %v want %v", path, "/testPath")
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/testPath", nil)
+	handler(httptest.NewRecorder(), r)
+	if !called {
+		t.Errorf("Noop tracer did not call the handler!")
+	}
+
+	if tracer.Transport(nil) != http.DefaultTransport {
+		t.Errorf("Noop tracer returned unexpected transport!")
+	}
+
+	if tracer.Shutdown() != nil {
+		t.Errorf("Noop tracer failed to shutdown!")
+	}
+}
@@ -0,0 +1,102 @@
+package apm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/newrelic/go-agent"
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/logger"
+)
+
+type newRelicSpan struct {
+	txn     newrelic.Transaction
+	segment *newrelic.Segment
+}
+
+func (s newRelicSpan) SetAttribute(key string, value interface{}) {
+	s.txn.AddAttribute(key, value)
+}
+
+func (s newRelicSpan) RecordError(err error) {
+	s.txn.NoticeError(err)
+}
+
+func (s newRelicSpan) StartChild(name string) Span {
+	return newRelicSpan{txn: s.txn, segment: newrelic.StartSegment(s.txn, name)}
+}
+
+// End finishes the segment; the root transaction is ended by the New Relic handler wrapper
+func (s newRelicSpan) End() {
+	if s.segment != nil {
+		s.segment.End()
+	}
+}
+
+type newRelicTracer struct {
+	app newrelic.Application
+}
+
+// NewNewRelicTracer configured from the NEW_RELIC_* environment variables
+func NewNewRelicTracer(certDirectory string) (Tracer, error) {
+	datacenter := strings.ToUpper(os.Getenv("NEW_RELIC_DATACENTER"))
+	environment := strings.ToUpper(os.Getenv("NEW_RELIC_ENVIRONMENT"))
+	roletypeid := strings.ToUpper(os.Getenv("NEW_RELIC_ROLETYPEID"))
+	appname := fmt.Sprintf("%v-%v1-%v", datacenter, environment, roletypeid)
+
+	newRelicConfig := newrelic.NewConfig(appname, os.Getenv("NEW_RELIC_LICENSE_KEY"))
+
+	// Configure Certificate Trust Chain for New Relic
+	certFile, _ := os.Open(filepath.Join(certDirectory, "newrelic.pem"))
+	certData, _ := ioutil.ReadAll(certFile)
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(certData)
+	tlsConfig := &tls.Config{
+		RootCAs: roots,
+	}
+
+	// Configure Transport
+	newRelicProxy := config.ProxyURI("NEW_RELIC_PROXY")
+	logger.Info("Configuring New Relic for Certificate Trust Chain & Proxy")
+	newRelicConfig.Transport = &http.Transport{
+		Proxy:           http.ProxyURL(newRelicProxy),
+		TLSClientConfig: tlsConfig,
+	}
+
+	newRelicConfig.Labels["Application"] = os.Getenv("NEW_RELIC_APP_NAME")
+	newRelicConfig.Labels["Datacenter"] = datacenter
+	newRelicConfig.Labels["Envrionment"] = environment
+	newRelicConfig.Labels["RoleTypeID"] = roletypeid
+
+	app, err := newrelic.NewApplication(newRelicConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return newRelicTracer{app: app}, nil
+}
+
+func (t newRelicTracer) WrapHandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) (string, func(http.ResponseWriter, *http.Request)) {
+	return newrelic.WrapHandleFunc(t.app, pattern, func(w http.ResponseWriter, r *http.Request) {
+		if txn := newrelic.FromContext(r.Context()); txn != nil {
+			r = r.WithContext(ContextWithSpan(r.Context(), newRelicSpan{txn: txn}))
+		}
+		handler(w, r)
+	})
+}
+
+// Transport records external segments for the transaction found in each request context
+func (t newRelicTracer) Transport(base http.RoundTripper) http.RoundTripper {
+	return newrelic.NewRoundTripper(nil, base)
+}
+
+func (t newRelicTracer) Shutdown() error {
+	t.app.Shutdown(0)
+	return nil
+}
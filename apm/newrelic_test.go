@@ -0,0 +1,53 @@
+package apm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestNewRelicTracerInvalidLicense(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("NEW_RELIC_LICENSE_KEY", "__YOUR_NEW_RELIC_LICENSE_KEY__")
+
+	_, err := NewNewRelicTracer("../certs/")
+	if err == nil {
+		t.Errorf("NewNewRelicTracer failed to detect an invalid license key!")
+	}
+}
+
+func TestNewRelicTracerCarriesSpan(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("NEW_RELIC_LICENSE_KEY", "0123456789012345678901234567890123456789")
+
+	tracer, err := NewNewRelicTracer("../certs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tracer.Shutdown()
+
+	expectedPath := "/testPath"
+	path, handler := tracer.WrapHandleFunc(expectedPath, func(w http.ResponseWriter, r *http.Request) {
+		span := SpanFromContext(r.Context())
+		if _, ok := span.(newRelicSpan); !ok {
+			t.Errorf("handler received unexpected span: got %T", span)
+		}
+
+		child := span.StartChild("work")
+		child.SetAttribute("airportCode", "SEA")
+		child.End()
+	})
+
+	if path != expectedPath {
+		t.Errorf("handler returned wrong path: got %v want %v", path, expectedPath)
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, expectedPath, nil)
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,35 @@
+package apm
+
+import (
+	"net/http"
+)
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+func (noopSpan) RecordError(err error)                      {}
+func (noopSpan) StartChild(name string) Span                { return noopSpan{} }
+func (noopSpan) End()                                       {}
+
+type noopTracer struct{}
+
+// NewNoopTracer for environments without an APM backend
+func NewNoopTracer() Tracer {
+	return noopTracer{}
+}
+
+func (noopTracer) WrapHandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) (string, func(http.ResponseWriter, *http.Request)) {
+	return pattern, handler
+}
+
+func (noopTracer) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		return http.DefaultTransport
+	}
+
+	return base
+}
+
+func (noopTracer) Shutdown() error {
+	return nil
+}
@@ -0,0 +1,214 @@
+package apm
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// stdout for mocking out os.Stdout
+var stdout io.Writer = os.Stdout
+
+// Span kinds following the OpenTelemetry specification
+const (
+	SpanKindInternal = "internal"
+	SpanKindServer   = "server"
+	SpanKindClient   = "client"
+)
+
+// SpanData of a finished OpenTelemetry Span
+type SpanData struct {
+	ServiceName  string                 `json:"serviceName"`
+	TraceID      string                 `json:"traceId"`
+	SpanID       string                 `json:"spanId"`
+	TraceState   string                 `json:"traceState,omitempty"`
+	ParentSpanID string                 `json:"parentSpanId,omitempty"`
+	Name         string                 `json:"name"`
+	Kind         string                 `json:"kind"`
+	StartTime    time.Time              `json:"startTime"`
+	EndTime      time.Time              `json:"endTime"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+// Exporter of finished Spans
+type Exporter interface {
+	ExportSpans(spans []SpanData) error
+	Shutdown() error
+}
+
+type writerExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterExporter writing one JSON document per Span, for development
+func NewWriterExporter(w io.Writer) Exporter {
+	return &writerExporter{w: w}
+}
+
+func (e *writerExporter) ExportSpans(spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	encoder := json.NewEncoder(e.w)
+	for _, span := range spans {
+		if err := encoder.Encode(span); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *writerExporter) Shutdown() error {
+	return nil
+}
+
+type otelSpan struct {
+	tracer  *otelTracer
+	mu      sync.Mutex
+	data    SpanData
+	sampled bool
+	ended   bool
+}
+
+func (s *otelSpan) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Attributes[key] = value
+}
+
+func (s *otelSpan) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Error = err.Error()
+}
+
+func (s *otelSpan) StartChild(name string) Span {
+	return s.tracer.startSpan(name, SpanKindInternal, s.spanContext())
+}
+
+// spanContext for propagating this Span as the parent of other Spans
+func (s *otelSpan) spanContext() spanContext {
+	return spanContext{
+		TraceID:    s.data.TraceID,
+		SpanID:     s.data.SpanID,
+		Sampled:    s.sampled,
+		TraceState: s.data.TraceState,
+	}
+}
+
+func (s *otelSpan) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if s.sampled {
+		s.tracer.exporter.ExportSpans([]SpanData{data})
+	}
+}
+
+type otelTracer struct {
+	serviceName string
+	exporter    Exporter
+}
+
+// NewOpenTelemetryTracer recording OpenTelemetry Spans to the Exporter
+func NewOpenTelemetryTracer(serviceName string, exporter Exporter) Tracer {
+	return &otelTracer{serviceName: serviceName, exporter: exporter}
+}
+
+// startSpan as a child of the parent, or as the root of a new sampled trace when the parent is empty
+func (t *otelTracer) startSpan(name string, kind string, parent spanContext) *otelSpan {
+	if len(parent.TraceID) == 0 {
+		parent = spanContext{TraceID: randomID(16), Sampled: true}
+	}
+
+	return &otelSpan{
+		tracer:  t,
+		sampled: parent.Sampled,
+		data: SpanData{
+			ServiceName:  t.serviceName,
+			TraceID:      parent.TraceID,
+			TraceState:   parent.TraceState,
+			SpanID:       randomID(8),
+			ParentSpanID: parent.SpanID,
+			Name:         name,
+			Kind:         kind,
+			StartTime:    time.Now(),
+			Attributes:   map[string]interface{}{},
+		},
+	}
+}
+
+func (t *otelTracer) WrapHandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) (string, func(http.ResponseWriter, *http.Request)) {
+	return pattern, func(w http.ResponseWriter, r *http.Request) {
+		// Continue the caller's trace when it sent a valid W3C traceparent
+		parent, _ := extractTraceContext(r.Header)
+		span := t.startSpan(pattern, SpanKindServer, parent)
+		span.SetAttribute("http.route", pattern)
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.target", r.URL.Path)
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(recorder, r.WithContext(ContextWithSpan(r.Context(), span)))
+		span.SetAttribute("http.status_code", recorder.status)
+	}
+}
+
+// Transport records a client Span and injects the W3C trace context for every outbound request
+func (t *otelTracer) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		parent, _ := SpanFromContext(r.Context()).(*otelSpan)
+		if parent == nil {
+			return base.RoundTrip(r)
+		}
+
+		span := t.startSpan(r.Method+" "+r.URL.Host, SpanKindClient, parent.spanContext())
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.url", r.URL.String())
+		defer span.End()
+
+		// The http.RoundTripper contract forbids modifying the original request
+		outbound := r.Clone(r.Context())
+		injectTraceContext(outbound.Header, span.spanContext())
+
+		resp, err := base.RoundTrip(outbound)
+		if err != nil {
+			span.RecordError(err)
+			return resp, err
+		}
+
+		span.SetAttribute("http.status_code", resp.StatusCode)
+		return resp, nil
+	})
+}
+
+func (t *otelTracer) Shutdown() error {
+	return t.exporter.Shutdown()
+}
+
+func randomID(size int) string {
+	id := make([]byte, size)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
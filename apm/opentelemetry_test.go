@@ -0,0 +1,163 @@
+package apm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type recordingExporter struct {
+	spans []SpanData
+}
+
+func (e *recordingExporter) ExportSpans(spans []SpanData) error {
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *recordingExporter) Shutdown() error {
+	return nil
+}
+
+func TestOpenTelemetryServerAndClientSpans(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "OK")
+	}))
+	defer ts.Close()
+
+	exporter := &recordingExporter{}
+	tracer := NewOpenTelemetryTracer("test", exporter)
+
+	_, handler := tracer.WrapHandleFunc("/testPath", func(w http.ResponseWriter, r *http.Request) {
+		_, span := StartSpan(r.Context(), "work")
+		span.SetAttribute("airportCode", "SEA")
+		span.End()
+
+		client := &http.Client{Transport: tracer.Transport(nil)}
+		req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+		resp, err := client.Do(req.WithContext(r.Context()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	r, _ := http.NewRequest(http.MethodGet, "/testPath", nil)
+	handler(httptest.NewRecorder(), r)
+
+	if len(exporter.spans) != 3 {
+		t.Fatalf("Tracer exported wrong number of spans: got %v want %v", len(exporter.spans), 3)
+	}
+
+	work, client, server := exporter.spans[0], exporter.spans[1], exporter.spans[2]
+	if server.Kind != SpanKindServer || server.Attributes["http.status_code"] != http.StatusTeapot {
+		t.Errorf("Tracer exported unexpected server span: got %v", server)
+	}
+
+	if work.ParentSpanID != server.SpanID || work.Attributes["airportCode"] != "SEA" {
+		t.Errorf("Tracer exported unexpected internal span: got %v", work)
+	}
+
+	if client.Kind != SpanKindClient || client.ParentSpanID != server.SpanID || client.TraceID != server.TraceID {
+		t.Errorf("Tracer exported unexpected client span: got %v", client)
+	}
+}
+
+func TestOpenTelemetryContinuesInboundTrace(t *testing.T) {
+	var upstream http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstream = r.Header
+	}))
+	defer ts.Close()
+
+	exporter := &recordingExporter{}
+	tracer := NewOpenTelemetryTracer("test", exporter)
+
+	_, handler := tracer.WrapHandleFunc("/testPath", func(w http.ResponseWriter, r *http.Request) {
+		client := &http.Client{Transport: tracer.Transport(nil)}
+		req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+		resp, err := client.Do(req.WithContext(r.Context()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if req.Header.Get(TraceparentHeader) != "" {
+			t.Errorf("Transport modified the original request!")
+		}
+	})
+
+	r, _ := http.NewRequest(http.MethodGet, "/testPath", nil)
+	r.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Header.Set(TracestateHeader, "congo=t61rcWkgMzE")
+	handler(httptest.NewRecorder(), r)
+
+	client, server := exporter.spans[0], exporter.spans[1]
+	if server.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || server.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("Tracer did not continue the inbound trace: got %v", server)
+	}
+
+	expected := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + client.SpanID + "-01"
+	if upstream.Get(TraceparentHeader) != expected {
+		t.Errorf("Transport injected wrong traceparent: got %v want %v", upstream.Get(TraceparentHeader), expected)
+	}
+
+	if upstream.Get(TracestateHeader) != "congo=t61rcWkgMzE" {
+		t.Errorf("Transport injected wrong tracestate: got %v", upstream.Get(TracestateHeader))
+	}
+}
+
+func TestOpenTelemetryUnsampledTraceNotExported(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewOpenTelemetryTracer("test", exporter)
+
+	_, handler := tracer.WrapHandleFunc("/testPath", func(w http.ResponseWriter, r *http.Request) {
+		_, span := StartSpan(r.Context(), "work")
+		span.End()
+	})
+
+	r, _ := http.NewRequest(http.MethodGet, "/testPath", nil)
+	r.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	handler(httptest.NewRecorder(), r)
+
+	if len(exporter.spans) != 0 {
+		t.Errorf("Tracer exported an unsampled trace: got %v", exporter.spans)
+	}
+}
+
+func TestOpenTelemetrySpanEndsOnce(t *testing.T) {
+	exporter := &recordingExporter{}
+	span := NewOpenTelemetryTracer("test", exporter).(*otelTracer).startSpan("once", SpanKindInternal, spanContext{})
+
+	span.RecordError(errors.New("failed"))
+	span.End()
+	span.End()
+
+	if len(exporter.spans) != 1 || exporter.spans[0].Error != "failed" {
+		t.Errorf("Span exported unexpectedly: got %v", exporter.spans)
+	}
+}
+
+func TestWriterExporter(t *testing.T) {
+	var output bytes.Buffer
+	exporter := NewWriterExporter(&output)
+
+	exporter.ExportSpans([]SpanData{{Name: "first"}, {Name: "second"}})
+
+	decoder := json.NewDecoder(&output)
+	for _, expected := range []string{"first", "second"} {
+		var span SpanData
+		if err := decoder.Decode(&span); err != nil {
+			t.Fatal(err)
+		}
+		if span.Name != expected {
+			t.Errorf("Exporter wrote wrong span: got %v want %v", span.Name, expected)
+		}
+	}
+}
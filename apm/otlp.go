@@ -0,0 +1,265 @@
+package apm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLPTracesPath appended to the collector endpoint for OTLP/HTTP
+const OTLPTracesPath = "/v1/traces"
+
+// OTLP span kinds
+var otlpKinds = map[string]int{
+	SpanKindInternal: 1,
+	SpanKindServer:   2,
+	SpanKindClient:   3,
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	TraceState        string          `json:"traceState,omitempty"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpExporter struct {
+	url    string
+	client *http.Client
+}
+
+// NewOTLPExporter posting OTLP/HTTP JSON to the collector endpoint
+func NewOTLPExporter(endpoint string) Exporter {
+	return &otlpExporter{
+		url:    strings.TrimRight(endpoint, "/") + OTLPTracesPath,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (e *otlpExporter) ExportSpans(spans []SpanData) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(newOTLPRequest(spans))
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("OTLP collector responded %v", resp.Status)
+	}
+
+	return nil
+}
+
+func (e *otlpExporter) Shutdown() error {
+	return nil
+}
+
+func newOTLPRequest(spans []SpanData) otlpRequest {
+	byService := map[string]int{}
+	request := otlpRequest{}
+
+	for _, span := range spans {
+		index, found := byService[span.ServiceName]
+		if !found {
+			resource := otlpResourceSpans{ScopeSpans: []otlpScopeSpans{{}}}
+			resource.Resource.Attributes = []otlpAttribute{newOTLPAttribute("service.name", span.ServiceName)}
+			resource.ScopeSpans[0].Scope.Name = "github.com/dukeluke16/sample-golang-webservice/apm"
+
+			index = len(request.ResourceSpans)
+			byService[span.ServiceName] = index
+			request.ResourceSpans = append(request.ResourceSpans, resource)
+		}
+
+		scope := &request.ResourceSpans[index].ScopeSpans[0]
+		scope.Spans = append(scope.Spans, newOTLPSpan(span))
+	}
+
+	return request
+}
+
+func newOTLPSpan(span SpanData) otlpSpan {
+	converted := otlpSpan{
+		TraceID:           span.TraceID,
+		SpanID:            span.SpanID,
+		TraceState:        span.TraceState,
+		ParentSpanID:      span.ParentSpanID,
+		Name:              span.Name,
+		Kind:              otlpKinds[span.Kind],
+		StartTimeUnixNano: strconv.FormatInt(span.StartTime.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.EndTime.UnixNano(), 10),
+	}
+
+	for key, value := range span.Attributes {
+		converted.Attributes = append(converted.Attributes, newOTLPAttribute(key, value))
+	}
+
+	if len(span.Error) != 0 {
+		converted.Status = otlpStatus{Code: 2, Message: span.Error}
+	}
+
+	return converted
+}
+
+func newOTLPAttribute(key string, value interface{}) otlpAttribute {
+	attribute := otlpAttribute{Key: key}
+
+	switch v := value.(type) {
+	case bool:
+		attribute.Value.BoolValue = &v
+	case int:
+		converted := strconv.Itoa(v)
+		attribute.Value.IntValue = &converted
+	case int64:
+		converted := strconv.FormatInt(v, 10)
+		attribute.Value.IntValue = &converted
+	case float64:
+		attribute.Value.DoubleValue = &v
+	case string:
+		attribute.Value.StringValue = &v
+	default:
+		converted := fmt.Sprint(v)
+		attribute.Value.StringValue = &converted
+	}
+
+	return attribute
+}
+
+// batchQueueSizes of Spans buffered, in batch sizes, while an export is in flight; Spans beyond it are dropped
+const batchQueueSizes = 4
+
+// errBatchQueueFull when Spans are dropped rather than wait for an export
+var errBatchQueueFull = errors.New("span export queue is full")
+
+type batchExporter struct {
+	next     Exporter
+	size     int
+	mu       sync.Mutex
+	buffer   []SpanData
+	full     chan struct{}
+	done     chan struct{}
+	finished sync.WaitGroup
+}
+
+// NewBatchExporter buffering Spans for the next Exporter until size is reached or interval passes; exports run in the background, never on the goroutine ending a Span
+func NewBatchExporter(next Exporter, size int, interval time.Duration) Exporter {
+	e := &batchExporter{next: next, size: size, full: make(chan struct{}, 1), done: make(chan struct{})}
+
+	e.finished.Add(1)
+	go func() {
+		defer e.finished.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				e.flush()
+			case <-e.full:
+				e.flush()
+			case <-e.done:
+				return
+			}
+		}
+	}()
+
+	return e
+}
+
+func (e *batchExporter) ExportSpans(spans []SpanData) error {
+	e.mu.Lock()
+	if len(e.buffer)+len(spans) > e.size*batchQueueSizes {
+		e.mu.Unlock()
+		return errBatchQueueFull
+	}
+	e.buffer = append(e.buffer, spans...)
+	full := len(e.buffer) >= e.size
+	e.mu.Unlock()
+
+	// Wake the background export without waiting for it, a wake up already pending covers this batch too
+	if full {
+		select {
+		case e.full <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (e *batchExporter) flush() error {
+	e.mu.Lock()
+	spans := e.buffer
+	e.buffer = nil
+	e.mu.Unlock()
+
+	return e.next.ExportSpans(spans)
+}
+
+// Shutdown stops the interval flush and exports any buffered Spans
+func (e *batchExporter) Shutdown() error {
+	close(e.done)
+	e.finished.Wait()
+
+	if err := e.flush(); err != nil {
+		return err
+	}
+
+	return e.next.Shutdown()
+}
@@ -0,0 +1,171 @@
+package apm
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOTLPExporter(t *testing.T) {
+	var received otlpRequest
+	var path string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer ts.Close()
+
+	started := time.Unix(1500000000, 0)
+	exporter := NewOTLPExporter(ts.URL + "/")
+	err := exporter.ExportSpans([]SpanData{{
+		ServiceName:  "test",
+		TraceID:      "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:       "00f067aa0ba902b7",
+		ParentSpanID: "a3ce929d0e0e4736",
+		Name:         "checkAirportLocationCode",
+		Kind:         SpanKindClient,
+		StartTime:    started,
+		EndTime:      started.Add(time.Second),
+		Attributes:   map[string]interface{}{"http.status_code": 200},
+		Error:        "failed",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if path != OTLPTracesPath {
+		t.Errorf("Exporter posted to wrong path: got %v want %v", path, OTLPTracesPath)
+	}
+
+	if len(received.ResourceSpans) != 1 || *received.ResourceSpans[0].Resource.Attributes[0].Value.StringValue != "test" {
+		t.Fatalf("Exporter posted unexpected resource: got %v", received)
+	}
+
+	span := received.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if span.Kind != 3 || span.StartTimeUnixNano != "1500000000000000000" || span.Status.Code != 2 {
+		t.Errorf("Exporter posted unexpected span: got %v", span)
+	}
+
+	if *span.Attributes[0].Value.IntValue != "200" {
+		t.Errorf("Exporter posted unexpected attribute: got %v", span.Attributes[0])
+	}
+}
+
+func TestOTLPExporterCollectorError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	err := NewOTLPExporter(ts.URL).ExportSpans([]SpanData{{Name: "span"}})
+	if err == nil {
+		t.Errorf("Exporter failed to detect a collector error!")
+	}
+}
+
+func TestNewOTLPRequestGroupsByService(t *testing.T) {
+	request := newOTLPRequest([]SpanData{
+		{ServiceName: "first", Name: "a"},
+		{ServiceName: "second", Name: "b"},
+		{ServiceName: "first", Name: "c"},
+	})
+
+	if len(request.ResourceSpans) != 2 || len(request.ResourceSpans[0].ScopeSpans[0].Spans) != 2 {
+		t.Errorf("newOTLPRequest grouped unexpectedly: got %v", request)
+	}
+}
+
+type failingExporter struct {
+	recordingExporter
+}
+
+func (e *failingExporter) ExportSpans(spans []SpanData) error {
+	e.recordingExporter.ExportSpans(spans)
+	return errors.New("collector unreachable")
+}
+
+// blockingExporter holding every export until released, reporting each export on exported
+type blockingExporter struct {
+	release  chan struct{}
+	exported chan []SpanData
+}
+
+func (e *blockingExporter) ExportSpans(spans []SpanData) error {
+	<-e.release
+	e.exported <- spans
+	return nil
+}
+
+func (e *blockingExporter) Shutdown() error {
+	return nil
+}
+
+func TestBatchExporterFlushesAtSize(t *testing.T) {
+	next := &blockingExporter{release: make(chan struct{}), exported: make(chan []SpanData, 10)}
+	exporter := NewBatchExporter(next, 2, time.Hour)
+
+	exporter.ExportSpans([]SpanData{{Name: "first"}})
+
+	// Reaching the batch size returns at once, while the export waits in the background
+	returned := make(chan error)
+	go func() { returned <- exporter.ExportSpans([]SpanData{{Name: "second"}}) }()
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatalf("Exporter blocked the caller on an export!")
+	}
+
+	close(next.release)
+	select {
+	case spans := <-next.exported:
+		if len(spans) != 2 {
+			t.Errorf("Exporter did not flush at the batch size: got %v", spans)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Exporter did not flush at the batch size!")
+	}
+
+	exporter.Shutdown()
+}
+
+func TestBatchExporterDropsWhenQueueFull(t *testing.T) {
+	next := &blockingExporter{release: make(chan struct{}), exported: make(chan []SpanData, 100)}
+	exporter := NewBatchExporter(next, 1, time.Hour)
+
+	// With the first export stuck, the queue fills up and further Spans are dropped
+	dropped := 0
+	for i := 0; i < 10; i++ {
+		if exporter.ExportSpans([]SpanData{{Name: "span"}}) == errBatchQueueFull {
+			dropped++
+		}
+	}
+	if dropped == 0 {
+		t.Errorf("Exporter queued Spans without bound!")
+	}
+
+	close(next.release)
+	exporter.Shutdown()
+}
+
+func TestBatchExporterFlushesOnShutdown(t *testing.T) {
+	next := &failingExporter{}
+	exporter := NewBatchExporter(next, 100, time.Hour)
+
+	exporter.ExportSpans([]SpanData{{Name: "buffered"}})
+	if err := exporter.Shutdown(); err == nil {
+		t.Errorf("Exporter did not report the flush error!")
+	}
+
+	if len(next.spans) != 1 {
+		t.Errorf("Exporter did not flush on shutdown: got %v", next.spans)
+	}
+}
+
+func TestNewExporterUnknown(t *testing.T) {
+	if _, err := New(Options{Provider: ProviderOpenTelemetry, Exporter: "zipkin"}); err == nil {
+		t.Errorf("New failed to detect an unknown exporter!")
+	}
+}
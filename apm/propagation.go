@@ -0,0 +1,75 @@
+package apm
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// W3C Trace Context headers
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+// spanContext propagated between services
+type spanContext struct {
+	TraceID    string
+	SpanID     string
+	Sampled    bool
+	TraceState string
+}
+
+// extractTraceContext parses the W3C traceparent and tracestate headers
+func extractTraceContext(h http.Header) (spanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(h.Get(TraceparentHeader)), "-")
+	if len(parts) < 4 {
+		return spanContext{}, false
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+
+	// Version ff is forbidden and version 00 carries exactly four fields
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return spanContext{}, false
+	}
+
+	if !isHex(traceID, 32) || !isHex(spanID, 16) || !isHex(flags, 2) || isZero(traceID) || isZero(spanID) {
+		return spanContext{}, false
+	}
+
+	flagBits, _ := hex.DecodeString(flags)
+	return spanContext{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		Sampled:    flagBits[0]&0x01 == 0x01,
+		TraceState: strings.TrimSpace(strings.Join(h[http.CanonicalHeaderKey(TracestateHeader)], ",")),
+	}, true
+}
+
+// injectTraceContext writes the W3C traceparent and tracestate headers
+func injectTraceContext(h http.Header, sc spanContext) {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+
+	h.Set(TraceparentHeader, fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags))
+	if len(sc.TraceState) != 0 {
+		h.Set(TracestateHeader, sc.TraceState)
+	}
+}
+
+func isHex(value string, size int) bool {
+	if len(value) != size || strings.ToLower(value) != value {
+		return false
+	}
+
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
+
+func isZero(value string) bool {
+	return strings.Trim(value, "0") == ""
+}
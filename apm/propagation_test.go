@@ -0,0 +1,86 @@
+package apm
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestExtractTraceContext(t *testing.T) {
+	h := http.Header{}
+	h.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.Add(TracestateHeader, "congo=t61rcWkgMzE")
+	h.Add(TracestateHeader, "rojo=00f067aa0ba902b7")
+
+	sc, ok := extractTraceContext(h)
+	if !ok {
+		t.Fatal("extractTraceContext rejected a valid traceparent!")
+	}
+
+	if sc.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID != "00f067aa0ba902b7" || !sc.Sampled {
+		t.Errorf("extractTraceContext returned unexpected context: got %v", sc)
+	}
+
+	if sc.TraceState != "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7" {
+		t.Errorf("extractTraceContext returned wrong tracestate: got %v", sc.TraceState)
+	}
+}
+
+func TestExtractTraceContextNotSampled(t *testing.T) {
+	h := http.Header{}
+	h.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+	sc, ok := extractTraceContext(h)
+	if !ok || sc.Sampled {
+		t.Errorf("extractTraceContext returned unexpected context: got %v", sc)
+	}
+}
+
+func TestExtractTraceContextInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz",
+	}
+
+	for _, traceparent := range invalid {
+		h := http.Header{}
+		h.Set(TraceparentHeader, traceparent)
+		if _, ok := extractTraceContext(h); ok {
+			t.Errorf("extractTraceContext accepted an invalid traceparent: %v", traceparent)
+		}
+	}
+}
+
+func TestExtractTraceContextFutureVersion(t *testing.T) {
+	h := http.Header{}
+	h.Set(TraceparentHeader, "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future")
+
+	if _, ok := extractTraceContext(h); !ok {
+		t.Errorf("extractTraceContext rejected a future version traceparent!")
+	}
+}
+
+func TestInjectTraceContext(t *testing.T) {
+	h := http.Header{}
+	injectTraceContext(h, spanContext{
+		TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:     "00f067aa0ba902b7",
+		Sampled:    true,
+		TraceState: "congo=t61rcWkgMzE",
+	})
+
+	expected := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	if h.Get(TraceparentHeader) != expected {
+		t.Errorf("injectTraceContext wrote wrong traceparent: got %v want %v", h.Get(TraceparentHeader), expected)
+	}
+
+	if h.Get(TracestateHeader) != "congo=t61rcWkgMzE" {
+		t.Errorf("injectTraceContext wrote wrong tracestate: got %v", h.Get(TracestateHeader))
+	}
+}
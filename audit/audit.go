@@ -0,0 +1,181 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Actions recorded for policy documents
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	// ActionDraft, ActionDiscard, and ActionPublish of a draft, which is only served to previews until it is published
+	ActionDraft   = "draft"
+	ActionDiscard = "discard"
+	ActionPublish = "publish"
+	// ActionFailed voids the entry of the same request whose change was recorded but then failed to be written
+	ActionFailed = "failed"
+)
+
+// Entry of the audit log for one change
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Actor      string    `json:"actor"`
+	AuthMethod string    `json:"authMethod,omitempty"`
+	RequestID  string    `json:"requestId,omitempty"`
+	Action     string    `json:"action"`
+	Resource   string    `json:"resource"`
+	Locale     string    `json:"locale,omitempty"`
+	Diff       string    `json:"diff"`
+	// Failed action and its Error, for ActionFailed
+	Failed string `json:"failed,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Log appending one JSON line per Entry to a file it never rewrites
+type Log struct {
+	mu   sync.Mutex
+	path string
+	now  func() time.Time
+}
+
+// NewLog appending to the file at path, created on first use
+func NewLog(path string) *Log {
+	return &Log{path: path, now: time.Now}
+}
+
+// Path of the log file
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Record the entry, stamped with the current time, returning once it is synced to disk
+func (l *Log) Record(entry Entry) error {
+	entry.Time = l.now().UTC()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// The file is opened for each entry so it may be rotated by moving it aside
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(append(line, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// maxDiffCells of the longest common subsequence table, bounding the memory and time a diff may take
+const maxDiffCells = 1 << 20
+
+// Diff of the lines of before and after in the unified format, without context lines
+func Diff(before []byte, after []byte) string {
+	a, b := lines(before), lines(after)
+
+	// Lines common to both ends are never part of a hunk, so they are left out of the table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	a, b = a[prefix:], b[prefix:]
+	for len(a) != 0 && len(b) != 0 && a[len(a)-1] == b[len(b)-1] {
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	var diff strings.Builder
+	if len(a)*len(b) > maxDiffCells {
+		// Too large to compare line by line, the changed lines are replaced in one hunk
+		writeHunk(&diff, prefix, prefix, a, b)
+		return diff.String()
+	}
+
+	// lengths[i][j] of the longest common subsequence of a[i:] and b[j:]
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		if i < len(a) && j < len(b) && a[i] == b[j] {
+			i, j = i+1, j+1
+			continue
+		}
+
+		// Collect the hunk of removed and added lines up to the next common line
+		startA, startB := i, j
+		for i < len(a) || j < len(b) {
+			if i < len(a) && j < len(b) && a[i] == b[j] {
+				break
+			}
+			if j == len(b) || i < len(a) && lengths[i+1][j] >= lengths[i][j+1] {
+				i++
+			} else {
+				j++
+			}
+		}
+
+		writeHunk(&diff, prefix+startA, prefix+startB, a[startA:i], b[startB:j])
+	}
+
+	return diff.String()
+}
+
+// writeHunk replacing the removed lines, from the zero based startA of before, with the added ones, from startB of after
+func writeHunk(diff *strings.Builder, startA int, startB int, removed []string, added []string) {
+	fmt.Fprintf(diff, "@@ -%s +%s @@\n", hunkRange(startA, len(removed)), hunkRange(startB, len(added)))
+	for _, line := range removed {
+		diff.WriteString("-" + line + "\n")
+	}
+	for _, line := range added {
+		diff.WriteString("+" + line + "\n")
+	}
+}
+
+// hunkRange of count lines from the zero based start, as line numbers from one
+func hunkRange(start int, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
+
+func lines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
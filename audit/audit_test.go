@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "audit")
+	defer os.RemoveAll(dir)
+
+	l := NewLog(filepath.Join(dir, "audit.log"))
+	l.now = func() time.Time { return time.Date(2026, 10, 19, 9, 30, 0, 0, time.UTC) }
+
+	for _, action := range []string{ActionCreate, ActionDelete} {
+		if err := l.Record(Entry{Actor: "content-editor", Action: action, Resource: "hazardousgoods", Locale: "fr", Diff: "+[]\n"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, _ := ioutil.ReadFile(l.Path())
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Record did not append one line per entry: got %v", lines)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Entry{Time: l.now(), Actor: "content-editor", Action: ActionDelete, Resource: "hazardousgoods", Locale: "fr", Diff: "+[]\n"}
+	if entry != expected {
+		t.Errorf("Record wrote wrong entry: got %+v want %+v", entry, expected)
+	}
+
+	if info, _ := os.Stat(l.Path()); info.Mode().Perm() != 0600 {
+		t.Errorf("Record created the log with wrong permissions: got %v", info.Mode().Perm())
+	}
+}
+
+func TestRecordError(t *testing.T) {
+	l := NewLog(filepath.Join("missing", "folder", "audit.log"))
+	if err := l.Record(Entry{Actor: "content-editor", Action: ActionCreate}); err == nil {
+		t.Errorf("Record should fail when the log cannot be opened!")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		before   string
+		after    string
+		expected string
+	}{
+		{"a\nb\nc\n", "a\nb\nc\n", ""},
+		{"", "a\nb\n", "@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"a\nb\n", "", "@@ -1,2 +0,0 @@\n-a\n-b\n"},
+		{"a\nb\nc\n", "a\nB\nc\n", "@@ -2 +2 @@\n-b\n+B\n"},
+		{"a\nb\nc\nd\n", "a\nc\nd\ne\n", "@@ -2 +1,0 @@\n-b\n@@ -4,0 +4 @@\n+e\n"},
+	}
+	for _, test := range tests {
+		if diff := Diff([]byte(test.before), []byte(test.after)); diff != test.expected {
+			t.Errorf("Diff returned wrong diff of %q and %q: got %q want %q", test.before, test.after, diff, test.expected)
+		}
+	}
+}
+
+func TestDiffLarge(t *testing.T) {
+	var before, after strings.Builder
+	before.WriteString("first\n")
+	after.WriteString("first\n")
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&before, "before %d\n", i)
+		fmt.Fprintf(&after, "after %d\n", i)
+	}
+	before.WriteString("last\n")
+	after.WriteString("last\n")
+
+	// Beyond maxDiffCells the changed lines are replaced in one hunk
+	diff := Diff([]byte(before.String()), []byte(after.String()))
+	if !strings.HasPrefix(diff, "@@ -2,2000 +2,2000 @@\n-before 0\n") || strings.Count(diff, "\n") != 4001 || !strings.HasSuffix(diff, "+after 1999\n") {
+		t.Errorf("Diff returned wrong diff of large documents: got %q", diff[:64])
+	}
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// APIKeyHeader carrying the client API key
+const APIKeyHeader = "X-Api-Key"
+
+// hashPrefix of the hashes stored in the API key file
+const hashPrefix = "sha256:"
+
+// APIKey entry of the API key file; only the hash of the key is stored
+type APIKey struct {
+	Name string `json:"name"`
+	Hash string `json:"hash"`
+	Tier string `json:"tier,omitempty"`
+}
+
+// HashAPIKey for storage in the API key file
+func HashAPIKey(key string) string {
+	digest := sha256.Sum256([]byte(key))
+	return hashPrefix + hex.EncodeToString(digest[:])
+}
+
+// APIKeyStore authenticating requests by the X-Api-Key header against hashed keys loaded from a file
+type APIKeyStore struct {
+	mu   sync.RWMutex
+	path string
+	keys map[string]APIKey
+}
+
+// NewAPIKeyStore loading the API key file
+func NewAPIKeyStore(path string) (*APIKeyStore, error) {
+	s := &APIKeyStore{path: path}
+	if err := s.Load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Path of the API key file
+func (s *APIKeyStore) Path() string {
+	return s.path
+}
+
+// Load the API key file, keeping the previous keys when it is invalid
+func (s *APIKeyStore) Load() error {
+	contents, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+
+	var entries []APIKey
+	if err := json.Unmarshal(contents, &entries); err != nil {
+		return fmt.Errorf("API key file %v: %v", s.path, err)
+	}
+
+	keys := make(map[string]APIKey, len(entries))
+	for _, entry := range entries {
+		hash := strings.ToLower(entry.Hash)
+		if !strings.HasPrefix(hash, hashPrefix) || len(hash) != len(hashPrefix)+sha256.Size*2 {
+			return fmt.Errorf("API key file %v: %v has no %v hash", s.path, entry.Name, hashPrefix)
+		}
+		if len(entry.Name) == 0 {
+			return errors.New("API key file " + s.path + ": every key needs a name")
+		}
+		keys[hash] = entry
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+	return nil
+}
+
+// Challenge naming the header carrying the API key
+func (s *APIKeyStore) Challenge() string {
+	return `ApiKey header="` + APIKeyHeader + `"`
+}
+
+// Authenticate the X-Api-Key header
+func (s *APIKeyStore) Authenticate(r *http.Request) (Identity, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if len(key) == 0 {
+		return Identity{}, ErrNoCredentials
+	}
+
+	s.mu.RLock()
+	entry, ok := s.keys[HashAPIKey(key)]
+	s.mu.RUnlock()
+	if !ok {
+		return Identity{}, errors.New("unknown API key")
+	}
+
+	tier := entry.Tier
+	if len(tier) == 0 {
+		tier = MethodAPIKey
+	}
+
+	return Identity{Subject: entry.Name, Method: MethodAPIKey, Tier: tier}, nil
+}
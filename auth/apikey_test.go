@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeAPIKeys(t *testing.T, contents string) string {
+	dir, err := ioutil.TempDir("", "apikeys")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "apikeys.json")
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func apiKeyRequest(key string) *http.Request {
+	r, _ := http.NewRequest(http.MethodPost, "/v1/policy/hazardousgoods/evaluate", nil)
+	if len(key) != 0 {
+		r.Header.Set(APIKeyHeader, key)
+	}
+
+	return r
+}
+
+func TestHashAPIKey(t *testing.T) {
+	expected := "sha256:2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b"
+	if hash := HashAPIKey("secret"); hash != expected {
+		t.Errorf("HashAPIKey returned wrong hash: got %v want %v", hash, expected)
+	}
+}
+
+func TestAPIKeyStore(t *testing.T) {
+	path := writeAPIKeys(t, `[
+		{"name": "booking-engine", "hash": "`+HashAPIKey("booking-secret")+`", "tier": "partner"},
+		{"name": "kiosk", "hash": "`+strings.ToUpper(HashAPIKey("kiosk-secret"))+`"}
+	]`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	store, err := NewAPIKeyStore(path)
+	if err != nil {
+		t.Fatalf("NewAPIKeyStore returned an error: %v", err)
+	}
+
+	expected := map[string]Identity{
+		"booking-secret": {Subject: "booking-engine", Method: MethodAPIKey, Tier: "partner"},
+		"kiosk-secret":   {Subject: "kiosk", Method: MethodAPIKey, Tier: MethodAPIKey},
+	}
+	for key, want := range expected {
+		if identity, err := store.Authenticate(apiKeyRequest(key)); err != nil || identity != want {
+			t.Errorf("Authenticate returned wrong Identity: got %+v %v want %+v", identity, err, want)
+		}
+	}
+
+	if _, err := store.Authenticate(apiKeyRequest("")); err != ErrNoCredentials {
+		t.Errorf("Authenticate returned wrong error: got %v want %v", err, ErrNoCredentials)
+	}
+
+	if _, err := store.Authenticate(apiKeyRequest("guess")); err == nil || err == ErrNoCredentials {
+		t.Errorf("Authenticate accepted an unknown API key: got %v", err)
+	}
+}
+
+func TestAPIKeyStoreReload(t *testing.T) {
+	path := writeAPIKeys(t, `[{"name": "kiosk", "hash": "`+HashAPIKey("old")+`"}]`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	store, _ := NewAPIKeyStore(path)
+	ioutil.WriteFile(path, []byte(`[{"name": "kiosk", "hash": "`+HashAPIKey("new")+`"}]`), 0600)
+	if err := store.Load(); err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+
+	if _, err := store.Authenticate(apiKeyRequest("old")); err == nil {
+		t.Errorf("Load kept a revoked API key!")
+	}
+
+	for _, invalid := range []string{`{`, `[{"name": "kiosk", "hash": "new"}]`, `[{"hash": "` + HashAPIKey("x") + `"}]`} {
+		ioutil.WriteFile(path, []byte(invalid), 0600)
+		if err := store.Load(); err == nil {
+			t.Errorf("Load accepted an invalid file %v", invalid)
+		}
+	}
+
+	if _, err := store.Authenticate(apiKeyRequest("new")); err != nil {
+		t.Errorf("Load dropped the previous keys for an invalid file: %v", err)
+	}
+}
+
+func TestNewAPIKeyStoreMissingFile(t *testing.T) {
+	if _, err := NewAPIKeyStore("./missing.json"); err == nil {
+		t.Errorf("NewAPIKeyStore accepted a missing file!")
+	}
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Authentication Methods
+const (
+	MethodAPIKey = "apikey"
+	MethodJWT    = "jwt"
+)
+
+// ErrNoCredentials when the request carries no credentials for the Authenticator
+var ErrNoCredentials = errors.New("no credentials")
+
+// Identity of an authenticated client
+type Identity struct {
+	// Subject naming the client, never the secret it presented
+	Subject string
+	// Method the client authenticated with
+	Method string
+	// Tier of per-client configuration such as rate limits, defaulting to the Method
+	Tier string
+}
+
+// Authenticator of requests
+type Authenticator interface {
+	// Authenticate returns the Identity, ErrNoCredentials, or an error rejecting the credentials presented
+	Authenticate(r *http.Request) (Identity, error)
+	// Challenge for the WWW-Authenticate header of a rejected request
+	Challenge() string
+}
+
+// Chain of Authenticators, each tried in order until one finds credentials
+type Chain []Authenticator
+
+// Authenticate with the first Authenticator that finds credentials
+func (c Chain) Authenticate(r *http.Request) (Identity, error) {
+	for _, authenticator := range c {
+		identity, err := authenticator.Authenticate(r)
+		if err != ErrNoCredentials {
+			return identity, err
+		}
+	}
+
+	return Identity{}, ErrNoCredentials
+}
+
+// Challenge of every Authenticator in the Chain
+func (c Chain) Challenge() string {
+	challenges := make([]string, 0, len(c))
+	for _, authenticator := range c {
+		challenges = append(challenges, authenticator.Challenge())
+	}
+
+	return strings.Join(challenges, ", ")
+}
+
+type identityKey struct{}
+
+// NewContext returns a copy of ctx carrying the Identity
+func NewContext(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext returns the Identity carried by ctx, if any
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// Watch polls the file every interval and calls reload when its modification time changes; call stop to end polling
+func Watch(path string, interval time.Duration, reload func() error, onError func(error)) (stop func()) {
+	done := make(chan struct{})
+	modified := modTime(path)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if current := modTime(path); !current.Equal(modified) {
+					modified = current
+					if err := reload(); err != nil && onError != nil {
+						onError(err)
+					}
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
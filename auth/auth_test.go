@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type fakeAuthenticator struct {
+	identity  Identity
+	err       error
+	challenge string
+}
+
+func (f fakeAuthenticator) Authenticate(r *http.Request) (Identity, error) {
+	return f.identity, f.err
+}
+
+func (f fakeAuthenticator) Challenge() string {
+	return f.challenge
+}
+
+func TestChain(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+	accepted := fakeAuthenticator{identity: Identity{Subject: "booking-engine"}, challenge: "Bearer"}
+	rejected := fakeAuthenticator{err: errors.New("unknown API key"), challenge: "ApiKey"}
+	absent := fakeAuthenticator{err: ErrNoCredentials, challenge: "ApiKey"}
+
+	if identity, err := (Chain{absent, accepted}).Authenticate(r); err != nil || identity.Subject != "booking-engine" {
+		t.Errorf("Chain skipped an Authenticator that found credentials: got %+v %v", identity, err)
+	}
+
+	if _, err := (Chain{rejected, accepted}).Authenticate(r); err == nil || err == ErrNoCredentials {
+		t.Errorf("Chain should stop at rejected credentials: got %v", err)
+	}
+
+	if _, err := (Chain{absent}).Authenticate(r); err != ErrNoCredentials {
+		t.Errorf("Chain returned wrong error: got %v want %v", err, ErrNoCredentials)
+	}
+
+	if challenge := (Chain{absent, accepted}).Challenge(); challenge != "ApiKey, Bearer" {
+		t.Errorf("Chain returned wrong challenge: got %v want %v", challenge, "ApiKey, Bearer")
+	}
+}
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Errorf("FromContext returned an Identity for an unauthenticated context!")
+	}
+
+	expected := Identity{Subject: "booking-engine", Method: MethodAPIKey, Tier: "partner"}
+	if identity, ok := FromContext(NewContext(context.Background(), expected)); !ok || identity != expected {
+		t.Errorf("FromContext returned wrong Identity: got %+v want %+v", identity, expected)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "auth")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "keys.json")
+	ioutil.WriteFile(path, []byte("[]"), 0600)
+
+	reloaded := make(chan struct{}, 1)
+	stop := Watch(path, 10*time.Millisecond, func() error {
+		reloaded <- struct{}{}
+		return nil
+	}, nil)
+	defer stop()
+
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Minute))
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second):
+		t.Errorf("Watch did not reload the changed file!")
+	}
+}
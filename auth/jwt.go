@@ -0,0 +1,278 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clockSkew tolerated when checking exp and nbf
+const clockSkew = 30 * time.Second
+
+// JWTOptions for a JWTVerifier
+type JWTOptions struct {
+	// JWKSPath of the local JWKS file holding the signing keys
+	JWKSPath string
+	// Issuer required in the iss claim
+	Issuer string
+	// Audience required in the aud claim
+	Audience string
+	// TierClaim naming the client tier, defaulting to the jwt tier when absent
+	TierClaim string
+}
+
+// JWTVerifier authenticating Bearer tokens signed with RS256 or ES256 by a key of the JWKS file
+type JWTVerifier struct {
+	mu      sync.RWMutex
+	options JWTOptions
+	keys    map[string]crypto.PublicKey
+	now     func() time.Time
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// NewJWTVerifier loading the JWKS file
+func NewJWTVerifier(options JWTOptions) (*JWTVerifier, error) {
+	if len(options.Issuer) == 0 || len(options.Audience) == 0 {
+		return nil, fmt.Errorf("JWT verification requires an issuer and an audience")
+	}
+
+	v := &JWTVerifier{options: options, now: time.Now}
+	if err := v.Load(); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Path of the JWKS file
+func (v *JWTVerifier) Path() string {
+	return v.options.JWKSPath
+}
+
+// Load the JWKS file, keeping the previous keys when it is invalid
+func (v *JWTVerifier) Load() error {
+	contents, err := ioutil.ReadFile(v.options.JWKSPath)
+	if err != nil {
+		return err
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(contents, &jwks); err != nil {
+		return fmt.Errorf("JWKS file %v: %v", v.options.JWKSPath, err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			return fmt.Errorf("JWKS file %v: key %v: %v", v.options.JWKSPath, jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.mu.Unlock()
+	return nil
+}
+
+// Challenge for a Bearer token
+func (v *JWTVerifier) Challenge() string {
+	return "Bearer"
+}
+
+// Authenticate the Authorization Bearer token
+func (v *JWTVerifier) Authenticate(r *http.Request) (Identity, error) {
+	authorization := r.Header.Get("Authorization")
+	if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+		return Identity{}, ErrNoCredentials
+	}
+
+	claims, err := v.verify(strings.TrimSpace(authorization[7:]))
+	if err != nil {
+		return Identity{}, err
+	}
+
+	subject, _ := claims["sub"].(string)
+	if len(subject) == 0 {
+		return Identity{}, fmt.Errorf("token has no subject")
+	}
+
+	tier := MethodJWT
+	if value, ok := claims[v.options.TierClaim].(string); ok && len(v.options.TierClaim) != 0 && len(value) != 0 {
+		tier = value
+	}
+
+	return Identity{Subject: subject, Method: MethodJWT, Tier: tier}, nil
+}
+
+func (v *JWTVerifier) verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token is not a JWS compact serialization")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("token header: %v", err)
+	}
+
+	v.mu.RLock()
+	key, ok := v.keys[header.Kid]
+	v.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("token key %q is not in the JWKS", header.Kid)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("token signature: %v", err)
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := verifySignature(header.Alg, key, digest[:], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("token claims: %v", err)
+	}
+
+	return claims, v.checkClaims(claims)
+}
+
+func verifySignature(alg string, key crypto.PublicKey, digest []byte, signature []byte) error {
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest, signature) != nil {
+			return fmt.Errorf("token signature is invalid")
+		}
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return fmt.Errorf("token signature is invalid")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return fmt.Errorf("token signature is invalid")
+		}
+	default:
+		return fmt.Errorf("token algorithm %q is not supported", alg)
+	}
+
+	return nil
+}
+
+func (v *JWTVerifier) checkClaims(claims map[string]interface{}) error {
+	now := v.now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("token has no expiry")
+	}
+	if now.Add(-clockSkew).After(time.Unix(int64(exp), 0)) {
+		return fmt.Errorf("token has expired")
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(clockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("token is not valid yet")
+	}
+
+	if claims["iss"] != v.options.Issuer {
+		return fmt.Errorf("token issuer %v is not trusted", claims["iss"])
+	}
+
+	if !hasAudience(claims["aud"], v.options.Audience) {
+		return fmt.Errorf("token audience %v does not include %v", claims["aud"], v.options.Audience)
+	}
+
+	return nil
+}
+
+// hasAudience for an aud claim that is either a string or an array of strings
+func hasAudience(aud interface{}, audience string) bool {
+	switch value := aud.(type) {
+	case string:
+		return value == audience
+	case []interface{}:
+		for _, item := range value {
+			if item == audience {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(decoded, v)
+}
+
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return nil, fmt.Errorf("curve %v is not supported", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("key type %v is not supported", jwk.Kty)
+	}
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var (
+	testRSAKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	testECKey, _  = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testNow       = time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func encodeSegment(v interface{}) string {
+	segment, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(segment)
+}
+
+func signToken(alg string, kid string, claims map[string]interface{}) string {
+	signingInput := encodeSegment(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + encodeSegment(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, testRSAKey, crypto.SHA256, digest[:])
+	case "ES256":
+		r, s, _ := ecdsa.Sign(rand.Reader, testECKey, digest[:])
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func testClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub": "booking-engine",
+		"iss": "https://issuer.example.com",
+		"aud": "hazardousgoods",
+		"exp": testNow.Add(time.Hour).Unix(),
+	}
+}
+
+func newTestVerifier(t *testing.T) *JWTVerifier {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	jwks := map[string]interface{}{"keys": []map[string]string{
+		{"kid": "rsa-1", "kty": "RSA", "use": "sig", "n": encode(testRSAKey.N.Bytes()), "e": encode(big.NewInt(int64(testRSAKey.E)).Bytes())},
+		{"kid": "ec-1", "kty": "EC", "crv": "P-256", "x": encode(testECKey.X.Bytes()), "y": encode(testECKey.Y.Bytes())},
+		{"kid": "enc-1", "kty": "oct", "use": "enc"},
+	}}
+
+	dir, _ := ioutil.TempDir("", "jwks")
+	path := filepath.Join(dir, "jwks.json")
+	contents, _ := json.Marshal(jwks)
+	ioutil.WriteFile(path, contents, 0600)
+
+	v, err := NewJWTVerifier(JWTOptions{
+		JWKSPath:  path,
+		Issuer:    "https://issuer.example.com",
+		Audience:  "hazardousgoods",
+		TierClaim: "tier",
+	})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier returned an error: %v", err)
+	}
+	v.now = func() time.Time { return testNow }
+
+	return v
+}
+
+func bearerRequest(token string) *http.Request {
+	r, _ := http.NewRequest(http.MethodPost, "/v1/policy/hazardousgoods/evaluate", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func TestJWTVerifierAccepts(t *testing.T) {
+	v := newTestVerifier(t)
+	defer os.RemoveAll(filepath.Dir(v.Path()))
+
+	partner := testClaims()
+	partner["tier"] = "partner"
+	partner["aud"] = []string{"other", "hazardousgoods"}
+
+	tokens := map[string]Identity{
+		signToken("RS256", "rsa-1", testClaims()): {Subject: "booking-engine", Method: MethodJWT, Tier: MethodJWT},
+		signToken("ES256", "ec-1", testClaims()):  {Subject: "booking-engine", Method: MethodJWT, Tier: MethodJWT},
+		signToken("RS256", "rsa-1", partner):      {Subject: "booking-engine", Method: MethodJWT, Tier: "partner"},
+	}
+	for token, expected := range tokens {
+		if identity, err := v.Authenticate(bearerRequest(token)); err != nil || identity != expected {
+			t.Errorf("Authenticate returned wrong Identity: got %+v %v want %+v", identity, err, expected)
+		}
+	}
+}
+
+func TestJWTVerifierRejects(t *testing.T) {
+	v := newTestVerifier(t)
+	defer os.RemoveAll(filepath.Dir(v.Path()))
+
+	claims := func(key string, value interface{}) map[string]interface{} {
+		c := testClaims()
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+
+	valid := signToken("RS256", "rsa-1", testClaims())
+	tokens := map[string]string{
+		"expired":         signToken("RS256", "rsa-1", claims("exp", testNow.Add(-time.Minute).Unix())),
+		"no expiry":       signToken("RS256", "rsa-1", claims("exp", nil)),
+		"not yet valid":   signToken("RS256", "rsa-1", claims("nbf", testNow.Add(time.Minute).Unix())),
+		"wrong issuer":    signToken("RS256", "rsa-1", claims("iss", "https://attacker.example.com")),
+		"wrong audience":  signToken("RS256", "rsa-1", claims("aud", "other")),
+		"no subject":      signToken("RS256", "rsa-1", claims("sub", nil)),
+		"unknown key":     signToken("RS256", "rsa-2", testClaims()),
+		"encryption key":  signToken("RS256", "enc-1", testClaims()),
+		"key type":        signToken("ES256", "rsa-1", testClaims()),
+		"none algorithm":  encodeSegment(map[string]string{"alg": "none", "kid": "rsa-1"}) + "." + encodeSegment(testClaims()) + ".",
+		"tampered claims": valid[:len(valid)-4] + "AAAA",
+		"malformed":       "not-a-token",
+	}
+	for name, token := range tokens {
+		if _, err := v.Authenticate(bearerRequest(token)); err == nil || err == ErrNoCredentials {
+			t.Errorf("Authenticate accepted a token with %v: got %v", name, err)
+		}
+	}
+
+	r, _ := http.NewRequest(http.MethodPost, "/v1/policy/hazardousgoods/evaluate", nil)
+	r.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	if _, err := v.Authenticate(r); err != ErrNoCredentials {
+		t.Errorf("Authenticate returned wrong error: got %v want %v", err, ErrNoCredentials)
+	}
+}
+
+func TestJWTVerifierClockSkew(t *testing.T) {
+	v := newTestVerifier(t)
+	defer os.RemoveAll(filepath.Dir(v.Path()))
+
+	c := testClaims()
+	c["exp"] = testNow.Add(-clockSkew / 2).Unix()
+	c["nbf"] = testNow.Add(clockSkew / 2).Unix()
+	if _, err := v.Authenticate(bearerRequest(signToken("RS256", "rsa-1", c))); err != nil {
+		t.Errorf("Authenticate rejected a token within the clock skew: %v", err)
+	}
+}
+
+func TestNewJWTVerifierRequiresIssuerAndAudience(t *testing.T) {
+	if _, err := NewJWTVerifier(JWTOptions{JWKSPath: "./missing.json", Issuer: "https://issuer.example.com"}); err == nil {
+		t.Errorf("NewJWTVerifier accepted options without an audience!")
+	}
+}
@@ -0,0 +1,111 @@
+package circuit
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// State of the Breaker
+type State int
+
+// Breaker States
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+// ErrOpen returned while the Breaker rejects calls
+var ErrOpen = errors.New("circuit breaker is open")
+
+// String representation of the State
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker trips open after consecutive failures and allows a trial call once cooled down
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	state     State
+	openedAt  time.Time
+	now       func() time.Time
+}
+
+// NewBreaker for guarding calls to an unreliable dependency
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a call may proceed
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.currentState() {
+	case Open:
+		return false
+	case HalfOpen:
+		// Only a single trial call is let through while half-open
+		b.state = Open
+		b.openedAt = b.now()
+		return true
+	default:
+		return true
+	}
+}
+
+// Success records a successful call and closes the Breaker
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.state = Closed
+}
+
+// Failure records a failed call and trips the Breaker at the threshold
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.state = Open
+		b.openedAt = b.now()
+	}
+}
+
+// State of the Breaker at this moment
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.currentState()
+}
+
+func (b *Breaker) currentState() State {
+	if b.state == Open && b.now().Sub(b.openedAt) >= b.cooldown {
+		b.state = HalfOpen
+	}
+
+	return b.state
+}
@@ -0,0 +1,81 @@
+package circuit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerStartsClosed(t *testing.T) {
+	b := NewBreaker(2, time.Minute)
+
+	if b.State() != Closed {
+		t.Errorf("Breaker returned wrong state: got %v want %v", b.State(), Closed)
+	}
+
+	if !b.Allow() {
+		t.Errorf("Closed Breaker should allow calls!")
+	}
+}
+
+func TestBreakerTripsAtThreshold(t *testing.T) {
+	b := NewBreaker(2, time.Minute)
+
+	b.Failure()
+	if b.State() != Closed {
+		t.Errorf("Breaker returned wrong state: got %v want %v", b.State(), Closed)
+	}
+
+	b.Failure()
+	if b.State() != Open {
+		t.Errorf("Breaker returned wrong state: got %v want %v", b.State(), Open)
+	}
+
+	if b.Allow() {
+		t.Errorf("Open Breaker should reject calls!")
+	}
+}
+
+func TestBreakerSuccessResetsFailures(t *testing.T) {
+	b := NewBreaker(2, time.Minute)
+
+	b.Failure()
+	b.Success()
+	b.Failure()
+	if b.State() != Closed {
+		t.Errorf("Breaker returned wrong state: got %v want %v", b.State(), Closed)
+	}
+}
+
+func TestBreakerHalfOpenAfterCooldown(t *testing.T) {
+	current := time.Now()
+	b := NewBreaker(1, time.Minute)
+	b.now = func() time.Time { return current }
+
+	b.Failure()
+	current = current.Add(time.Minute)
+	if b.State() != HalfOpen {
+		t.Errorf("Breaker returned wrong state: got %v want %v", b.State(), HalfOpen)
+	}
+
+	if !b.Allow() {
+		t.Errorf("Half-open Breaker should allow a trial call!")
+	}
+
+	if b.Allow() {
+		t.Errorf("Half-open Breaker should allow only a single trial call!")
+	}
+
+	b.Success()
+	if b.State() != Closed {
+		t.Errorf("Breaker returned wrong state: got %v want %v", b.State(), Closed)
+	}
+}
+
+func TestStateString(t *testing.T) {
+	expected := map[State]string{Closed: "closed", Open: "open", HalfOpen: "half-open"}
+	for state, name := range expected {
+		if state.String() != name {
+			t.Errorf("State returned wrong name: got %v want %v", state.String(), name)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Content Encodings
+const (
+	EncodingBrotli   = "br"
+	EncodingGzip     = "gzip"
+	EncodingIdentity = "identity"
+)
+
+// brotliStreamingLevel trades ratio for speed on responses compressed per request
+const brotliStreamingLevel = 5
+
+// Negotiate the supported encoding with the highest Accept-Encoding quality, the earliest supported winning ties; identity when none is acceptable
+func Negotiate(acceptEncoding string, supported []string) string {
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		if len(name) == 0 {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		qualities[name] = quality
+	}
+
+	best, bestQuality := EncodingIdentity, 0.0
+	for _, encoding := range supported {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality = qualities["*"]
+		}
+		if quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+
+	return best
+}
+
+// AddVary adds Accept-Encoding to the Vary header unless already listed
+func AddVary(header http.Header) {
+	for _, value := range header["Vary"] {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), "Accept-Encoding") {
+				return
+			}
+		}
+	}
+
+	header.Add("Vary", "Accept-Encoding")
+}
+
+// Compressible reports whether the media type is text that compresses well
+func Compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/json" ||
+		mediaType == "application/javascript" ||
+		strings.HasSuffix(mediaType, "+json") ||
+		strings.HasSuffix(mediaType, "+xml")
+}
+
+// Encode data with the encoding at its best compression, for content compressed once and cached
+func Encode(encoding string, data []byte) []byte {
+	var buffer bytes.Buffer
+	var encoder io.WriteCloser
+	switch encoding {
+	case EncodingBrotli:
+		encoder = brotli.NewWriterLevel(&buffer, brotli.BestCompression)
+	case EncodingGzip:
+		encoder, _ = gzip.NewWriterLevel(&buffer, gzip.BestCompression)
+	default:
+		return data
+	}
+
+	encoder.Write(data)
+	encoder.Close()
+	return buffer.Bytes()
+}
+
+// Cache of encoded variants of static content, each replaced when its content version changes
+type Cache struct {
+	mu       sync.Mutex
+	variants map[string]variant
+}
+
+type variant struct {
+	version string
+	data    []byte
+}
+
+// NewCache without any variants
+func NewCache() *Cache {
+	return &Cache{variants: map[string]variant{}}
+}
+
+// Encoded variant of the content named key at version, encoding it on first use
+func (c *Cache) Encoded(key string, version string, encoding string, data []byte) []byte {
+	id := key + "|" + encoding
+
+	c.mu.Lock()
+	cached, ok := c.variants[id]
+	c.mu.Unlock()
+	if ok && cached.version == version {
+		return cached.data
+	}
+
+	encoded := Encode(encoding, data)
+	c.mu.Lock()
+	c.variants[id] = variant{version: version, data: encoded}
+	c.mu.Unlock()
+
+	return encoded
+}
+
+// Middleware compressing responses of handler with the negotiated encoding once they reach minSize bytes
+func Middleware(supported []string, minSize int, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(supported) == 0 || r.Method == http.MethodHead {
+			handler(w, r)
+			return
+		}
+
+		encoding := Negotiate(r.Header.Get("Accept-Encoding"), supported)
+		cw := &responseWriter{ResponseWriter: w, encoding: encoding, minSize: minSize, status: http.StatusOK}
+		defer cw.close()
+		handler(cw, r)
+	}
+}
+
+// responseWriter buffering the start of the body until it knows whether to compress it
+type responseWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+	status   int
+	buffer   []byte
+	encoder  io.WriteCloser
+	decided  bool
+}
+
+func (cw *responseWriter) WriteHeader(status int) {
+	if !cw.decided {
+		cw.status = status
+	}
+}
+
+func (cw *responseWriter) Write(b []byte) (int, error) {
+	if cw.decided {
+		if cw.encoder != nil {
+			return cw.encoder.Write(b)
+		}
+		return cw.ResponseWriter.Write(b)
+	}
+
+	cw.buffer = append(cw.buffer, b...)
+	if len(cw.buffer) >= cw.minSize {
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+// decide whether to compress, then write the status and the buffered body
+func (cw *responseWriter) decide() error {
+	cw.decided = true
+	header := cw.Header()
+
+	// Sniff the content type as net/http would, since it cannot see the body once it is compressed
+	contentType := header.Get("Content-Type")
+	if len(contentType) == 0 && len(cw.buffer) != 0 {
+		contentType = http.DetectContentType(cw.buffer)
+		header.Set("Content-Type", contentType)
+	}
+
+	if cw.status != http.StatusNoContent && Compressible(contentType) {
+		AddVary(header)
+
+		// Content the handler already encoded passes through untouched
+		if len(header.Get("Content-Encoding")) == 0 && cw.encoding != EncodingIdentity && len(cw.buffer) >= cw.minSize {
+			header.Set("Content-Encoding", cw.encoding)
+			header.Del("Content-Length")
+			cw.encoder = newEncoder(cw.encoding, cw.ResponseWriter)
+		}
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if len(cw.buffer) == 0 {
+		return nil
+	}
+
+	var err error
+	if cw.encoder != nil {
+		_, err = cw.encoder.Write(cw.buffer)
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buffer)
+	}
+	cw.buffer = nil
+	return err
+}
+
+func (cw *responseWriter) close() {
+	if !cw.decided {
+		cw.decide()
+	}
+
+	if cw.encoder != nil {
+		cw.encoder.Close()
+	}
+}
+
+func newEncoder(encoding string, w io.Writer) io.WriteCloser {
+	if encoding == EncodingBrotli {
+		return brotli.NewWriterLevel(w, brotliStreamingLevel)
+	}
+
+	encoder, _ := gzip.NewWriterLevel(w, gzip.DefaultCompression)
+	return encoder
+}
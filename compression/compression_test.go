@@ -0,0 +1,198 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+var text = strings.Repeat(`{"policy": "hazardous goods"}`, 64)
+
+func serve(acceptEncoding string, status int, contentType string, body string) *httptest.ResponseRecorder {
+	handler := Middleware([]string{EncodingBrotli, EncodingGzip}, 256, func(w http.ResponseWriter, r *http.Request) {
+		if len(contentType) != 0 {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	})
+
+	r, _ := http.NewRequest(http.MethodGet, "/v1/policies/hazardousgoods", nil)
+	if len(acceptEncoding) != 0 {
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func decode(t *testing.T, encoding string, data []byte) string {
+	var decoded []byte
+	var err error
+	switch encoding {
+	case EncodingBrotli:
+		decoded, err = ioutil.ReadAll(brotli.NewReader(bytes.NewReader(data)))
+	case EncodingGzip:
+		var reader *gzip.Reader
+		if reader, err = gzip.NewReader(bytes.NewReader(data)); err == nil {
+			decoded, err = ioutil.ReadAll(reader)
+		}
+	default:
+		decoded = data
+	}
+
+	if err != nil {
+		t.Fatalf("%v body could not be decoded: %v", encoding, err)
+	}
+
+	return string(decoded)
+}
+
+func TestNegotiate(t *testing.T) {
+	supported := []string{EncodingBrotli, EncodingGzip}
+	expected := map[string]string{
+		"":                         EncodingIdentity,
+		"gzip":                     EncodingGzip,
+		"gzip, br":                 EncodingBrotli,
+		"GZIP;q=1.0, br;q=0.5":     EncodingGzip,
+		"br;q=0, gzip":             EncodingGzip,
+		"*":                        EncodingBrotli,
+		"*;q=0.5, br;q=0":          EncodingGzip,
+		"deflate, identity":        EncodingIdentity,
+		"gzip;q=0, br;q=0, *;q=0":  EncodingIdentity,
+		"gzip;q=invalid, br;q=0.1": EncodingGzip,
+	}
+	for acceptEncoding, encoding := range expected {
+		if actual := Negotiate(acceptEncoding, supported); actual != encoding {
+			t.Errorf("Negotiate returned wrong encoding for %q: got %v want %v", acceptEncoding, actual, encoding)
+		}
+	}
+}
+
+func TestAddVary(t *testing.T) {
+	header := http.Header{}
+	header.Add("Vary", "Accept-Language")
+	AddVary(header)
+	header.Set("Vary", "Accept-Language, accept-encoding")
+	AddVary(header)
+
+	if vary := header["Vary"]; len(vary) != 1 {
+		t.Errorf("AddVary listed Accept-Encoding twice: got %v", vary)
+	}
+}
+
+func TestCompressible(t *testing.T) {
+	expected := map[string]bool{
+		"application/json; charset=utf-8": true,
+		"application/problem+json":        true,
+		"text/plain; charset=utf-8":       true,
+		"image/svg+xml":                   true,
+		"image/png":                       false,
+		"application/octet-stream":        false,
+		"":                                false,
+	}
+	for contentType, compressible := range expected {
+		if Compressible(contentType) != compressible {
+			t.Errorf("Compressible returned wrong result for %q: got %v want %v", contentType, !compressible, compressible)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	for _, encoding := range []string{EncodingBrotli, EncodingGzip} {
+		w := serve(encoding, http.StatusOK, "application/json", text)
+		if w.Header().Get("Content-Encoding") != encoding {
+			t.Errorf("Middleware returned wrong Content-Encoding: got %v want %v", w.Header().Get("Content-Encoding"), encoding)
+		}
+
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Middleware returned wrong Vary: got %v", w.Header().Get("Vary"))
+		}
+
+		if w.Body.Len() >= len(text) {
+			t.Errorf("Middleware did not compress the body: got %v bytes", w.Body.Len())
+		}
+
+		if decoded := decode(t, encoding, w.Body.Bytes()); decoded != text {
+			t.Errorf("Middleware returned wrong %v body: got %v", encoding, decoded)
+		}
+	}
+}
+
+func TestMiddlewareSniffsContentType(t *testing.T) {
+	w := serve("gzip", http.StatusOK, "", text)
+	if w.Header().Get("Content-Type") != "text/plain; charset=utf-8" || w.Header().Get("Content-Encoding") != EncodingGzip {
+		t.Errorf("Middleware returned wrong headers: got %v", w.Header())
+	}
+}
+
+func TestMiddlewareUncompressed(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		status         int
+		contentType    string
+		body           string
+		vary           string
+	}{
+		{"identity", "identity", http.StatusOK, "application/json", text, "Accept-Encoding"},
+		{"small", "gzip", http.StatusOK, "application/json", "[]", "Accept-Encoding"},
+		{"binary", "gzip", http.StatusOK, "image/png", text, ""},
+		{"no content", "gzip", http.StatusNoContent, "application/json", "", ""},
+	}
+	for _, test := range tests {
+		w := serve(test.acceptEncoding, test.status, test.contentType, test.body)
+		if w.Code != test.status || len(w.Header().Get("Content-Encoding")) != 0 || w.Body.String() != test.body {
+			t.Errorf("Middleware compressed the %v response: got %v %v", test.name, w.Code, w.Header())
+		}
+
+		if w.Header().Get("Vary") != test.vary {
+			t.Errorf("Middleware returned wrong Vary for the %v response: got %v want %v", test.name, w.Header().Get("Vary"), test.vary)
+		}
+	}
+}
+
+func TestMiddlewarePassesThroughEncodedContent(t *testing.T) {
+	encoded := Encode(EncodingGzip, []byte(text))
+	handler := Middleware([]string{EncodingBrotli, EncodingGzip}, 0, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", EncodingGzip)
+		w.Write(encoded)
+	})
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "br, gzip")
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	if w.Header().Get("Content-Encoding") != EncodingGzip || !bytes.Equal(w.Body.Bytes(), encoded) {
+		t.Errorf("Middleware encoded the content twice: got %v", w.Header())
+	}
+}
+
+func TestCache(t *testing.T) {
+	cache := NewCache()
+	first := cache.Encoded("en-US", "1", EncodingBrotli, []byte(text))
+	if decoded := decode(t, EncodingBrotli, first); decoded != text {
+		t.Errorf("Cache returned wrong variant: got %v", decoded)
+	}
+
+	if cached := cache.Encoded("en-US", "1", EncodingBrotli, []byte("ignored")); !bytes.Equal(cached, first) {
+		t.Errorf("Cache encoded the variant again")
+	}
+
+	if updated := cache.Encoded("en-US", "2", EncodingBrotli, []byte("[]")); decode(t, EncodingBrotli, updated) != "[]" {
+		t.Errorf("Cache kept the variant of a previous version")
+	}
+
+	if identity := cache.Encoded("en-US", "2", EncodingIdentity, []byte("[]")); string(identity) != "[]" {
+		t.Errorf("Cache encoded the identity variant: got %v", identity)
+	}
+}
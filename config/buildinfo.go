@@ -0,0 +1,70 @@
+package config
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// BuildTime of Application
+var BuildTime = ""
+
+// readBuildInfo for mocking out debug.ReadBuildInfo
+var readBuildInfo = debug.ReadBuildInfo
+
+// Dependency module compiled into the binary
+type Dependency struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Replace string `json:"replace,omitempty"`
+}
+
+// BuildInfo describing the running binary
+type BuildInfo struct {
+	Version      string       `json:"version"`
+	Revision     string       `json:"revision,omitempty"`
+	Dirty        bool         `json:"dirty"`
+	BuildTime    string       `json:"buildTime,omitempty"`
+	GoVersion    string       `json:"goVersion"`
+	Platform     string       `json:"platform"`
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+// Build method to return the build metadata of the running binary
+func Build() BuildInfo {
+	build := BuildInfo{
+		Version:      BinaryVersion,
+		BuildTime:    BuildTime,
+		GoVersion:    runtime.Version(),
+		Platform:     runtime.GOOS + "/" + runtime.GOARCH,
+		Dependencies: []Dependency{},
+	}
+
+	info, ok := readBuildInfo()
+	if !ok {
+		return build
+	}
+
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Revision = setting.Value
+		case "vcs.modified":
+			build.Dirty = setting.Value == "true"
+		case "vcs.time":
+			// Prefer the time injected through -ldflags by script/ci/release.sh
+			if len(build.BuildTime) == 0 {
+				build.BuildTime = setting.Value
+			}
+		}
+	}
+
+	for _, module := range info.Deps {
+		dependency := Dependency{Path: module.Path, Version: module.Version}
+		if module.Replace != nil {
+			dependency.Replace = module.Replace.Path + "@" + module.Replace.Version
+		}
+		build.Dependencies = append(build.Dependencies, dependency)
+	}
+
+	return build
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+func TestBuildWithoutBuildInfo(t *testing.T) {
+	readBuildInfo = func() (*debug.BuildInfo, bool) { return nil, false }
+	defer func() { readBuildInfo = debug.ReadBuildInfo }()
+
+	build := Build()
+	if build.Version != BinaryVersion {
+		t.Errorf("Build returned wrong version: got %v want %v", build.Version, BinaryVersion)
+	}
+
+	if build.GoVersion != runtime.Version() {
+		t.Errorf("Build returned wrong Go version: got %v want %v", build.GoVersion, runtime.Version())
+	}
+
+	if build.Revision != "" || build.Dirty || len(build.Dependencies) != 0 {
+		t.Errorf("Build returned unexpected VCS details: got %v", build)
+	}
+}
+
+func TestBuildWithBuildInfo(t *testing.T) {
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			Deps: []*debug.Module{
+				{Path: "golang.org/x/text", Version: "v0.3.0"},
+				{Path: "github.com/newrelic/go-agent", Version: "v1.9.0", Replace: &debug.Module{Path: "../go-agent", Version: "v1.9.1"}},
+			},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "abc123"},
+				{Key: "vcs.modified", Value: "true"},
+				{Key: "vcs.time", Value: "2017-06-01T00:00:00Z"},
+			},
+		}, true
+	}
+	defer func() { readBuildInfo = debug.ReadBuildInfo }()
+
+	build := Build()
+	if build.Revision != "abc123" || !build.Dirty {
+		t.Errorf("Build returned unexpected VCS details: got %v", build)
+	}
+
+	if build.BuildTime != "2017-06-01T00:00:00Z" {
+		t.Errorf("Build returned wrong build time: got %v want %v", build.BuildTime, "2017-06-01T00:00:00Z")
+	}
+
+	if len(build.Dependencies) != 2 || build.Dependencies[1].Replace != "../go-agent@v1.9.1" {
+		t.Errorf("Build returned unexpected dependencies: got %v", build.Dependencies)
+	}
+}
+
+func TestBuildPrefersInjectedBuildTime(t *testing.T) {
+	BuildTime = "2017-07-04T12:00:00Z"
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			Settings: []debug.BuildSetting{{Key: "vcs.time", Value: "2017-06-01T00:00:00Z"}},
+		}, true
+	}
+	defer func() {
+		BuildTime = ""
+		readBuildInfo = debug.ReadBuildInfo
+	}()
+
+	build := Build()
+	if build.BuildTime != "2017-07-04T12:00:00Z" {
+		t.Errorf("Build returned wrong build time: got %v want %v", build.BuildTime, "2017-07-04T12:00:00Z")
+	}
+}
@@ -3,6 +3,9 @@ package config
 import (
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // BinaryVersion of Application
@@ -38,3 +41,196 @@ func ProxyURI(proxyURIKey string) *url.URL {
 	uri, _ := url.Parse(envValue)
 	return uri
 }
+
+// StringValue method to return environment config or the fallback
+func StringValue(key string, fallback string) string {
+	value := os.Getenv(key)
+	if len(value) == 0 {
+		return fallback
+	}
+
+	return value
+}
+
+// ListValue method to return comma separated environment config
+func ListValue(key string) []string {
+	values := []string{}
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); len(value) != 0 {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// IntValue method to return parsed environment config or the fallback
+func IntValue(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
+// FloatValue method to return parsed environment config or the fallback
+func FloatValue(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
+// DurationValue method to return parsed environment config or the fallback
+func DurationValue(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
+// HealthProbeTTLKey enivronment variable key
+const HealthProbeTTLKey = "HEALTH_PROBE_TTL"
+
+// CircuitBreakerThresholdKey enivronment variable key
+const CircuitBreakerThresholdKey = "CIRCUIT_BREAKER_THRESHOLD"
+
+// CircuitBreakerCooldownKey enivronment variable key
+const CircuitBreakerCooldownKey = "CIRCUIT_BREAKER_COOLDOWN"
+
+// APMProviderKey enivronment variable key
+const APMProviderKey = "APM_PROVIDER"
+
+// APMServiceNameKey enivronment variable key
+const APMServiceNameKey = "APM_SERVICE_NAME"
+
+// OTelExporterKey enivronment variable key
+const OTelExporterKey = "OTEL_TRACES_EXPORTER"
+
+// OTelEndpointKey enivronment variable key
+const OTelEndpointKey = "OTEL_EXPORTER_OTLP_ENDPOINT"
+
+// LogLevelKey enivronment variable key
+const LogLevelKey = "LOG_LEVEL"
+
+// LogFormatKey enivronment variable key
+const LogFormatKey = "LOG_FORMAT"
+
+// LogOutputKey enivronment variable key
+const LogOutputKey = "LOG_OUTPUT"
+
+// LogMaxSizeKey enivronment variable key
+const LogMaxSizeKey = "LOG_MAX_SIZE_MB"
+
+// LogMaxAgeKey enivronment variable key
+const LogMaxAgeKey = "LOG_MAX_AGE"
+
+// LogMaxBackupsKey enivronment variable key
+const LogMaxBackupsKey = "LOG_MAX_BACKUPS"
+
+// LogCompressKey enivronment variable key
+const LogCompressKey = "LOG_COMPRESS"
+
+// RedactHeadersAllowKey enivronment variable key
+const RedactHeadersAllowKey = "REDACT_HEADERS_ALLOW"
+
+// RedactHeadersDenyKey enivronment variable key
+const RedactHeadersDenyKey = "REDACT_HEADERS_DENY"
+
+// AccessLogOutputKey enivronment variable key
+const AccessLogOutputKey = "ACCESS_LOG_OUTPUT"
+
+// AccessLogFormatKey enivronment variable key
+const AccessLogFormatKey = "ACCESS_LOG_FORMAT"
+
+// AccessLogSample2xxKey enivronment variable key
+const AccessLogSample2xxKey = "ACCESS_LOG_SAMPLE_2XX"
+
+// LegacySunsetKey enivronment variable key
+const LegacySunsetKey = "LEGACY_API_SUNSET"
+
+// AirportCodesMaxKey enivronment variable key
+const AirportCodesMaxKey = "AIRPORT_CODES_MAX"
+
+// AirportCodesLenientKey enivronment variable key
+const AirportCodesLenientKey = "AIRPORT_CODES_LENIENT"
+
+// MaxRequestBodyBytesKey enivronment variable key
+const MaxRequestBodyBytesKey = "MAX_REQUEST_BODY_BYTES"
+
+// RequireJSONContentTypeKey enivronment variable key
+const RequireJSONContentTypeKey = "REQUIRE_JSON_CONTENT_TYPE"
+
+// RateLimitTiersKey enivronment variable key
+const RateLimitTiersKey = "RATE_LIMIT_TIERS"
+
+// AuthModeKey enivronment variable key
+const AuthModeKey = "AUTH_MODE"
+
+// AuthAPIKeysFileKey enivronment variable key
+const AuthAPIKeysFileKey = "AUTH_API_KEYS_FILE"
+
+// AuthJWKSFileKey enivronment variable key
+const AuthJWKSFileKey = "AUTH_JWKS_FILE"
+
+// AuthJWTIssuerKey enivronment variable key
+const AuthJWTIssuerKey = "AUTH_JWT_ISSUER"
+
+// AuthJWTAudienceKey enivronment variable key
+const AuthJWTAudienceKey = "AUTH_JWT_AUDIENCE"
+
+// AuthJWTTierClaimKey enivronment variable key
+const AuthJWTTierClaimKey = "AUTH_JWT_TIER_CLAIM"
+
+// AuthReloadIntervalKey enivronment variable key
+const AuthReloadIntervalKey = "AUTH_RELOAD_INTERVAL"
+
+// CORSAllowedOriginsKey enivronment variable key
+const CORSAllowedOriginsKey = "CORS_ALLOWED_ORIGINS"
+
+// CORSAllowedMethodsKey enivronment variable key
+const CORSAllowedMethodsKey = "CORS_ALLOWED_METHODS"
+
+// CORSAllowedHeadersKey enivronment variable key
+const CORSAllowedHeadersKey = "CORS_ALLOWED_HEADERS"
+
+// CORSExposedHeadersKey enivronment variable key
+const CORSExposedHeadersKey = "CORS_EXPOSED_HEADERS"
+
+// CORSAllowCredentialsKey enivronment variable key
+const CORSAllowCredentialsKey = "CORS_ALLOW_CREDENTIALS"
+
+// CORSMaxAgeKey enivronment variable key
+const CORSMaxAgeKey = "CORS_MAX_AGE"
+
+// PolicyCacheControlKey enivronment variable key
+const PolicyCacheControlKey = "POLICY_CACHE_CONTROL"
+
+// BatchMaxItinerariesKey enivronment variable key
+const BatchMaxItinerariesKey = "BATCH_MAX_ITINERARIES"
+
+// BatchMaxResponseBytesKey enivronment variable key
+const BatchMaxResponseBytesKey = "BATCH_MAX_RESPONSE_BYTES"
+
+// CompressionEncodingsKey enivronment variable key
+const CompressionEncodingsKey = "COMPRESSION_ENCODINGS"
+
+// CompressionMinBytesKey enivronment variable key
+const CompressionMinBytesKey = "COMPRESSION_MIN_BYTES"
+
+// AdminClientsKey enivronment variable key
+const AdminClientsKey = "ADMIN_CLIENTS"
+
+// AuditLogFileKey enivronment variable key
+const AuditLogFileKey = "AUDIT_LOG_FILE"
+
+// PreviewClientsKey enivronment variable key
+const PreviewClientsKey = "PREVIEW_CLIENTS"
+
+// TrustedProxiesKey enivronment variable key
+const TrustedProxiesKey = "TRUSTED_PROXIES"
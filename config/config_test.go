@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestBinaryVersionAccess(t *testing.T) {
@@ -90,3 +91,110 @@ func TestProxyURIParseFailure(t *testing.T) {
 			actual, expected)
 	}
 }
+
+func TestStringValueSuccess(t *testing.T) {
+	os.Clearenv()
+
+	os.Setenv("StringValueKey", "TEST")
+	actual := StringValue("StringValueKey", "fallback")
+	if actual != "TEST" {
+		t.Errorf("StringValue does not match: got %v want %v",
+			actual, "TEST")
+	}
+}
+
+func TestStringValueFallback(t *testing.T) {
+	os.Clearenv()
+
+	actual := StringValue("StringValueKey", "fallback")
+	if actual != "fallback" {
+		t.Errorf("StringValue does not match: got %v want %v",
+			actual, "fallback")
+	}
+}
+
+func TestListValue(t *testing.T) {
+	os.Clearenv()
+
+	os.Setenv("ListValueKey", " Cookie, ,X-Api-Key ")
+	actual := ListValue("ListValueKey")
+	if len(actual) != 2 || actual[0] != "Cookie" || actual[1] != "X-Api-Key" {
+		t.Errorf("ListValue does not match: got %v want %v",
+			actual, []string{"Cookie", "X-Api-Key"})
+	}
+}
+
+func TestListValueEmpty(t *testing.T) {
+	os.Clearenv()
+
+	actual := ListValue("ListValueKey")
+	if len(actual) != 0 {
+		t.Errorf("ListValue does not match: got %v want %v",
+			actual, []string{})
+	}
+}
+
+func TestIntValueSuccess(t *testing.T) {
+	os.Clearenv()
+
+	os.Setenv("IntValueKey", "42")
+	actual := IntValue("IntValueKey", 7)
+	if actual != 42 {
+		t.Errorf("IntValue does not match: got %v want %v",
+			actual, 42)
+	}
+}
+
+func TestIntValueFallback(t *testing.T) {
+	os.Clearenv()
+
+	os.Setenv("IntValueKey", "forty-two")
+	actual := IntValue("IntValueKey", 7)
+	if actual != 7 {
+		t.Errorf("IntValue does not match: got %v want %v",
+			actual, 7)
+	}
+}
+
+func TestFloatValueSuccess(t *testing.T) {
+	os.Clearenv()
+
+	os.Setenv("FloatValueKey", "0.25")
+	actual := FloatValue("FloatValueKey", 1)
+	if actual != 0.25 {
+		t.Errorf("FloatValue does not match: got %v want %v",
+			actual, 0.25)
+	}
+}
+
+func TestFloatValueFallback(t *testing.T) {
+	os.Clearenv()
+
+	os.Setenv("FloatValueKey", "quarter")
+	actual := FloatValue("FloatValueKey", 1)
+	if actual != 1 {
+		t.Errorf("FloatValue does not match: got %v want %v",
+			actual, 1)
+	}
+}
+
+func TestDurationValueSuccess(t *testing.T) {
+	os.Clearenv()
+
+	os.Setenv("DurationValueKey", "90s")
+	actual := DurationValue("DurationValueKey", time.Second)
+	if actual != 90*time.Second {
+		t.Errorf("DurationValue does not match: got %v want %v",
+			actual, 90*time.Second)
+	}
+}
+
+func TestDurationValueFallback(t *testing.T) {
+	os.Clearenv()
+
+	actual := DurationValue("DurationValueKey", time.Second)
+	if actual != time.Second {
+		t.Errorf("DurationValue does not match: got %v want %v",
+			actual, time.Second)
+	}
+}
@@ -0,0 +1,183 @@
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options of a Policy
+type Options struct {
+	// AllowedOrigins exactly, e.g. https://book.example.com, by subdomain wildcard, e.g. https://*.example.com, or * for any
+	AllowedOrigins []string
+	// AllowedMethods of requests, answered in preflight responses
+	AllowedMethods []string
+	// AllowedHeaders of requests, answered in preflight responses
+	AllowedHeaders []string
+	// ExposedHeaders of responses readable by the browser
+	ExposedHeaders []string
+	// AllowCredentials such as cookies and Authorization headers, ignored with * since any site could then read responses with the user's credentials
+	AllowCredentials bool
+	// MaxAge a preflight response may be cached
+	MaxAge time.Duration
+}
+
+// Policy for cross-origin requests; a Policy without AllowedOrigins leaves responses untouched
+type Policy struct {
+	options   Options
+	anyOrigin bool
+	exact     map[string]bool
+	wildcards [][2]string
+	methods   map[string]bool
+	headers   map[string]bool
+}
+
+// New Policy for the options
+func New(options Options) *Policy {
+	p := &Policy{
+		options: options,
+		exact:   map[string]bool{},
+		methods: map[string]bool{},
+		headers: map[string]bool{},
+	}
+
+	for _, origin := range options.AllowedOrigins {
+		origin = strings.ToLower(origin)
+		switch {
+		case origin == "*":
+			p.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			star := strings.Index(origin, "*")
+			p.wildcards = append(p.wildcards, [2]string{origin[:star], origin[star+1:]})
+		default:
+			p.exact[origin] = true
+		}
+	}
+
+	if p.anyOrigin {
+		p.options.AllowCredentials = false
+	}
+
+	for _, method := range options.AllowedMethods {
+		p.methods[strings.ToUpper(method)] = true
+	}
+
+	for _, header := range options.AllowedHeaders {
+		p.headers[http.CanonicalHeaderKey(header)] = true
+	}
+
+	return p
+}
+
+// Enabled when any origin is allowed
+func (p *Policy) Enabled() bool {
+	return p.anyOrigin || len(p.exact) != 0 || len(p.wildcards) != 0
+}
+
+// AllowOrigin reports whether the origin may read responses
+func (p *Policy) AllowOrigin(origin string) bool {
+	if len(origin) == 0 {
+		return false
+	}
+
+	origin = strings.ToLower(origin)
+	if p.anyOrigin || p.exact[origin] {
+		return true
+	}
+
+	for _, wildcard := range p.wildcards {
+		if strings.HasPrefix(origin, wildcard[0]) && strings.HasSuffix(origin, wildcard[1]) {
+			subdomain := origin[len(wildcard[0]) : len(origin)-len(wildcard[1])]
+			if validSubdomain(subdomain) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Handler answering preflight requests and adding CORS headers to the responses of handler for allowed origins
+func (p *Policy) Handler(handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !p.Enabled() {
+			handler(w, r)
+			return
+		}
+
+		origin := r.Header.Get("Origin")
+		if r.Method == http.MethodOptions && len(r.Header.Get("Access-Control-Request-Method")) != 0 {
+			w.Header().Add("Vary", "Origin, Access-Control-Request-Method, Access-Control-Request-Headers")
+			if !p.AllowOrigin(origin) || !p.allowPreflight(r) {
+				handler(w, r)
+				return
+			}
+
+			p.setOrigin(w, origin)
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(p.options.AllowedMethods, ", "))
+			if requested := r.Header.Get("Access-Control-Request-Headers"); len(requested) != 0 {
+				w.Header().Set("Access-Control-Allow-Headers", requested)
+			}
+			if p.options.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(p.options.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		if p.AllowOrigin(origin) {
+			p.setOrigin(w, origin)
+			if len(p.options.ExposedHeaders) != 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(p.options.ExposedHeaders, ", "))
+			}
+		}
+
+		handler(w, r)
+	}
+}
+
+// allowPreflight when the requested method and every requested header are allowed
+func (p *Policy) allowPreflight(r *http.Request) bool {
+	if !p.methods[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))] {
+		return false
+	}
+
+	for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		if header = strings.TrimSpace(header); len(header) != 0 && !p.headers[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setOrigin as * for any origin, otherwise echoing the origin, since browsers reject * for requests with credentials
+func (p *Policy) setOrigin(w http.ResponseWriter, origin string) {
+	if p.anyOrigin {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if p.options.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// validSubdomain of one or more DNS labels, so a wildcard never matches a different host or port
+func validSubdomain(subdomain string) bool {
+	for _, label := range strings.Split(subdomain, ".") {
+		if len(label) == 0 {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
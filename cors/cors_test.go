@@ -0,0 +1,151 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestPolicy(credentials bool, origins ...string) *Policy {
+	return New(Options{
+		AllowedOrigins:   origins,
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type", "X-Api-Key"},
+		ExposedHeaders:   []string{"Content-Language"},
+		AllowCredentials: credentials,
+		MaxAge:           10 * time.Minute,
+	})
+}
+
+func serve(p *Policy, method string, headers map[string]string) (*httptest.ResponseRecorder, bool) {
+	called := false
+	handler := p.Handler(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	r, _ := http.NewRequest(method, "/v1/policy/hazardousgoods/evaluate", nil)
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w, called
+}
+
+func TestAllowOrigin(t *testing.T) {
+	p := newTestPolicy(false, "https://book.example.com", "https://*.example.net")
+
+	expected := map[string]bool{
+		"https://book.example.com":      true,
+		"HTTPS://BOOK.EXAMPLE.COM":      true,
+		"https://api.example.com":       false,
+		"http://book.example.com":       false,
+		"https://a.example.net":         true,
+		"https://a.b.example.net":       true,
+		"https://example.net":           false,
+		"https://evilexample.net":       false,
+		"https://a.example.net:8443":    false,
+		"https://a.example.net.evil.io": false,
+		"https://a/b.example.net":       false,
+		"":                              false,
+	}
+	for origin, allowed := range expected {
+		if p.AllowOrigin(origin) != allowed {
+			t.Errorf("AllowOrigin returned wrong result for %q: got %v want %v", origin, !allowed, allowed)
+		}
+	}
+
+	if !New(Options{AllowedOrigins: []string{"*"}}).AllowOrigin("https://anywhere.example.org") {
+		t.Errorf("AllowOrigin should allow any origin for *!")
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	w, called := serve(newTestPolicy(true, "https://book.example.com"), http.MethodOptions, map[string]string{
+		"Origin":                         "https://book.example.com",
+		"Access-Control-Request-Method":  "POST",
+		"Access-Control-Request-Headers": "content-type, x-api-key",
+	})
+
+	if called || w.Code != http.StatusNoContent {
+		t.Errorf("Handler did not answer the preflight: got %v called %v", w.Code, called)
+	}
+
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":      "https://book.example.com",
+		"Access-Control-Allow-Methods":     "GET, POST",
+		"Access-Control-Allow-Headers":     "content-type, x-api-key",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Max-Age":           "600",
+		"Vary":                             "Origin, Access-Control-Request-Method, Access-Control-Request-Headers",
+	}
+	for header, value := range expected {
+		if w.Header().Get(header) != value {
+			t.Errorf("Handler returned wrong %v header: got %v want %v", header, w.Header().Get(header), value)
+		}
+	}
+}
+
+func TestPreflightRejected(t *testing.T) {
+	p := newTestPolicy(false, "https://book.example.com")
+	preflights := []map[string]string{
+		{"Origin": "https://evil.example.com", "Access-Control-Request-Method": "POST"},
+		{"Origin": "https://book.example.com", "Access-Control-Request-Method": "DELETE"},
+		{"Origin": "https://book.example.com", "Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "X-Debug"},
+	}
+	for _, headers := range preflights {
+		w, called := serve(p, http.MethodOptions, headers)
+		if !called || len(w.Header().Get("Access-Control-Allow-Origin")) != 0 {
+			t.Errorf("Handler allowed the preflight %v", headers)
+		}
+	}
+}
+
+func TestActualRequest(t *testing.T) {
+	w, called := serve(newTestPolicy(false, "https://book.example.com"), http.MethodPost, map[string]string{"Origin": "https://book.example.com"})
+	if !called {
+		t.Errorf("Handler did not serve the request!")
+	}
+
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":      "https://book.example.com",
+		"Access-Control-Expose-Headers":    "Content-Language",
+		"Access-Control-Allow-Credentials": "",
+		"Vary":                             "Origin",
+	}
+	for header, value := range expected {
+		if w.Header().Get(header) != value {
+			t.Errorf("Handler returned wrong %v header: got %v want %v", header, w.Header().Get(header), value)
+		}
+	}
+
+	w, _ = serve(newTestPolicy(false, "https://book.example.com"), http.MethodPost, map[string]string{"Origin": "https://evil.example.com"})
+	if len(w.Header().Get("Access-Control-Allow-Origin")) != 0 {
+		t.Errorf("Handler allowed a disallowed origin!")
+	}
+}
+
+func TestAnyOrigin(t *testing.T) {
+	w, _ := serve(newTestPolicy(false, "*"), http.MethodPost, map[string]string{"Origin": "https://book.example.com"})
+	if origin := w.Header().Get("Access-Control-Allow-Origin"); origin != "*" {
+		t.Errorf("Handler returned wrong origin: got %v want %v", origin, "*")
+	}
+
+	// Credentials are never allowed for any origin, or every site could read responses on behalf of the user
+	w, _ = serve(newTestPolicy(true, "*", "https://book.example.com"), http.MethodPost, map[string]string{"Origin": "https://book.example.com"})
+	if origin := w.Header().Get("Access-Control-Allow-Origin"); origin != "*" || len(w.Header().Get("Access-Control-Allow-Credentials")) != 0 {
+		t.Errorf("Handler allowed credentials for any origin: got %v", w.Header())
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	w, called := serve(New(Options{}), http.MethodOptions, map[string]string{
+		"Origin":                        "https://book.example.com",
+		"Access-Control-Request-Method": "POST",
+	})
+	if !called || len(w.Header()) != 0 {
+		t.Errorf("Handler changed the response without allowed origins: got %v", w.Header())
+	}
+}
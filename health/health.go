@@ -0,0 +1,142 @@
+package health
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Status of a Check or a Report
+type Status string
+
+// Statuses reported
+const (
+	StatusUp   Status = "UP"
+	StatusDown Status = "DOWN"
+)
+
+// Checker for a single dependency
+type Checker interface {
+	Name() string
+	Check() error
+}
+
+// Result of running a single Checker
+type Result struct {
+	Name      string  `json:"name"`
+	Status    Status  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report of running every registered Checker
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Registry of Checkers run for readiness
+type Registry struct {
+	mu       sync.RWMutex
+	checkers []Checker
+}
+
+// NewRegistry with no Checkers
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register a Checker, replacing any previous Checker of the same name
+func (r *Registry) Register(c Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.checkers {
+		if existing.Name() == c.Name() {
+			r.checkers[i] = c
+			return
+		}
+	}
+	r.checkers = append(r.checkers, c)
+}
+
+// Run every Checker in registration order
+func (r *Registry) Run() Report {
+	r.mu.RLock()
+	checkers := make([]Checker, len(r.checkers))
+	copy(checkers, r.checkers)
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: []Result{}}
+	for _, c := range checkers {
+		result := run(c)
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	return report
+}
+
+func run(c Checker) (result Result) {
+	result.Name = c.Name()
+	started := time.Now()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result.Status = StatusDown
+			result.Error = fmt.Sprint(recovered)
+		}
+		result.LatencyMs = float64(time.Since(started)) / float64(time.Millisecond)
+	}()
+
+	if err := c.Check(); err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = StatusUp
+	return result
+}
+
+type checkFunc struct {
+	name string
+	fn   func() error
+}
+
+func (c checkFunc) Name() string { return c.name }
+func (c checkFunc) Check() error { return c.fn() }
+
+// NewCheck from a plain function
+func NewCheck(name string, fn func() error) Checker {
+	return checkFunc{name: name, fn: fn}
+}
+
+type cachedCheck struct {
+	Checker
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	checked time.Time
+	err     error
+}
+
+// Cached wraps a Checker so the underlying Check runs at most once per ttl
+func Cached(c Checker, ttl time.Duration) Checker {
+	return &cachedCheck{Checker: c, ttl: ttl, now: time.Now}
+}
+
+func (c *cachedCheck) Check() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.checked.IsZero() && c.now().Sub(c.checked) < c.ttl {
+		return c.err
+	}
+
+	c.err = c.Checker.Check()
+	c.checked = c.now()
+	return c.err
+}
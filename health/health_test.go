@@ -0,0 +1,85 @@
+package health
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunAllUp(t *testing.T) {
+	r := NewRegistry()
+	r.Register(NewCheck("first", func() error { return nil }))
+	r.Register(NewCheck("second", func() error { return nil }))
+
+	report := r.Run()
+	if report.Status != StatusUp {
+		t.Errorf("Run returned wrong status: got %v want %v", report.Status, StatusUp)
+	}
+
+	if len(report.Checks) != 2 || report.Checks[0].Name != "first" || report.Checks[1].Name != "second" {
+		t.Errorf("Run returned unexpected checks: got %v", report.Checks)
+	}
+}
+
+func TestRunFailingCheck(t *testing.T) {
+	r := NewRegistry()
+	r.Register(NewCheck("ok", func() error { return nil }))
+	r.Register(NewCheck("broken", func() error { return errors.New("unreachable") }))
+
+	report := r.Run()
+	if report.Status != StatusDown {
+		t.Errorf("Run returned wrong status: got %v want %v", report.Status, StatusDown)
+	}
+
+	broken := report.Checks[1]
+	if broken.Status != StatusDown || broken.Error != "unreachable" {
+		t.Errorf("Run returned unexpected result: got %v", broken)
+	}
+}
+
+func TestRunPanickingCheck(t *testing.T) {
+	r := NewRegistry()
+	r.Register(NewCheck("panics", func() error { panic("not configured") }))
+
+	report := r.Run()
+	if report.Status != StatusDown || report.Checks[0].Error != "not configured" {
+		t.Errorf("Run returned unexpected report: got %v", report)
+	}
+}
+
+func TestRegisterReplacesByName(t *testing.T) {
+	r := NewRegistry()
+	r.Register(NewCheck("dependency", func() error { return errors.New("down") }))
+	r.Register(NewCheck("dependency", func() error { return nil }))
+
+	report := r.Run()
+	if len(report.Checks) != 1 || report.Status != StatusUp {
+		t.Errorf("Register did not replace the check: got %v", report)
+	}
+}
+
+func TestCachedCheck(t *testing.T) {
+	calls := 0
+	current := time.Now()
+	c := Cached(NewCheck("probe", func() error {
+		calls++
+		return nil
+	}), time.Minute).(*cachedCheck)
+	c.now = func() time.Time { return current }
+
+	c.Check()
+	c.Check()
+	if calls != 1 {
+		t.Errorf("Cached check ran too often: got %v want %v", calls, 1)
+	}
+
+	current = current.Add(time.Minute)
+	c.Check()
+	if calls != 2 {
+		t.Errorf("Cached check did not expire: got %v want %v", calls, 2)
+	}
+
+	if c.Name() != "probe" {
+		t.Errorf("Cached check returned wrong name: got %v want %v", c.Name(), "probe")
+	}
+}
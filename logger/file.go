@@ -0,0 +1,257 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeLayout appended to the name of rotated files; it sorts in rotation order
+const backupTimeLayout = "2006-01-02T15-04-05.000"
+
+// compressSuffix of gzipped backups
+const compressSuffix = ".gz"
+
+// RotateOptions for a FileWriter; zero values disable the corresponding behavior
+type RotateOptions struct {
+	// MaxSize in bytes the file may reach before it is rotated
+	MaxSize int64
+	// MaxAge of the file before it is rotated
+	MaxAge time.Duration
+	// MaxBackups of rotated files kept, oldest removed first
+	MaxBackups int
+	// Compress rotated files with gzip
+	Compress bool
+}
+
+// FileWriter appending to a file that is rotated by size and age
+type FileWriter struct {
+	mu       sync.Mutex
+	path     string
+	options  RotateOptions
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	now      func() time.Time
+}
+
+// Log Outputs besides a file path
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+// Open the output, returning a rotating FileWriter when it is not stdout or stderr
+func Open(output string, options RotateOptions) (io.Writer, error) {
+	switch output {
+	case OutputStdout, "":
+		return os.Stdout, nil
+	case OutputStderr:
+		return os.Stderr, nil
+	default:
+		return NewFileWriter(output, options)
+	}
+}
+
+// NewFileWriter opens path for appending, creating it when missing
+func NewFileWriter(path string, options RotateOptions) (*FileWriter, error) {
+	w := &FileWriter{path: path, options: options, now: time.Now}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Write p to the file, rotating it first when p would exceed MaxSize or the file is older than MaxAge
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.rotationDue(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate the file now
+func (w *FileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.rotate()
+}
+
+// Reopen the file at path, for external tools such as logrotate that move it aside
+func (w *FileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.close()
+	return w.open()
+}
+
+// Close the file
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.close()
+}
+
+func (w *FileWriter) rotationDue(pending int64) bool {
+	if w.size == 0 {
+		return false
+	}
+
+	if w.options.MaxSize > 0 && w.size+pending > w.options.MaxSize {
+		return true
+	}
+
+	return w.options.MaxAge > 0 && w.now().Sub(w.openedAt) >= w.options.MaxAge
+}
+
+func (w *FileWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.file = file
+	w.size = info.Size()
+	w.openedAt = w.now()
+	return nil
+}
+
+func (w *FileWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *FileWriter) rotate() error {
+	if err := w.close(); err != nil {
+		return err
+	}
+
+	backup := w.backupName(w.now())
+	if err := os.Rename(w.path, backup); err != nil {
+		if os.IsNotExist(err) {
+			return w.open()
+		}
+		return err
+	}
+
+	if w.options.Compress {
+		if err := compress(backup); err != nil {
+			return err
+		}
+	}
+
+	if err := w.removeExcessBackups(); err != nil {
+		return err
+	}
+
+	return w.open()
+}
+
+// backupName of the file rotated at t, e.g. service-2017-06-01T12-00-00.000.log
+func (w *FileWriter) backupName(t time.Time) string {
+	ext := filepath.Ext(w.path)
+	return strings.TrimSuffix(w.path, ext) + "-" + t.UTC().Format(backupTimeLayout) + ext
+}
+
+// backups of the file, oldest first; only names stamped with backupTimeLayout are backups, so e.g. service-access.log is left alone
+func (w *FileWriter) backups() ([]string, error) {
+	ext := filepath.Ext(w.path)
+	prefix := strings.TrimSuffix(w.path, ext) + "-"
+	matches, err := filepath.Glob(prefix + "*" + ext + "*")
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, match := range matches {
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(match, prefix), ".gz"), ext)
+		if _, err := time.Parse(backupTimeLayout, stamp); err == nil {
+			backups = append(backups, match)
+		}
+	}
+
+	sort.Strings(backups)
+	return backups, nil
+}
+
+func (w *FileWriter) removeExcessBackups() error {
+	if w.options.MaxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := w.backups()
+	if err != nil {
+		return err
+	}
+
+	for len(backups) > w.options.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+
+	return nil
+}
+
+// compress the file to a gzipped copy and remove the original
+func compress(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(target)
+	if _, err = io.Copy(gz, source); err == nil {
+		err = gz.Close()
+	}
+	if closeErr := target.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + compressSuffix)
+		return err
+	}
+
+	return os.Remove(path)
+}
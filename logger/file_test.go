@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func readFile(path string) string {
+	contents, _ := ioutil.ReadFile(path)
+	return string(contents)
+}
+
+func TestOpen(t *testing.T) {
+	if out, _ := Open(OutputStdout, RotateOptions{}); out != os.Stdout {
+		t.Errorf("Open returned wrong writer: got %v want %v", out, os.Stdout)
+	}
+
+	if out, _ := Open(OutputStderr, RotateOptions{}); out != os.Stderr {
+		t.Errorf("Open returned wrong writer: got %v want %v", out, os.Stderr)
+	}
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	out, err := Open(filepath.Join(dir, "service.log"), RotateOptions{})
+	if _, ok := out.(*FileWriter); !ok || err != nil {
+		t.Errorf("Open should return a FileWriter for a path: got %v %v", out, err)
+	}
+
+	if _, err := Open(filepath.Join(dir, "missing", "service.log"), RotateOptions{}); err == nil {
+		t.Errorf("Open should fail for a missing directory!")
+	}
+}
+
+func TestFileWriterRotatesBySize(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "service.log")
+	w, _ := NewFileWriter(path, RotateOptions{MaxSize: 10})
+	defer w.Close()
+
+	clock := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return clock }
+
+	w.Write([]byte("first\n"))
+	w.Write([]byte("second\n"))
+
+	if readFile(path) != "second\n" {
+		t.Errorf("FileWriter wrote unexpected contents: got %v", readFile(path))
+	}
+
+	backup := filepath.Join(dir, "service-2017-06-01T12-00-00.000.log")
+	if readFile(backup) != "first\n" {
+		t.Errorf("FileWriter rotated unexpected contents: got %v", readFile(backup))
+	}
+}
+
+func TestFileWriterRotatesByAge(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "service.log")
+	w, _ := NewFileWriter(path, RotateOptions{MaxAge: time.Hour})
+	defer w.Close()
+
+	clock := time.Now()
+	w.now = func() time.Time { return clock }
+	w.openedAt = clock
+
+	w.Write([]byte("first\n"))
+	clock = clock.Add(30 * time.Minute)
+	w.Write([]byte("second\n"))
+
+	if readFile(path) != "first\nsecond\n" {
+		t.Errorf("FileWriter rotated too early: got %v", readFile(path))
+	}
+
+	clock = clock.Add(30 * time.Minute)
+	w.Write([]byte("third\n"))
+
+	if readFile(path) != "third\n" {
+		t.Errorf("FileWriter did not rotate by age: got %v", readFile(path))
+	}
+}
+
+func TestFileWriterMaxBackupsAndCompress(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "service.log")
+	w, _ := NewFileWriter(path, RotateOptions{MaxBackups: 2, Compress: true})
+	defer w.Close()
+
+	clock := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return clock }
+
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		w.Write([]byte(line))
+		w.Rotate()
+		clock = clock.Add(time.Second)
+	}
+
+	backups, _ := w.backups()
+	expected := []string{
+		filepath.Join(dir, "service-2017-06-01T12-00-01.000.log.gz"),
+		filepath.Join(dir, "service-2017-06-01T12-00-02.000.log.gz"),
+	}
+	if len(backups) != len(expected) || backups[0] != expected[0] || backups[1] != expected[1] {
+		t.Fatalf("FileWriter kept unexpected backups: got %v want %v", backups, expected)
+	}
+
+	file, _ := os.Open(backups[1])
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("FileWriter wrote an invalid gzip backup: %v", err)
+	}
+	if contents, _ := ioutil.ReadAll(gz); string(contents) != "three\n" {
+		t.Errorf("FileWriter compressed unexpected contents: got %v", string(contents))
+	}
+}
+
+func TestFileWriterBackupsIgnoreOtherFiles(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "service.log")
+	others := []string{"service-access.log", "service-access.log.gz", "service-2017-06-01.log"}
+	for _, name := range others {
+		ioutil.WriteFile(filepath.Join(dir, name), []byte("other\n"), 0644)
+	}
+
+	w, _ := NewFileWriter(path, RotateOptions{MaxBackups: 1})
+	defer w.Close()
+
+	clock := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return clock }
+	for i := 0; i < 2; i++ {
+		w.Write([]byte("line\n"))
+		w.Rotate()
+		clock = clock.Add(time.Second)
+	}
+
+	backups, _ := w.backups()
+	if len(backups) != 1 || backups[0] != filepath.Join(dir, "service-2017-06-01T12-00-01.000.log") {
+		t.Errorf("FileWriter returned unexpected backups: got %v", backups)
+	}
+
+	for _, name := range others {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("FileWriter removed a file that is not a backup: %v", err)
+		}
+	}
+}
+
+func TestFileWriterReopen(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "service.log")
+	w, _ := NewFileWriter(path, RotateOptions{})
+	defer w.Close()
+
+	w.Write([]byte("before\n"))
+	os.Rename(path, path+".1")
+	w.Write([]byte("moved\n"))
+
+	if err := w.Reopen(); err != nil {
+		t.Fatalf("Reopen returned an error: %v", err)
+	}
+	w.Write([]byte("after\n"))
+
+	if readFile(path+".1") != "before\nmoved\n" || readFile(path) != "after\n" {
+		t.Errorf("FileWriter did not reopen: got %v and %v", readFile(path+".1"), readFile(path))
+	}
+}
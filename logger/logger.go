@@ -1,41 +1,232 @@
 package logger
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"io"
-	"log"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
-// Log Configuration
-var (
-	Trace       *log.Logger
-	Info        *log.Logger
-	Warning     *log.Logger
-	Error       *log.Logger
-	Initialized bool
+// Level of a log entry
+type Level int
+
+// Log Levels, from most to least verbose
+const (
+	LevelTrace Level = iota
+	LevelInfo
+	LevelWarning
+	LevelError
 )
 
+var levelNames = map[Level]string{
+	LevelTrace:   "trace",
+	LevelInfo:    "info",
+	LevelWarning: "warning",
+	LevelError:   "error",
+}
+
+// String representation of the Level
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel from its name, defaulting to LevelInfo
+func ParseLevel(name string) Level {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level
+		}
+	}
+
+	return LevelInfo
+}
+
+// Format of log entries
+type Format string
+
+// Log Formats
+const (
+	FormatJSON   Format = "json"
+	FormatLogfmt Format = "logfmt"
+)
+
+// Logger writing structured entries at or above its Level
+type Logger struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	level  Level
+	format Format
+	fields []interface{}
+	now    func() time.Time
+}
+
+// New Logger writing to out
+func New(out io.Writer, level Level, format Format) *Logger {
+	return &Logger{
+		mu:     &sync.Mutex{},
+		out:    out,
+		level:  level,
+		format: format,
+		now:    time.Now,
+	}
+}
+
+// std Logger discards everything until Init is called
+var std = New(ioutil.Discard, LevelError, FormatJSON)
+
 // Init Log
-func Init(
-	traceHandle io.Writer,
-	infoHandle io.Writer,
-	warningHandle io.Writer,
-	errorHandle io.Writer) {
+func Init(out io.Writer, level Level, format Format) {
+	std = New(out, level, format)
+}
+
+// Default Logger configured by Init
+func Default() *Logger {
+	return std
+}
 
-	Trace = log.New(traceHandle,
-		"TRACE: ",
-		log.Ldate|log.Ltime|log.Lshortfile)
+// With key value pairs added to every entry of the returned Logger
+func With(keyvals ...interface{}) *Logger { return std.With(keyvals...) }
 
-	Info = log.New(infoHandle,
-		"INFO: ",
-		log.Ldate|log.Ltime|log.Lshortfile)
+// Trace entry on the Default Logger
+func Trace(msg string, keyvals ...interface{}) { std.log(LevelTrace, msg, keyvals) }
 
-	Warning = log.New(warningHandle,
-		"WARNING: ",
-		log.Ldate|log.Ltime|log.Lshortfile)
+// Info entry on the Default Logger
+func Info(msg string, keyvals ...interface{}) { std.log(LevelInfo, msg, keyvals) }
+
+// Warning entry on the Default Logger
+func Warning(msg string, keyvals ...interface{}) { std.log(LevelWarning, msg, keyvals) }
+
+// Error entry on the Default Logger
+func Error(msg string, keyvals ...interface{}) { std.log(LevelError, msg, keyvals) }
+
+// With key value pairs added to every entry of the returned Logger
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	child := *l
+	child.fields = append(append([]interface{}{}, l.fields...), keyvals...)
+	return &child
+}
+
+// Enabled reports whether entries at the Level are written
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.level
+}
+
+// Trace entry
+func (l *Logger) Trace(msg string, keyvals ...interface{}) { l.log(LevelTrace, msg, keyvals) }
+
+// Info entry
+func (l *Logger) Info(msg string, keyvals ...interface{}) { l.log(LevelInfo, msg, keyvals) }
+
+// Warning entry
+func (l *Logger) Warning(msg string, keyvals ...interface{}) { l.log(LevelWarning, msg, keyvals) }
+
+// Error entry
+func (l *Logger) Error(msg string, keyvals ...interface{}) { l.log(LevelError, msg, keyvals) }
+
+func (l *Logger) log(level Level, msg string, keyvals []interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	// Skip log and the exported method or function that called it
+	_, file, line, _ := runtime.Caller(2)
+
+	entry := []interface{}{
+		"time", l.now().UTC().Format(time.RFC3339Nano),
+		"level", level.String(),
+		"msg", msg,
+		"caller", filepath.Base(file) + ":" + strconv.Itoa(line),
+	}
+	entry = append(entry, l.fields...)
+	entry = append(entry, keyvals...)
+
+	// An odd number of key value arguments is a caller mistake that should still be visible
+	if len(entry)%2 != 0 {
+		entry = append(entry, "!MISSING")
+	}
+
+	var buffer bytes.Buffer
+	if l.format == FormatLogfmt {
+		encodeLogfmt(&buffer, entry)
+	} else {
+		encodeJSON(&buffer, entry)
+	}
+	buffer.WriteByte('\n')
+
+	l.mu.Lock()
+	l.out.Write(buffer.Bytes())
+	l.mu.Unlock()
+}
+
+func encodeJSON(buffer *bytes.Buffer, entry []interface{}) {
+	buffer.WriteByte('{')
+	for i := 0; i < len(entry); i += 2 {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(fmt.Sprint(entry[i]))
+		buffer.Write(key)
+		buffer.WriteByte(':')
+
+		value, err := json.Marshal(normalize(entry[i+1]))
+		if err != nil {
+			value, _ = json.Marshal(fmt.Sprint(entry[i+1]))
+		}
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+}
+
+func encodeLogfmt(buffer *bytes.Buffer, entry []interface{}) {
+	for i := 0; i < len(entry); i += 2 {
+		if i > 0 {
+			buffer.WriteByte(' ')
+		}
+
+		buffer.WriteString(fmt.Sprint(entry[i]))
+		buffer.WriteByte('=')
+
+		value := fmt.Sprint(normalize(entry[i+1]))
+		if value == "" || strings.ContainsAny(value, " =\"\t\r\n") {
+			value = strconv.Quote(value)
+		}
+		buffer.WriteString(value)
+	}
+}
+
+// normalize values that do not encode usefully on their own
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return v
+	}
+}
+
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx carrying the Logger
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
 
-	Error = log.New(errorHandle,
-		"ERROR: ",
-		log.Ldate|log.Ltime|log.Lshortfile)
+// FromContext returns the Logger carried by ctx, or the Default Logger
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*Logger); ok {
+		return l
+	}
 
-	Initialized = true
+	return std
 }
@@ -1,53 +1,148 @@
 package logger
 
 import (
-	"io/ioutil"
-	"os"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNoInitialization(t *testing.T) {
-	if Trace != nil {
-		t.Errorf("Trace should not yet be Initialized!")
+	defer func() {
+		if recover() != nil {
+			t.Errorf("Logging before Init should not panic!")
+		}
+	}()
+
+	Trace("trace before Init")
+	Info("info before Init")
+	Warning("warning before Init")
+	Error("error before Init", "key", "value")
+	With("key", "value").Error("error before Init")
+}
+
+func TestInitialization(t *testing.T) {
+	var output bytes.Buffer
+	Init(&output, LevelInfo, FormatJSON)
+	defer Init(&bytes.Buffer{}, LevelError, FormatJSON)
+
+	Info("initialized")
+
+	if Default().out != &output {
+		t.Errorf("Default Logger should be Initialized!")
 	}
 
-	if Info != nil {
-		t.Errorf("Info should not yet be Initialized!")
+	if !strings.Contains(output.String(), `"msg":"initialized"`) {
+		t.Errorf("Default Logger wrote unexpected entry: got %v", output.String())
 	}
+}
 
-	if Warning != nil {
-		t.Errorf("Warning should not yet be Initialized!")
+func TestLevelThreshold(t *testing.T) {
+	var output bytes.Buffer
+	l := New(&output, LevelWarning, FormatLogfmt)
+
+	l.Trace("trace")
+	l.Info("info")
+	l.Warning("warning")
+	l.Error("error")
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "level=warning") || !strings.Contains(lines[1], "level=error") {
+		t.Errorf("Logger wrote unexpected entries: got %v", lines)
 	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	var output bytes.Buffer
+	l := New(&output, LevelTrace, FormatJSON)
+	l.now = func() time.Time { return time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC) }
 
-	if Error != nil {
-		t.Errorf("Error should not yet be Initialized!")
+	l.With("route", "/health").Error("request failed", "status", 503, "error", errors.New("unreachable"), "level", LevelError)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &entry); err != nil {
+		t.Fatalf("Logger wrote invalid JSON: %v", output.String())
 	}
 
-	if Initialized != false {
-		t.Errorf("Initialized should not yet be Initialized!")
+	expected := map[string]interface{}{
+		"time":   "2017-06-01T12:00:00Z",
+		"level":  "error",
+		"msg":    "request failed",
+		"route":  "/health",
+		"status": float64(503),
+		"error":  "unreachable",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("Logger wrote wrong %v: got %v want %v", key, entry[key], value)
+		}
+	}
+
+	if !strings.HasPrefix(entry["caller"].(string), "logger_test.go:") {
+		t.Errorf("Logger wrote wrong caller: got %v", entry["caller"])
+	}
+
+	if !strings.HasPrefix(output.String(), `{"time":"2017-06-01T12:00:00Z","level":"error","msg":"request failed"`) {
+		t.Errorf("Logger wrote keys out of order: got %v", output.String())
 	}
 }
 
-func TestInitialization(t *testing.T) {
-	Init(ioutil.Discard, os.Stdout, os.Stdout, os.Stderr)
+func TestLogfmtFormat(t *testing.T) {
+	var output bytes.Buffer
+	l := New(&output, LevelTrace, FormatLogfmt)
+	l.now = func() time.Time { return time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC) }
 
-	if Trace == nil {
-		t.Errorf("Trace should be Initialized!")
+	l.Info("server started", "port", ":4001", "empty", "", "quoted", `say "hi"`, "dangling")
+
+	expected := `time=2017-06-01T12:00:00Z level=info msg="server started" caller=`
+	if !strings.HasPrefix(output.String(), expected) {
+		t.Errorf("Logger wrote unexpected prefix: got %v want %v", output.String(), expected)
 	}
 
-	if Info == nil {
-		t.Errorf("Info should be Initialized!")
+	expected = ` port=:4001 empty="" quoted="say \"hi\"" dangling=!MISSING` + "\n"
+	if !strings.HasSuffix(output.String(), expected) {
+		t.Errorf("Logger wrote unexpected fields: got %v want %v", output.String(), expected)
 	}
+}
+
+func TestWithDoesNotShareFields(t *testing.T) {
+	var output bytes.Buffer
+	parent := New(&output, LevelTrace, FormatLogfmt).With("a", 1)
 
-	if Warning == nil {
-		t.Errorf("Warning should be Initialized!")
+	parent.With("b", 2)
+	parent.Info("parent")
+
+	if strings.Contains(output.String(), "b=2") {
+		t.Errorf("With leaked fields into the parent Logger: got %v", output.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	expected := map[string]Level{
+		"trace":   LevelTrace,
+		"INFO":    LevelInfo,
+		"Warning": LevelWarning,
+		"error":   LevelError,
+		"verbose": LevelInfo,
+	}
+	for name, level := range expected {
+		if ParseLevel(name) != level {
+			t.Errorf("ParseLevel returned wrong level for %v: got %v want %v", name, ParseLevel(name), level)
+		}
 	}
+}
 
-	if Error == nil {
-		t.Errorf("Error should be Initialized!")
+func TestContext(t *testing.T) {
+	if FromContext(context.Background()) != Default() {
+		t.Errorf("FromContext should default to the Default Logger!")
 	}
 
-	if Initialized == false {
-		t.Errorf("Initialized should be Initialized!")
+	l := New(&bytes.Buffer{}, LevelInfo, FormatJSON).With("requestId", "abc123")
+	ctx := NewContext(context.Background(), l)
+	if FromContext(ctx) != l {
+		t.Errorf("FromContext did not return the carried Logger!")
 	}
 }
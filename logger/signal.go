@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"os"
+	"os/signal"
+)
+
+// ReopenOnSignal reopens the FileWriter whenever one of the signals arrives; call stop to release the signals
+func ReopenOnSignal(w *FileWriter, signals ...os.Signal) (stop func()) {
+	received := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(received, signals...)
+
+	go func() {
+		for {
+			select {
+			case <-received:
+				if err := w.Reopen(); err != nil {
+					Error("Log file failed to reopen", "path", w.path, "error", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(received)
+		close(done)
+	}
+}
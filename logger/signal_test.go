@@ -0,0 +1,34 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReopenOnSignal(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "service.log")
+	w, _ := NewFileWriter(path, RotateOptions{})
+	defer w.Close()
+
+	stop := ReopenOnSignal(w, syscall.SIGUSR1)
+	defer stop()
+
+	os.Rename(path, path+".1")
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(path); err == nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Errorf("FileWriter was not reopened after the signal!")
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
-	"io/ioutil"
+	"encoding/json"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"syscall"
 
+	"github.com/dukeluke16/sample-golang-webservice/config"
 	"github.com/dukeluke16/sample-golang-webservice/logger"
 	"github.com/dukeluke16/sample-golang-webservice/web"
 )
@@ -15,10 +19,51 @@ var start = web.Start
 // fatal for mocking out log.Fatal
 var fatal = log.Fatal
 
+// args for mocking out os.Args
+var args = os.Args
+
+// stdout for mocking out os.Stdout
+var stdout io.Writer = os.Stdout
+
 func main() {
-	logger.Init(ioutil.Discard, os.Stdout, os.Stdout, os.Stderr)
+	if len(args) > 1 && args[1] == "version" {
+		printVersion(stdout)
+		return
+	}
+
+	logger.Init(logOutput(),
+		logger.ParseLevel(config.StringValue(config.LogLevelKey, "info")),
+		logger.Format(config.StringValue(config.LogFormatKey, string(logger.FormatJSON))))
 	err := start()
 	if err != nil {
 		fatal(err)
 	}
 }
+
+// logOutput for the destination and rotation configured in the environment, falling back to stdout
+func logOutput() io.Writer {
+	output := config.StringValue(config.LogOutputKey, logger.OutputStdout)
+	out, err := logger.Open(output, logger.RotateOptions{
+		MaxSize:    int64(config.IntValue(config.LogMaxSizeKey, 0)) * 1024 * 1024,
+		MaxAge:     config.DurationValue(config.LogMaxAgeKey, 0),
+		MaxBackups: config.IntValue(config.LogMaxBackupsKey, 0),
+		Compress:   config.StringValue(config.LogCompressKey, "false") == "true",
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Log output falls back to stdout:", err)
+		return os.Stdout
+	}
+
+	// Reopen for external logrotate
+	if file, ok := out.(*logger.FileWriter); ok {
+		logger.ReopenOnSignal(file, syscall.SIGUSR1)
+	}
+
+	return out
+}
+
+// printVersion for the version subcommand
+func printVersion(w io.Writer) {
+	info, _ := json.MarshalIndent(web.CurrentInfo(), "", "  ")
+	fmt.Fprintln(w, string(info))
+}
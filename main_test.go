@@ -1,8 +1,18 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/logger"
+	"github.com/dukeluke16/sample-golang-webservice/web"
 )
 
 func TestMainSuccess(t *testing.T) {
@@ -33,3 +43,52 @@ func TestMainFailure(t *testing.T) {
 	}()
 	main()
 }
+
+func TestMainVersion(t *testing.T) {
+	start = func() error {
+		t.Error("version subcommand should not start the service!")
+		return nil
+	}
+
+	defer func(originalArgs []string, originalStdout io.Writer) {
+		args = originalArgs
+		stdout = originalStdout
+	}(args, stdout)
+
+	var output bytes.Buffer
+	args = []string{"service", "version"}
+	stdout = &output
+	main()
+
+	var info web.Info
+	if err := json.Unmarshal(output.Bytes(), &info); err != nil {
+		t.Errorf("Parsing error.")
+	}
+
+	if info.Build.Version != "0.0.dev" {
+		t.Errorf("version returned wrong version: got %v want %v", info.Build.Version, "0.0.dev")
+	}
+}
+
+func TestLogOutputFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "main")
+	defer os.RemoveAll(dir)
+
+	os.Setenv(config.LogOutputKey, filepath.Join(dir, "service.log"))
+	defer os.Unsetenv(config.LogOutputKey)
+
+	out := logOutput()
+	if _, ok := out.(*logger.FileWriter); !ok {
+		t.Errorf("logOutput returned wrong writer: got %v", out)
+	}
+	out.(*logger.FileWriter).Close()
+}
+
+func TestLogOutputFallback(t *testing.T) {
+	os.Setenv(config.LogOutputKey, filepath.Join("missing", "folder", "service.log"))
+	defer os.Unsetenv(config.LogOutputKey)
+
+	if out := logOutput(); out != os.Stdout {
+		t.Errorf("logOutput returned wrong writer: got %v want %v", out, os.Stdout)
+	}
+}
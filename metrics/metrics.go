@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry of the service metrics, kept apart from the global Prometheus registry
+var Registry = prometheus.NewRegistry()
+
+// Service Metrics
+var (
+	RequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests handled by route and status code.",
+	}, []string{"route", "status"})
+
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "status"})
+
+	RequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "HTTP requests currently being handled.",
+	})
+
+	UpstreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "upstream_request_duration_seconds",
+		Help:    "Upstream call latency by upstream service.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"upstream"})
+
+	UpstreamErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_errors_total",
+		Help: "Failed upstream calls by upstream service.",
+	}, []string{"upstream"})
+
+	NegotiatedLocales = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "negotiated_locale_total",
+		Help: "Requests by negotiated response locale.",
+	}, []string{"locale"})
+
+	APIVersionRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "api_version_requests_total",
+		Help: "Requests by API version, legacy for the deprecated unversioned routes.",
+	}, []string{"version"})
+
+	RateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limit_rejections_total",
+		Help: "Requests rejected with Too Many Requests by rate limit tier.",
+	}, []string{"tier"})
+
+	AuthenticationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "authentication_failures_total",
+		Help: "Requests rejected with Unauthorized by whether credentials were missing or invalid.",
+	}, []string{"reason"})
+
+	PolicyDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "policy_decisions_total",
+		Help: "Evaluations by whether the policy applied.",
+	}, []string{"applied"})
+)
+
+func init() {
+	Registry.MustRegister(
+		RequestsTotal,
+		RequestDuration,
+		RequestsInFlight,
+		UpstreamDuration,
+		UpstreamErrors,
+		NegotiatedLocales,
+		APIVersionRequests,
+		RateLimitRejections,
+		AuthenticationFailures,
+		PolicyDecisions,
+	)
+}
+
+// Handler serving the Registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveUpstream records the latency and outcome of an upstream call
+func ObserveUpstream(upstream string, started time.Time, err error) {
+	UpstreamDuration.WithLabelValues(upstream).Observe(time.Since(started).Seconds())
+	if err != nil {
+		UpstreamErrors.WithLabelValues(upstream).Inc()
+	}
+}
+
+// ObserveAPIVersion records a request against the API version
+func ObserveAPIVersion(version string) {
+	APIVersionRequests.WithLabelValues(version).Inc()
+}
+
+// ObservePolicyDecision records whether the policy applied to an evaluation
+func ObservePolicyDecision(applied bool) {
+	PolicyDecisions.WithLabelValues(strconv.FormatBool(applied)).Inc()
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Instrument a handler with request count, latency, and in-flight metrics for the route
+func Instrument(route string, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		RequestsInFlight.Inc()
+		defer RequestsInFlight.Dec()
+
+		started := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(recorder, r)
+
+		status := strconv.Itoa(recorder.status)
+		RequestsTotal.WithLabelValues(route, status).Inc()
+		RequestDuration.WithLabelValues(route, status).Observe(time.Since(started).Seconds())
+	}
+}
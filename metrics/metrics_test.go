@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestInstrumentRecordsStatus(t *testing.T) {
+	handler := Instrument("/teapot", func(w http.ResponseWriter, r *http.Request) {
+		if testutil.ToFloat64(RequestsInFlight) != 1 {
+			t.Errorf("Instrument did not track in-flight requests!")
+		}
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	r, _ := http.NewRequest(http.MethodGet, "/teapot", nil)
+	handler(httptest.NewRecorder(), r)
+
+	actual := testutil.ToFloat64(RequestsTotal.WithLabelValues("/teapot", "418"))
+	if actual != 1 {
+		t.Errorf("RequestsTotal does not match: got %v want %v", actual, 1)
+	}
+
+	if testutil.ToFloat64(RequestsInFlight) != 0 {
+		t.Errorf("Instrument did not release in-flight requests!")
+	}
+}
+
+func TestInstrumentDefaultsToOK(t *testing.T) {
+	handler := Instrument("/implicit", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+
+	r, _ := http.NewRequest(http.MethodGet, "/implicit", nil)
+	handler(httptest.NewRecorder(), r)
+
+	actual := testutil.ToFloat64(RequestsTotal.WithLabelValues("/implicit", "200"))
+	if actual != 1 {
+		t.Errorf("RequestsTotal does not match: got %v want %v", actual, 1)
+	}
+}
+
+func TestObserveUpstream(t *testing.T) {
+	ObserveUpstream("test", time.Now(), nil)
+	ObserveUpstream("test", time.Now(), errors.New("unreachable"))
+
+	actual := testutil.ToFloat64(UpstreamErrors.WithLabelValues("test"))
+	if actual != 1 {
+		t.Errorf("UpstreamErrors does not match: got %v want %v", actual, 1)
+	}
+}
+
+func TestObserveAPIVersion(t *testing.T) {
+	ObserveAPIVersion("v9")
+	ObserveAPIVersion("v9")
+
+	actual := testutil.ToFloat64(APIVersionRequests.WithLabelValues("v9"))
+	if actual != 2 {
+		t.Errorf("APIVersionRequests does not match: got %v want %v", actual, 2)
+	}
+}
+
+func TestHandlerExposesTextFormat(t *testing.T) {
+	ObservePolicyDecision(true)
+
+	r, _ := http.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+	}
+
+	expected := `policy_decisions_total{applied="true"} 1`
+	if !strings.Contains(w.Body.String(), expected) {
+		t.Errorf("handler returned unexpected body: missing %v in \n%v", expected, w.Body.String())
+	}
+}
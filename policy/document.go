@@ -0,0 +1,65 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Section of a policy document for the country it applies in
+type Section struct {
+	Code  string   `json:"code"`
+	Alert string   `json:"alert"`
+	Title string   `json:"title"`
+	Body  []string `json:"body"`
+}
+
+// Validate the document is a non-empty array of sections, each with a country code, alert, title, and body paragraphs
+func Validate(document []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+
+	var sections []Section
+	if err := decoder.Decode(&sections); err != nil {
+		return fmt.Errorf("invalid policy document: %v", err)
+	}
+	if decoder.Decode(&json.RawMessage{}) != io.EOF {
+		return errors.New("invalid policy document: unexpected data after the JSON value")
+	}
+
+	if len(sections) == 0 {
+		return errors.New("invalid policy document: no sections")
+	}
+
+	for i, section := range sections {
+		if !countryCode(section.Code) {
+			return fmt.Errorf("invalid policy document: section %d code %q is not an ISO 3166 country code", i, section.Code)
+		}
+
+		if len(section.Alert) == 0 {
+			return fmt.Errorf("invalid policy document: section %d has no alert", i)
+		}
+
+		if len(section.Title) == 0 {
+			return fmt.Errorf("invalid policy document: section %d has no title", i)
+		}
+
+		if len(section.Body) == 0 {
+			return fmt.Errorf("invalid policy document: section %d has no body", i)
+		}
+		for j, paragraph := range section.Body {
+			if len(paragraph) == 0 {
+				return fmt.Errorf("invalid policy document: section %d body paragraph %d is empty", i, j)
+			}
+		}
+	}
+
+	return nil
+}
+
+// countryCode of two upper case letters
+func countryCode(code string) bool {
+	return len(code) == 2 && code[0] >= 'A' && code[0] <= 'Z' && code[1] >= 'A' && code[1] <= 'Z'
+}
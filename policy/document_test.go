@@ -0,0 +1,37 @@
+package policy
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	paths, _ := filepath.Glob(filepath.Join("../data", "*", DocumentName))
+	for _, path := range paths {
+		document, _ := ioutil.ReadFile(path)
+		if err := Validate(document); err != nil {
+			t.Errorf("Validate rejected %v: %v", path, err)
+		}
+	}
+}
+
+func TestValidateInvalid(t *testing.T) {
+	invalid := map[string]string{
+		`{"code": "US"}`: "cannot unmarshal object",
+		`[]`:             "no sections",
+		`[{"code": "usa", "alert": "Alert", "title": "Title", "body": ["Body"]}]`:               `code "usa"`,
+		`[{"code": "US", "title": "Title", "body": ["Body"]}]`:                                  "no alert",
+		`[{"code": "US", "alert": "Alert", "body": ["Body"]}]`:                                  "no title",
+		`[{"code": "US", "alert": "Alert", "title": "Title", "body": []}]`:                      "no body",
+		`[{"code": "US", "alert": "Alert", "title": "Title", "body": ["Body", ""]}]`:            "paragraph 1 is empty",
+		`[{"code": "US", "alert": "Alert", "title": "Title", "body": ["Body"], "extra": true}]`: "unknown field",
+		testDocument + `[]`: "unexpected data",
+	}
+	for document, expected := range invalid {
+		if err := Validate([]byte(document)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Validate returned wrong error for %v: got %v want %v", document, err, expected)
+		}
+	}
+}
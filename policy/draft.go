@@ -0,0 +1,175 @@
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DraftName of the draft of the hazardous goods policy within each locale folder
+const DraftName = "hazardousGoodsPolicy.draft.json"
+
+// DraftVersion naming the version of a draft, which is never in force
+const DraftVersion = "draft"
+
+// Publication of a draft, given the document it replaces, nil when none, and the draft; the draft is only published when it returns nil
+type Publication func(previous []byte, draft []byte) error
+
+// loadDrafts of every locale from the locale folders, including locales with no document yet
+func loadDrafts(folder string) (map[string][]byte, error) {
+	paths, err := filepath.Glob(filepath.Join(folder, "*", DraftName))
+	if err != nil {
+		return nil, err
+	}
+
+	drafts := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		drafts[filepath.Base(filepath.Dir(path))] = data
+	}
+
+	return drafts, nil
+}
+
+// Draft of the document for the locale, never returned by At
+func (s *Store) Draft(locale string) (Version, error) {
+	if !s.Loaded() {
+		if err := s.Load(); err != nil {
+			return Version{}, err
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	draft, found := s.drafts[locale]
+	if !found {
+		return Version{}, ErrNotFound
+	}
+
+	return Version{Version: DraftVersion, Document: draft, Digest: Digest(draft)}, nil
+}
+
+// Drafts of the locales with one, sorted
+func (s *Store) Drafts() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	locales := make([]string, 0, len(s.drafts))
+	for locale := range s.drafts {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	return locales
+}
+
+// PutDraft of the document for the locale, written atomically beside the document once change accepts it, given the draft it replaces
+func (s *Store) PutDraft(locale string, document []byte, change Change) error {
+	if !validLocale(locale) {
+		return ErrInvalidLocale
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	previous, err := s.Draft(locale)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+
+	folder := filepath.Join(s.folder, locale)
+	temp, err := writeTemp(folder, DraftName, document)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp)
+
+	if err := change(previous.Document); err != nil {
+		return err
+	}
+
+	if err := os.Rename(temp, filepath.Join(folder, DraftName)); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.drafts[locale] = document
+	s.mu.Unlock()
+
+	return nil
+}
+
+// DeleteDraft of the document for the locale once change accepts it, given the draft
+func (s *Store) DeleteDraft(locale string, change Change) error {
+	if !validLocale(locale) {
+		return ErrInvalidLocale
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	previous, err := s.Draft(locale)
+	if err != nil {
+		return err
+	}
+
+	if err := change(previous.Document); err != nil {
+		return err
+	}
+
+	folder := filepath.Join(s.folder, locale)
+	if err := os.Remove(filepath.Join(folder, DraftName)); err != nil {
+		return err
+	}
+	// The locale folder of a draft that was never published is left empty
+	os.Remove(folder)
+
+	s.mu.Lock()
+	delete(s.drafts, locale)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Publish the draft for the locale as its document once publication accepts it, renaming the draft over the document so readers see one or the other
+func (s *Store) Publish(locale string, publication Publication) error {
+	if !validLocale(locale) {
+		return ErrInvalidLocale
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	draft, err := s.Draft(locale)
+	if err != nil {
+		return err
+	}
+
+	previous, err := s.Document(locale)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+
+	if err := publication(previous, draft.Document); err != nil {
+		return err
+	}
+
+	folder := filepath.Join(s.folder, locale)
+	if err := os.Rename(filepath.Join(folder, DraftName), filepath.Join(folder, DocumentName)); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.documents[locale] = draft.Document
+	s.digests[locale] = draft.Digest
+	delete(s.drafts, locale)
+	s.checksum = checksum(s.documents, s.scheduled)
+	s.mu.Unlock()
+
+	return nil
+}
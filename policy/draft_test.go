@@ -0,0 +1,140 @@
+package policy
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testDraft = `[{"code": "US", "alert": "Alert", "title": "Draft", "body": ["Body"]}]`
+
+func TestStorePutDraft(t *testing.T) {
+	s, folder := tempStore(t)
+	defer os.RemoveAll(folder)
+
+	if err := s.PutDraft("en-US", []byte(testDraft), func(previous []byte) error {
+		if previous != nil {
+			t.Errorf("PutDraft passed a previous draft: got %v", string(previous))
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	draft, err := s.Draft("en-US")
+	if err != nil || string(draft.Document) != testDraft || draft.Version != DraftVersion || draft.Digest != Digest([]byte(testDraft)) {
+		t.Errorf("Store returned wrong draft: got %+v %v", draft, err)
+	}
+
+	// The draft is never in force
+	if current, _ := s.At("en-US", time.Now()); string(current.Document) != testDocument {
+		t.Errorf("Store served the draft: got %v", string(current.Document))
+	}
+	if document, _ := s.Document("en-US"); string(document) != testDocument {
+		t.Errorf("Store replaced the document with the draft: got %v", string(document))
+	}
+
+	rejected := errors.New("rejected")
+	if err := s.PutDraft("en-US", []byte(testDocument), func(previous []byte) error { return rejected }); err != rejected {
+		t.Errorf("Store returned wrong error: got %v want %v", err, rejected)
+	}
+
+	// A fresh Store reads the same draft from the data folder
+	reloaded := NewStore(folder)
+	if draft, err := reloaded.Draft("en-US"); err != nil || string(draft.Document) != testDraft {
+		t.Errorf("Store persisted wrong draft: got %v %v", string(draft.Document), err)
+	}
+
+	if err := s.PutDraft("fr", []byte(testDraft), accept); err != nil {
+		t.Fatal(err)
+	}
+	if locales := s.Locales(); len(locales) != 1 {
+		t.Errorf("Store listed the locale of a draft: got %v", locales)
+	}
+	if drafts := s.Drafts(); len(drafts) != 2 || drafts[0] != "en-US" || drafts[1] != "fr" {
+		t.Errorf("Store returned wrong drafts: got %v", drafts)
+	}
+
+	if err := s.PutDraft("../fr", []byte(testDraft), accept); err != ErrInvalidLocale {
+		t.Errorf("Store returned wrong error: got %v want %v", err, ErrInvalidLocale)
+	}
+}
+
+func TestStoreDeleteDraft(t *testing.T) {
+	s, folder := tempStore(t)
+	defer os.RemoveAll(folder)
+
+	if err := s.DeleteDraft("en-US", accept); err != ErrNotFound {
+		t.Errorf("Store returned wrong error: got %v want %v", err, ErrNotFound)
+	}
+
+	s.PutDraft("fr", []byte(testDraft), accept)
+	if err := s.DeleteDraft("fr", accept); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Draft("fr"); err != ErrNotFound {
+		t.Errorf("Store returned wrong error: got %v want %v", err, ErrNotFound)
+	}
+	if _, err := os.Stat(filepath.Join(folder, "fr")); !os.IsNotExist(err) {
+		t.Errorf("Store left the folder of the discarded draft: got %v", err)
+	}
+}
+
+func TestStorePublish(t *testing.T) {
+	s, folder := tempStore(t)
+	defer os.RemoveAll(folder)
+
+	if err := s.Publish("en-US", func(previous []byte, draft []byte) error { return nil }); err != ErrNotFound {
+		t.Errorf("Store returned wrong error: got %v want %v", err, ErrNotFound)
+	}
+
+	s.PutDraft("en-US", []byte(testDraft), accept)
+	checksum := s.Checksum()
+
+	rejected := errors.New("rejected")
+	if err := s.Publish("en-US", func(previous []byte, draft []byte) error { return rejected }); err != rejected {
+		t.Errorf("Store returned wrong error: got %v want %v", err, rejected)
+	}
+	if _, err := s.Draft("en-US"); err != nil {
+		t.Errorf("Store discarded a rejected draft: got %v", err)
+	}
+
+	if err := s.Publish("en-US", func(previous []byte, draft []byte) error {
+		if string(previous) != testDocument || string(draft) != testDraft {
+			t.Errorf("Publish passed wrong documents: got %v %v", string(previous), string(draft))
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if document, _ := s.Document("en-US"); string(document) != testDraft || s.Digest("en-US") != Digest([]byte(testDraft)) || s.Checksum() == checksum {
+		t.Errorf("Store did not publish the draft: got %v", string(document))
+	}
+	if _, err := s.Draft("en-US"); err != ErrNotFound {
+		t.Errorf("Store kept the published draft: got %v", err)
+	}
+
+	stored, _ := ioutil.ReadFile(filepath.Join(folder, "en-US", DocumentName))
+	if _, err := os.Stat(filepath.Join(folder, "en-US", DraftName)); string(stored) != testDraft || !os.IsNotExist(err) {
+		t.Errorf("Store persisted wrong document: got %v %v", string(stored), err)
+	}
+
+	// Publishing the draft of a new locale creates it
+	s.PutDraft("fr", []byte(testDraft), accept)
+	if err := s.Publish("fr", func(previous []byte, draft []byte) error {
+		if previous != nil {
+			t.Errorf("Publish passed a previous document: got %v", string(previous))
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if locales := s.Locales(); len(locales) != 2 {
+		t.Errorf("Store did not publish the new locale: got %v", locales)
+	}
+}
@@ -0,0 +1,315 @@
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DocumentName of the hazardous goods policy within each locale folder
+const DocumentName = "hazardousGoodsPolicy.json"
+
+// VersionName of the file holding the data folder version
+const VersionName = "VERSION"
+
+// ErrNotFound returned when no document exists for the locale
+var ErrNotFound = errors.New("policy document not found")
+
+// ErrInvalidLocale returned when the locale cannot name a folder of the data folder
+var ErrInvalidLocale = errors.New("invalid locale")
+
+// ErrLastDocument returned when deleting the only document, which would leave nothing to load
+var ErrLastDocument = errors.New("the last policy document cannot be deleted")
+
+// Change to a document, given the document it replaces, nil when none; the change is only persisted when it returns nil
+type Change func(previous []byte) error
+
+// Store of localized policy documents read from the data folder
+type Store struct {
+	mu sync.RWMutex
+	// writeMu serializes Put and Delete so each Change sees the document it replaces
+	writeMu   sync.Mutex
+	folder    string
+	documents map[string][]byte
+	digests   map[string]string
+	scheduled map[string][]Version
+	drafts    map[string][]byte
+	version   string
+	checksum  string
+	loaded    bool
+}
+
+// NewStore for the data folder, loaded lazily on first use
+func NewStore(folder string) *Store {
+	return &Store{folder: folder}
+}
+
+// Load every locale document from the data folder
+func (s *Store) Load() error {
+	paths, err := filepath.Glob(filepath.Join(s.folder, "*", DocumentName))
+	if err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		return errors.New("no policy documents found in " + s.folder)
+	}
+
+	documents := make(map[string][]byte, len(paths))
+	digests := make(map[string]string, len(paths))
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		locale := filepath.Base(filepath.Dir(path))
+		documents[locale] = data
+		digests[locale] = Digest(data)
+	}
+
+	scheduled, err := loadVersions(s.folder, documents)
+	if err != nil {
+		return err
+	}
+
+	drafts, err := loadDrafts(s.folder)
+	if err != nil {
+		return err
+	}
+
+	// The VERSION file is optional
+	version, _ := ioutil.ReadFile(filepath.Join(s.folder, VersionName))
+
+	s.mu.Lock()
+	s.documents = documents
+	s.digests = digests
+	s.scheduled = scheduled
+	s.drafts = drafts
+	s.version = strings.TrimSpace(string(version))
+	s.checksum = checksum(documents, scheduled)
+	s.loaded = true
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Loaded reports whether the data folder has been loaded successfully
+func (s *Store) Loaded() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.loaded
+}
+
+// Document for the locale as stored in its folder, regardless of the scheduled versions
+func (s *Store) Document(locale string) ([]byte, error) {
+	if !s.Loaded() {
+		if err := s.Load(); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, found := s.documents[locale]
+	if !found {
+		return nil, ErrNotFound
+	}
+
+	return data, nil
+}
+
+// Put the document for the locale, written atomically to the data folder once change accepts it
+func (s *Store) Put(locale string, document []byte, change Change) error {
+	if !validLocale(locale) {
+		return ErrInvalidLocale
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	previous, err := s.Document(locale)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+
+	folder := filepath.Join(s.folder, locale)
+	temp, err := writeTemp(folder, DocumentName, document)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp)
+
+	if err := change(previous); err != nil {
+		return err
+	}
+
+	if err := os.Rename(temp, filepath.Join(folder, DocumentName)); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.documents[locale] = document
+	s.digests[locale] = Digest(document)
+	s.checksum = checksum(s.documents, s.scheduled)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Delete the document for the locale from the data folder once change accepts it
+func (s *Store) Delete(locale string, change Change) error {
+	if !validLocale(locale) {
+		return ErrInvalidLocale
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	previous, err := s.Document(locale)
+	if err != nil {
+		return err
+	}
+
+	if len(s.Locales()) == 1 {
+		return ErrLastDocument
+	}
+
+	if len(s.Versions(locale)) > 1 {
+		return ErrScheduledVersions
+	}
+
+	if err := change(previous); err != nil {
+		return err
+	}
+
+	folder := filepath.Join(s.folder, locale)
+	if err := os.Remove(filepath.Join(folder, DocumentName)); err != nil {
+		return err
+	}
+	// The locale folder is only removed when nothing else is left in it
+	os.Remove(folder)
+
+	s.mu.Lock()
+	delete(s.documents, locale)
+	delete(s.digests, locale)
+	s.checksum = checksum(s.documents, s.scheduled)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Digest of the document for the locale, empty when absent
+func (s *Store) Digest(locale string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.digests[locale]
+}
+
+// Locales loaded, sorted
+func (s *Store) Locales() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	locales := make([]string, 0, len(s.documents))
+	for locale := range s.documents {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	return locales
+}
+
+// Version of the loaded data folder
+func (s *Store) Version() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.version
+}
+
+// Checksum over every loaded document and scheduled version
+func (s *Store) Checksum() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.checksum
+}
+
+// writeTemp file beside the file named name in folder, to be renamed over it so readers never see a partial write
+func writeTemp(folder string, name string, document []byte) (string, error) {
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return "", err
+	}
+
+	temp, err := ioutil.TempFile(folder, "."+name+"-*")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = temp.Write(document)
+	if err == nil {
+		err = temp.Sync()
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temp.Name(), 0644)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+		return "", err
+	}
+
+	return temp.Name(), nil
+}
+
+// validLocale of letters, digits, and hyphens, e.g. zh-Hant, so it names exactly one folder
+func validLocale(locale string) bool {
+	if len(locale) == 0 {
+		return false
+	}
+
+	for _, c := range locale {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Digest identifying the document contents, short enough for an ETag
+func Digest(document []byte) string {
+	sum := sha256.Sum256(document)
+	return hex.EncodeToString(sum[:8])
+}
+
+func checksum(documents map[string][]byte, scheduled map[string][]Version) string {
+	locales := make([]string, 0, len(documents))
+	for locale := range documents {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	hash := sha256.New()
+	for _, locale := range locales {
+		hash.Write([]byte(locale + "\n"))
+		hash.Write(documents[locale])
+		for _, version := range scheduled[locale] {
+			hash.Write([]byte("\n" + version.Version + "\n"))
+			hash.Write(version.Document)
+		}
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,220 @@
+package policy
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testDocument = `[{"code": "US", "alert": "Alert", "title": "Title", "body": ["Body"]}]`
+
+// tempStore of one en-US document in a temporary data folder
+func tempStore(t *testing.T) (*Store, string) {
+	folder, err := ioutil.TempDir("", "policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Mkdir(filepath.Join(folder, "en-US"), 0755)
+	ioutil.WriteFile(filepath.Join(folder, "en-US", DocumentName), []byte(testDocument), 0644)
+
+	return NewStore(folder), folder
+}
+
+func accept(previous []byte) error { return nil }
+
+func TestStoreLoad(t *testing.T) {
+	s := NewStore("../data/")
+	if s.Loaded() {
+		t.Errorf("Store should not yet be Loaded!")
+	}
+
+	if err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.Loaded() {
+		t.Errorf("Store should be Loaded!")
+	}
+
+	locales := s.Locales()
+	if len(locales) != 33 || locales[0] != "bg" {
+		t.Errorf("Store returned unexpected locales: got %v", locales)
+	}
+}
+
+func TestStoreDocumentLazyLoad(t *testing.T) {
+	s := NewStore("../data/")
+
+	data, err := s.Document("en-US")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(data) == 0 {
+		t.Errorf("Store returned an empty document!")
+	}
+}
+
+func TestStoreDocumentNotFound(t *testing.T) {
+	s := NewStore("../data/")
+
+	_, err := s.Document("tlh")
+	if err != ErrNotFound {
+		t.Errorf("Store returned wrong error: got %v want %v", err, ErrNotFound)
+	}
+}
+
+func TestStoreBadFolder(t *testing.T) {
+	s := NewStore("../badDataFolder/")
+
+	if _, err := s.Document("en-US"); err == nil {
+		t.Errorf("Store failed to detect a missing data folder!")
+	}
+
+	if s.Loaded() {
+		t.Errorf("Store should not be Loaded!")
+	}
+}
+
+func TestStoreVersionAndChecksum(t *testing.T) {
+	s := NewStore("../data/")
+	if err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Version() != "1.0.0" {
+		t.Errorf("Store returned wrong version: got %v want %v", s.Version(), "1.0.0")
+	}
+
+	if !strings.HasPrefix(s.Checksum(), "sha256:") || len(s.Checksum()) != 71 {
+		t.Errorf("Store returned unexpected checksum: got %v", s.Checksum())
+	}
+
+	other := NewStore("../data/")
+	other.Load()
+	if other.Checksum() != s.Checksum() {
+		t.Errorf("Store checksum is not stable: got %v want %v", other.Checksum(), s.Checksum())
+	}
+}
+
+func TestStoreDigest(t *testing.T) {
+	s := NewStore("../data/")
+	if err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.Digest("en-US")) != 16 || s.Digest("en-US") == s.Digest("fr") {
+		t.Errorf("Store returned unexpected digests: got %v %v", s.Digest("en-US"), s.Digest("fr"))
+	}
+
+	if s.Digest("tlh") != "" {
+		t.Errorf("Store returned a digest for a missing locale: got %v", s.Digest("tlh"))
+	}
+}
+
+func TestStorePut(t *testing.T) {
+	s, folder := tempStore(t)
+	defer os.RemoveAll(folder)
+
+	var previous []byte
+	updated := []byte(strings.Replace(testDocument, "Title", "New Title", 1))
+	err := s.Put("en-US", updated, func(p []byte) error {
+		previous = p
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(previous) != testDocument {
+		t.Errorf("Store passed wrong previous document: got %v", string(previous))
+	}
+
+	if data, _ := ioutil.ReadFile(filepath.Join(folder, "en-US", DocumentName)); string(data) != string(updated) {
+		t.Errorf("Store did not write the document: got %v", string(data))
+	}
+
+	if data, _ := s.Document("en-US"); string(data) != string(updated) || s.Digest("en-US") != Digest(updated) {
+		t.Errorf("Store did not update the loaded document: got %v", string(data))
+	}
+
+	if err := s.Put("fr", []byte(testDocument), func(p []byte) error {
+		previous = p
+		return nil
+	}); err != nil || previous != nil {
+		t.Errorf("Store did not create the document: got %v %v", err, previous)
+	}
+
+	if locales := s.Locales(); len(locales) != 2 || locales[1] != "fr" {
+		t.Errorf("Store returned wrong locales: got %v", locales)
+	}
+
+	// Only the documents are left in the locale folders
+	files, _ := filepath.Glob(filepath.Join(folder, "*", "*"))
+	if len(files) != 2 {
+		t.Errorf("Store left temporary files: got %v", files)
+	}
+}
+
+func TestStorePutRejected(t *testing.T) {
+	s, folder := tempStore(t)
+	defer os.RemoveAll(folder)
+
+	rejected := errors.New("rejected")
+	if err := s.Put("en-US", []byte("[]"), func([]byte) error { return rejected }); err != rejected {
+		t.Errorf("Store returned wrong error: got %v want %v", err, rejected)
+	}
+
+	if data, _ := ioutil.ReadFile(filepath.Join(folder, "en-US", DocumentName)); string(data) != testDocument {
+		t.Errorf("Store wrote a rejected document: got %v", string(data))
+	}
+
+	files, _ := filepath.Glob(filepath.Join(folder, "en-US", "*"))
+	if len(files) != 1 {
+		t.Errorf("Store left temporary files: got %v", files)
+	}
+
+	for _, locale := range []string{"", "..", "../en-US", "en/US"} {
+		if err := s.Put(locale, []byte(testDocument), accept); err != ErrInvalidLocale {
+			t.Errorf("Store accepted locale %q: got %v", locale, err)
+		}
+	}
+}
+
+func TestStoreDelete(t *testing.T) {
+	s, folder := tempStore(t)
+	defer os.RemoveAll(folder)
+
+	if err := s.Delete("en-US", accept); err != ErrLastDocument {
+		t.Errorf("Store returned wrong error: got %v want %v", err, ErrLastDocument)
+	}
+
+	s.Put("fr", []byte(testDocument), accept)
+	var previous []byte
+	if err := s.Delete("fr", func(p []byte) error {
+		previous = p
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(previous) != testDocument {
+		t.Errorf("Store passed wrong previous document: got %v", string(previous))
+	}
+
+	if _, err := os.Stat(filepath.Join(folder, "fr")); !os.IsNotExist(err) {
+		t.Errorf("Store did not remove the locale folder: got %v", err)
+	}
+
+	if _, err := s.Document("fr"); err != ErrNotFound {
+		t.Errorf("Store returned wrong error: got %v want %v", err, ErrNotFound)
+	}
+
+	if err := s.Delete("fr", accept); err != ErrNotFound {
+		t.Errorf("Store returned wrong error: got %v want %v", err, ErrNotFound)
+	}
+}
@@ -0,0 +1,174 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VersionsFolder within each locale folder holding the scheduled versions of its document, one <version>.json each
+const VersionsFolder = "versions"
+
+// ErrScheduledVersions returned when deleting a document that scheduled versions still fall back to
+var ErrScheduledVersions = errors.New("the policy document has scheduled versions")
+
+// Version of a localized policy document and the window it is in force
+type Version struct {
+	Version string
+	// EffectiveFrom when the version comes into force, zero for the document of the locale folder, which is always in force
+	EffectiveFrom time.Time
+	// EffectiveUntil when the version lapses, zero when open ended
+	EffectiveUntil time.Time
+	Document       []byte
+	Digest         string
+}
+
+// InForce reports whether the version is in force at t
+func (v Version) InForce(t time.Time) bool {
+	return !t.Before(v.EffectiveFrom) && (v.EffectiveUntil.IsZero() || t.Before(v.EffectiveUntil))
+}
+
+// versionFile of a scheduled version, wrapping the document with its window
+type versionFile struct {
+	Version        string          `json:"version"`
+	EffectiveFrom  time.Time       `json:"effectiveFrom"`
+	EffectiveUntil *time.Time      `json:"effectiveUntil,omitempty"`
+	Document       json.RawMessage `json:"document"`
+}
+
+// loadVersions of every locale from the versions folders, sorted by EffectiveFrom
+func loadVersions(folder string, documents map[string][]byte) (map[string][]Version, error) {
+	paths, err := filepath.Glob(filepath.Join(folder, "*", VersionsFolder, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled := map[string][]Version{}
+	for _, path := range paths {
+		locale := filepath.Base(filepath.Dir(filepath.Dir(path)))
+		if _, found := documents[locale]; !found {
+			return nil, fmt.Errorf("%v: no %v of the %v locale to fall back to", path, DocumentName, locale)
+		}
+
+		version, err := readVersion(path)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+		scheduled[locale] = append(scheduled[locale], version)
+	}
+
+	for locale, versions := range scheduled {
+		sort.Slice(versions, func(i, j int) bool { return versions[i].EffectiveFrom.Before(versions[j].EffectiveFrom) })
+		for i := 1; i < len(versions); i++ {
+			if versions[i].EffectiveFrom.Equal(versions[i-1].EffectiveFrom) {
+				return nil, fmt.Errorf("versions %v and %v of the %v locale both take effect at %v",
+					versions[i-1].Version, versions[i].Version, locale, versions[i].EffectiveFrom.Format(time.RFC3339))
+			}
+		}
+	}
+
+	return scheduled, nil
+}
+
+// readVersion file, named after its version
+func readVersion(path string) (Version, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Version{}, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var file versionFile
+	if err := decoder.Decode(&file); err != nil {
+		return Version{}, err
+	}
+
+	switch {
+	case file.Version != strings.TrimSuffix(filepath.Base(path), ".json"):
+		return Version{}, fmt.Errorf("version %q does not match the file name", file.Version)
+	case file.EffectiveFrom.IsZero():
+		return Version{}, errors.New("no effectiveFrom")
+	case file.EffectiveUntil != nil && !file.EffectiveUntil.After(file.EffectiveFrom):
+		return Version{}, errors.New("effectiveUntil is not after effectiveFrom")
+	}
+
+	if err := Validate(file.Document); err != nil {
+		return Version{}, err
+	}
+
+	version := Version{
+		Version:       file.Version,
+		EffectiveFrom: file.EffectiveFrom.UTC(),
+		Document:      []byte(file.Document),
+		Digest:        Digest(file.Document),
+	}
+	if file.EffectiveUntil != nil {
+		version.EffectiveUntil = file.EffectiveUntil.UTC()
+	}
+
+	return version, nil
+}
+
+// At returns the version of the document for the locale in force at t: the scheduled version in force that took effect last, otherwise the document of the locale folder
+func (s *Store) At(locale string, t time.Time) (Version, error) {
+	if !s.Loaded() {
+		if err := s.Load(); err != nil {
+			return Version{}, err
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	versions := s.scheduled[locale]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].InForce(t) {
+			return versions[i], nil
+		}
+	}
+
+	document, found := s.documents[locale]
+	if !found {
+		return Version{}, ErrNotFound
+	}
+
+	return Version{Version: s.version, Document: document, Digest: s.digests[locale]}, nil
+}
+
+// Versions of the document for the locale, the document of the locale folder first, then the scheduled versions by EffectiveFrom
+func (s *Store) Versions(locale string) []Version {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	document, found := s.documents[locale]
+	if !found {
+		return nil
+	}
+
+	versions := []Version{{Version: s.version, Document: document, Digest: s.digests[locale]}}
+	return append(versions, s.scheduled[locale]...)
+}
+
+// NextChange after t when another version of the document for the locale may come into force, zero when none is scheduled
+func (s *Store) NextChange(locale string, t time.Time) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var next time.Time
+	for _, version := range s.scheduled[locale] {
+		for _, change := range []time.Time{version.EffectiveFrom, version.EffectiveUntil} {
+			if change.After(t) && (next.IsZero() || change.Before(next)) {
+				next = change
+			}
+		}
+	}
+
+	return next
+}
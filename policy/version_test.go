@@ -0,0 +1,144 @@
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	effective = time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	temporary = time.Date(2027, 6, 1, 0, 0, 0, 0, time.UTC)
+	lapsed    = time.Date(2027, 7, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// writeVersion file named name in the versions folder of the locale
+func writeVersion(t *testing.T, folder string, locale string, name string, contents string) {
+	versions := filepath.Join(folder, locale, VersionsFolder)
+	os.MkdirAll(versions, 0755)
+	if err := ioutil.WriteFile(filepath.Join(versions, name+".json"), []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func versionContents(version string, from string, until string, title string) string {
+	contents := `{"version": "` + version + `", "effectiveFrom": "` + from + `"`
+	if len(until) != 0 {
+		contents += `, "effectiveUntil": "` + until + `"`
+	}
+	return contents + `, "document": ` + strings.Replace(testDocument, "Title", title, 1) + `}`
+}
+
+// scheduledStore with 2.0.0 in force from 2027 and 2.1.0 in force for June 2027
+func scheduledStore(t *testing.T) (*Store, string) {
+	s, folder := tempStore(t)
+	ioutil.WriteFile(filepath.Join(folder, VersionName), []byte("1.0.0\n"), 0644)
+	writeVersion(t, folder, "en-US", "2.0.0", versionContents("2.0.0", "2027-01-01T00:00:00Z", "", "Title 2"))
+	writeVersion(t, folder, "en-US", "2.1.0", versionContents("2.1.0", "2027-06-01T00:00:00Z", "2027-07-01T00:00:00Z", "Title 2.1"))
+
+	if err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	return s, folder
+}
+
+func TestStoreAt(t *testing.T) {
+	s, folder := scheduledStore(t)
+	defer os.RemoveAll(folder)
+
+	expected := map[time.Time]string{
+		effective.Add(-time.Second):    "1.0.0",
+		effective:                      "2.0.0",
+		temporary.Add(-time.Second):    "2.0.0",
+		temporary:                      "2.1.0",
+		lapsed.Add(-time.Nanosecond):   "2.1.0",
+		lapsed:                         "2.0.0",
+		effective.AddDate(10, 0, 0):    "2.0.0",
+		effective.AddDate(-10, 0, 0):   "1.0.0",
+		temporary.Add(15 * time.Hour):  "2.1.0",
+		temporary.Add(-15 * time.Hour): "2.0.0",
+	}
+	for at, version := range expected {
+		actual, err := s.At("en-US", at)
+		if err != nil || actual.Version != version {
+			t.Errorf("Store returned wrong version at %v: got %v %v want %v", at, actual.Version, err, version)
+		}
+	}
+
+	current, _ := s.At("en-US", temporary)
+	if !strings.Contains(string(current.Document), "Title 2.1") || current.Digest != Digest(current.Document) {
+		t.Errorf("Store returned wrong document: got %v", string(current.Document))
+	}
+
+	if _, err := s.At("fr", effective); err != ErrNotFound {
+		t.Errorf("Store returned wrong error: got %v want %v", err, ErrNotFound)
+	}
+}
+
+func TestStoreVersions(t *testing.T) {
+	s, folder := scheduledStore(t)
+	defer os.RemoveAll(folder)
+
+	versions := s.Versions("en-US")
+	if len(versions) != 3 || versions[0].Version != "1.0.0" || versions[1].Version != "2.0.0" || versions[2].Version != "2.1.0" {
+		t.Fatalf("Store returned wrong versions: got %+v", versions)
+	}
+
+	if !versions[0].EffectiveFrom.IsZero() || !versions[1].EffectiveFrom.Equal(effective) || !versions[2].EffectiveUntil.Equal(lapsed) {
+		t.Errorf("Store returned wrong windows: got %+v", versions)
+	}
+
+	expected := map[time.Time]time.Time{
+		effective.AddDate(-1, 0, 0): effective,
+		effective:                   temporary,
+		temporary:                   lapsed,
+		lapsed:                      {},
+	}
+	for at, next := range expected {
+		if actual := s.NextChange("en-US", at); !actual.Equal(next) {
+			t.Errorf("Store returned wrong next change after %v: got %v want %v", at, actual, next)
+		}
+	}
+
+	s.Put("fr", []byte(testDocument), accept)
+	if err := s.Delete("en-US", accept); err != ErrScheduledVersions {
+		t.Errorf("Store returned wrong error: got %v want %v", err, ErrScheduledVersions)
+	}
+}
+
+func TestStoreInvalidVersions(t *testing.T) {
+	invalid := map[string]string{
+		versionContents("2.0.1", "2027-01-01T00:00:00Z", "", "Title"):                     "does not match the file name",
+		versionContents("2.0.0", "0001-01-01T00:00:00Z", "", "Title"):                     "no effectiveFrom",
+		versionContents("2.0.0", "2027-01-01T00:00:00Z", "2026-01-01T00:00:00Z", "Title"): "effectiveUntil is not after",
+		versionContents("2.0.0", "2027-01-01T00:00:00Z", "", ""):                          "has no title",
+		`{"version": "2.0.0", "effectiveFrom": "2027-01-01T00:00:00Z", "extra": true}`:    "unknown field",
+	}
+	for contents, expected := range invalid {
+		s, folder := tempStore(t)
+		writeVersion(t, folder, "en-US", "2.0.0", contents)
+
+		if err := s.Load(); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Store returned wrong error for %v: got %v want %v", contents, err, expected)
+		}
+		os.RemoveAll(folder)
+	}
+
+	s, folder := tempStore(t)
+	defer os.RemoveAll(folder)
+	writeVersion(t, folder, "en-US", "2.0.0", versionContents("2.0.0", "2027-01-01T00:00:00Z", "", "Title"))
+	writeVersion(t, folder, "en-US", "3.0.0", versionContents("3.0.0", "2027-01-01T00:00:00Z", "", "Title"))
+	if err := s.Load(); err == nil || !strings.Contains(err.Error(), "both take effect") {
+		t.Errorf("Store accepted versions taking effect together: got %v", err)
+	}
+
+	os.RemoveAll(filepath.Join(folder, "en-US", VersionsFolder))
+	writeVersion(t, folder, "fr", "2.0.0", versionContents("2.0.0", "2027-01-01T00:00:00Z", "", "Title"))
+	if err := s.Load(); err == nil || !strings.Contains(err.Error(), "to fall back to") {
+		t.Errorf("Store accepted a version without a document to fall back to: got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package ratelimit
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sweepInterval of Allow calls between removals of idle buckets
+const sweepInterval = 1024
+
+// Tier of clients sharing the same limits
+type Tier struct {
+	Name string
+	// Rate of tokens added per second; zero or less leaves the tier unlimited
+	Rate float64
+	// Burst of requests allowed at once, the size of the bucket
+	Burst int
+}
+
+// Unlimited reports whether the tier enforces no limit
+func (t Tier) Unlimited() bool {
+	return t.Rate <= 0
+}
+
+// Decision for a single request
+type Decision struct {
+	Allowed bool
+	// Limit of the bucket, zero for an unlimited tier
+	Limit int
+	// Remaining requests that may be sent at once
+	Remaining int
+	// Reset until the bucket is full again
+	Reset time.Duration
+	// RetryAfter until the next request is allowed, zero when Allowed
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter of token buckets keyed by client
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+	now     func() time.Time
+}
+
+// NewLimiter without any buckets
+func NewLimiter() *Limiter {
+	return &Limiter{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+// Allow a request from the client when its bucket for the tier holds a token
+func (l *Limiter) Allow(client string, tier Tier) Decision {
+	return l.take(client, tier, true)
+}
+
+// Peek at the decision Allow would make without spending a token
+func (l *Limiter) Peek(client string, tier Tier) Decision {
+	return l.take(client, tier, false)
+}
+
+func (l *Limiter) take(client string, tier Tier, spend bool) Decision {
+	if tier.Unlimited() {
+		return Decision{Allowed: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now, tier)
+
+	key := tier.Name + "|" + client
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(tier.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.refill(now, tier)
+
+	decision := Decision{Limit: tier.Burst}
+	if b.tokens >= 1 {
+		if spend {
+			b.tokens--
+		}
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = seconds((1 - b.tokens) / tier.Rate)
+	}
+
+	decision.Remaining = int(math.Floor(b.tokens))
+	decision.Reset = seconds((float64(tier.Burst) - b.tokens) / tier.Rate)
+	return decision
+}
+
+func (b *bucket) refill(now time.Time, tier Tier) {
+	b.tokens = math.Min(float64(tier.Burst), b.tokens+now.Sub(b.last).Seconds()*tier.Rate)
+	b.last = now
+}
+
+// sweep removes buckets of the tier that have refilled, bounding memory to active clients
+func (l *Limiter) sweep(now time.Time, tier Tier) {
+	l.calls++
+	if l.calls%sweepInterval != 0 {
+		return
+	}
+
+	prefix := tier.Name + "|"
+	for key, b := range l.buckets {
+		if strings.HasPrefix(key, prefix) {
+			b.refill(now, tier)
+			if b.tokens >= float64(tier.Burst) {
+				delete(l.buckets, key)
+			}
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// ParseTiers from a comma separated spec of name=rate:burst, e.g. ip=5:10,apikey=50:100
+func ParseTiers(spec string) (map[string]Tier, error) {
+	tiers := map[string]Tier{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		nameLimits := strings.SplitN(entry, "=", 2)
+		if len(nameLimits) != 2 {
+			return nil, errors.New("rate limit tier " + entry + " is not name=rate:burst")
+		}
+
+		rateBurst := strings.SplitN(nameLimits[1], ":", 2)
+		if len(rateBurst) != 2 {
+			return nil, errors.New("rate limit tier " + entry + " is not name=rate:burst")
+		}
+
+		rate, err := strconv.ParseFloat(rateBurst[0], 64)
+		if err != nil {
+			return nil, errors.New("rate limit tier " + entry + " has an invalid rate")
+		}
+
+		burst, err := strconv.Atoi(rateBurst[1])
+		if err != nil || (burst < 1 && rate > 0) {
+			return nil, errors.New("rate limit tier " + entry + " has an invalid burst")
+		}
+
+		name := strings.TrimSpace(nameLimits[0])
+		tiers[name] = Tier{Name: name, Rate: rate, Burst: burst}
+	}
+
+	return tiers, nil
+}
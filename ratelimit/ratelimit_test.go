@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestLimiter(clock *time.Time) *Limiter {
+	l := NewLimiter()
+	l.now = func() time.Time { return *clock }
+	return l
+}
+
+func TestAllowBurstThenLimit(t *testing.T) {
+	clock := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&clock)
+	tier := Tier{Name: "ip", Rate: 2, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		decision := l.Allow("192.0.2.1", tier)
+		if !decision.Allowed || decision.Remaining != i || decision.Limit != 3 {
+			t.Errorf("Allow returned wrong decision: got %+v want remaining %v", decision, i)
+		}
+	}
+
+	decision := l.Allow("192.0.2.1", tier)
+	if decision.Allowed {
+		t.Errorf("Allow should limit after the burst!")
+	}
+
+	if decision.RetryAfter != 500*time.Millisecond || decision.Reset != 1500*time.Millisecond {
+		t.Errorf("Allow returned wrong timing: got %+v", decision)
+	}
+
+	clock = clock.Add(500 * time.Millisecond)
+	if !l.Allow("192.0.2.1", tier).Allowed {
+		t.Errorf("Allow should refill at the tier rate!")
+	}
+}
+
+func TestAllowClientsAreIndependent(t *testing.T) {
+	clock := time.Now()
+	l := newTestLimiter(&clock)
+	tier := Tier{Name: "ip", Rate: 1, Burst: 1}
+
+	l.Allow("192.0.2.1", tier)
+	if !l.Allow("192.0.2.2", tier).Allowed {
+		t.Errorf("Allow shared a bucket between clients!")
+	}
+
+	if !l.Allow("192.0.2.1", Tier{Name: "apikey", Rate: 1, Burst: 1}).Allowed {
+		t.Errorf("Allow shared a bucket between tiers!")
+	}
+}
+
+func TestAllowUnlimited(t *testing.T) {
+	l := NewLimiter()
+	tier := Tier{Name: "internal", Rate: 0}
+
+	for i := 0; i < 100; i++ {
+		if decision := l.Allow("192.0.2.1", tier); !decision.Allowed || decision.Limit != 0 {
+			t.Fatalf("Allow limited an unlimited tier: got %+v", decision)
+		}
+	}
+}
+
+func TestPeek(t *testing.T) {
+	clock := time.Now()
+	l := newTestLimiter(&clock)
+	tier := Tier{Name: "ip", Rate: 1, Burst: 1}
+
+	for i := 0; i < 3; i++ {
+		if decision := l.Peek("192.0.2.1", tier); !decision.Allowed || decision.Remaining != 1 {
+			t.Fatalf("Peek spent a token: got %+v", decision)
+		}
+	}
+
+	l.Allow("192.0.2.1", tier)
+	if decision := l.Peek("192.0.2.1", tier); decision.Allowed || decision.RetryAfter != time.Second {
+		t.Errorf("Peek allowed a spent bucket: got %+v", decision)
+	}
+}
+
+func TestSweepRemovesIdleBuckets(t *testing.T) {
+	clock := time.Now()
+	l := newTestLimiter(&clock)
+	tier := Tier{Name: "ip", Rate: 1000, Burst: 1}
+
+	l.Allow("idle", tier)
+	clock = clock.Add(time.Second)
+	for i := 0; i < sweepInterval; i++ {
+		l.Allow("active", tier)
+	}
+
+	if _, ok := l.buckets["ip|idle"]; ok {
+		t.Errorf("sweep kept an idle bucket!")
+	}
+}
+
+func TestParseTiers(t *testing.T) {
+	tiers, err := ParseTiers("ip=5:10, apikey=50.5:100,internal=0:0")
+	if err != nil {
+		t.Fatalf("ParseTiers returned an error: %v", err)
+	}
+
+	expected := map[string]Tier{
+		"ip":       {Name: "ip", Rate: 5, Burst: 10},
+		"apikey":   {Name: "apikey", Rate: 50.5, Burst: 100},
+		"internal": {Name: "internal", Rate: 0, Burst: 0},
+	}
+	for name, tier := range expected {
+		if tiers[name] != tier {
+			t.Errorf("ParseTiers returned wrong tier %v: got %+v want %+v", name, tiers[name], tier)
+		}
+	}
+
+	for _, spec := range []string{"ip", "ip=5", "ip=fast:10", "ip=5:none", "ip=5:0"} {
+		if _, err := ParseTiers(spec); err == nil {
+			t.Errorf("ParseTiers accepted an invalid spec %v", spec)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package redact
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Mask replacing redacted header values
+const Mask = "[REDACTED]"
+
+// DefaultDeny headers always redacted
+var DefaultDeny = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+	"X-Csrf-Token",
+	"X-Amz-Security-Token",
+}
+
+// sensitiveFragments of header names treated as credentials even when not denied by name
+var sensitiveFragments = []string{
+	"auth",
+	"cookie",
+	"key",
+	"password",
+	"secret",
+	"session",
+	"signature",
+	"token",
+}
+
+// Policy deciding which header values may be logged verbatim
+type Policy struct {
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+// NewPolicy with the DefaultDeny headers plus deny; when allow is not empty every other header is redacted too
+func NewPolicy(allow []string, deny []string) *Policy {
+	p := &Policy{allow: map[string]bool{}, deny: map[string]bool{}}
+
+	for _, name := range allow {
+		p.allow[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+	}
+
+	for _, name := range append(append([]string{}, DefaultDeny...), deny...) {
+		p.deny[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+	}
+
+	return p
+}
+
+// Redacted reports whether the header value must be masked
+func (p *Policy) Redacted(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	if p.deny[name] {
+		return true
+	}
+
+	if len(p.allow) != 0 {
+		return !p.allow[name]
+	}
+
+	lower := strings.ToLower(name)
+	for _, fragment := range sensitiveFragments {
+		if strings.Contains(lower, fragment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Value of the header safe for logging
+func (p *Policy) Value(name string, value string) string {
+	if !p.Redacted(name) {
+		return value
+	}
+
+	// Keep the authentication scheme so logs still show how a client authenticated
+	if scheme := strings.SplitN(value, " ", 2); len(scheme) == 2 && isScheme(scheme[0]) {
+		return scheme[0] + " " + Mask
+	}
+
+	return Mask
+}
+
+// Headers flattened for logging with redacted values masked
+func (p *Policy) Headers(h http.Header) map[string]string {
+	headers := make(map[string]string, len(h))
+	for name, values := range h {
+		masked := make([]string, len(values))
+		for i, value := range values {
+			masked[i] = p.Value(name, value)
+		}
+		headers[name] = strings.Join(masked, ", ")
+	}
+
+	return headers
+}
+
+// URL with the values of redacted query parameters masked, for request URIs and Referer headers
+func (p *Policy) URL(rawURL string) string {
+	question := strings.IndexByte(rawURL, '?')
+	if question < 0 {
+		return rawURL
+	}
+
+	params := strings.Split(rawURL[question+1:], "&")
+	for i, param := range params {
+		name := strings.SplitN(param, "=", 2)[0]
+		// A name that cannot be decoded could hide a credential, so it is masked too
+		if decoded, err := url.QueryUnescape(name); err != nil || p.Redacted(decoded) {
+			params[i] = name + "=" + Mask
+		}
+	}
+
+	return rawURL[:question+1] + strings.Join(params, "&")
+}
+
+func isScheme(value string) bool {
+	switch strings.ToLower(value) {
+	case "basic", "bearer", "digest", "negotiate", "apikey":
+		return true
+	default:
+		return false
+	}
+}
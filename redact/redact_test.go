@@ -0,0 +1,88 @@
+package redact
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDefaultPolicyRedactsCredentials(t *testing.T) {
+	p := NewPolicy(nil, nil)
+
+	h := http.Header{}
+	h.Set("Authorization", "Bearer abc123")
+	h.Set("Cookie", "session=abc123")
+	h.Set("X-Api-Key", "abc123")
+	h.Set("X-Partner-Token", "abc123")
+	h.Set("Accept-Language", "fr")
+	h.Add("Correlationid", "123")
+	h.Add("Correlationid", "456")
+
+	expected := map[string]string{
+		"Authorization":   "Bearer " + Mask,
+		"Cookie":          Mask,
+		"X-Api-Key":       Mask,
+		"X-Partner-Token": Mask,
+		"Accept-Language": "fr",
+		"Correlationid":   "123, 456",
+	}
+
+	actual := p.Headers(h)
+	for name, value := range expected {
+		if actual[name] != value {
+			t.Errorf("Headers returned wrong %v: got %v want %v", name, actual[name], value)
+		}
+	}
+}
+
+func TestDenyList(t *testing.T) {
+	p := NewPolicy(nil, []string{"correlationid"})
+
+	if p.Value("CorrelationID", "123") != Mask {
+		t.Errorf("Value did not redact a denied header!")
+	}
+}
+
+func TestAllowList(t *testing.T) {
+	p := NewPolicy([]string{"Accept-Language", "Authorization"}, nil)
+
+	if p.Value("Accept-Language", "fr") != "fr" {
+		t.Errorf("Value redacted an allowed header!")
+	}
+
+	if p.Value("Correlationid", "123") != Mask {
+		t.Errorf("Value did not redact a header missing from the allow list!")
+	}
+
+	if p.Value("Authorization", "abc123") != Mask {
+		t.Errorf("Allow list should never expose a denied header!")
+	}
+}
+
+func TestValueKeepsOnlyKnownSchemes(t *testing.T) {
+	p := NewPolicy(nil, nil)
+
+	if p.Value("Authorization", "Basic dXNlcjpwYXNz") != "Basic "+Mask {
+		t.Errorf("Value did not keep the Basic scheme!")
+	}
+
+	if p.Value("Authorization", "abc123 with spaces") != Mask {
+		t.Errorf("Value leaked part of an unknown scheme!")
+	}
+}
+
+func TestURL(t *testing.T) {
+	p := NewPolicy(nil, []string{"sig"})
+
+	tests := map[string]string{
+		"/v1/policies/hazardousgoods":                             "/v1/policies/hazardousgoods",
+		"/v1/policies/hazardousgoods?at=2027-01-01":               "/v1/policies/hazardousgoods?at=2027-01-01",
+		"/evaluate?api_key=abc123&at=2027-01-01":                  "/evaluate?api_key=" + Mask + "&at=2027-01-01",
+		"https://book.example.com/cart?Access_Token=abc123&sig=1": "https://book.example.com/cart?Access_Token=" + Mask + "&sig=" + Mask,
+		"/evaluate?%zz=abc123":                                    "/evaluate?%zz=" + Mask,
+	}
+	for rawURL, expected := range tests {
+		if redacted := p.URL(rawURL); redacted != expected {
+			t.Errorf("URL returned wrong URL: got %v want %v", redacted, expected)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// UnmatchedRoute passed to Middleware for requests that match no pattern
+const UnmatchedRoute = "unmatched"
+
+// Middleware wrapping the handler registered for route; the first Middleware given to New is the outermost
+type Middleware func(route string, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request)
+
+// Router matching requests by method and path pattern, e.g. /policies/{id}
+type Router struct {
+	// NotFound handler for requests matching no pattern
+	NotFound func(http.ResponseWriter, *http.Request)
+	// MethodNotAllowed handler for patterns without a handler for the method; the Allow header is already set
+	MethodNotAllowed func(http.ResponseWriter, *http.Request)
+
+	middleware []Middleware
+	routes     []*route
+	unmatched  func(http.ResponseWriter, *http.Request)
+}
+
+type route struct {
+	pattern  string
+	segments []string
+	handlers map[string]func(http.ResponseWriter, *http.Request)
+	options  func(http.ResponseWriter, *http.Request)
+	rejected func(http.ResponseWriter, *http.Request)
+}
+
+type paramsKey struct{}
+
+// New Router applying the middleware to every registered handler
+func New(middleware ...Middleware) *Router {
+	rt := &Router{
+		NotFound:         http.NotFound,
+		MethodNotAllowed: methodNotAllowed,
+		middleware:       middleware,
+	}
+	rt.unmatched = rt.wrap(UnmatchedRoute, func(w http.ResponseWriter, r *http.Request) { rt.NotFound(w, r) })
+
+	return rt
+}
+
+// Handle requests for the method and pattern; segments written as {name} are path parameters read with Param
+func (rt *Router) Handle(method string, pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	rt.route(pattern).handlers[method] = rt.wrap(pattern, handler)
+}
+
+// Routes registered, in registration order
+func (rt *Router) Routes() []string {
+	patterns := make([]string, len(rt.routes))
+	for i, rte := range rt.routes {
+		patterns[i] = rte.pattern
+	}
+
+	return patterns
+}
+
+// ServeHTTP dispatches the request to the handler of the best matching pattern
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rte, params := rt.match(r.URL.Path)
+	if rte == nil {
+		rt.unmatched(w, r)
+		return
+	}
+
+	if len(params) != 0 {
+		r = r.WithContext(context.WithValue(r.Context(), paramsKey{}, params))
+	}
+
+	handler, ok := rte.handlers[r.Method]
+	if !ok && r.Method == http.MethodHead {
+		handler, ok = rte.handlers[http.MethodGet]
+	}
+
+	switch {
+	case ok:
+		handler(w, r)
+	case r.Method == http.MethodOptions:
+		w.Header().Set("Allow", rte.allow())
+		rte.options(w, r)
+	default:
+		w.Header().Set("Allow", rte.allow())
+		rte.rejected(w, r)
+	}
+}
+
+// Param of the path matched by the Router, empty when absent
+func Param(r *http.Request, name string) string {
+	params, _ := r.Context().Value(paramsKey{}).(map[string]string)
+	return params[name]
+}
+
+func (rt *Router) wrap(pattern string, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	for i := len(rt.middleware) - 1; i >= 0; i-- {
+		handler = rt.middleware[i](pattern, handler)
+	}
+
+	return handler
+}
+
+func (rt *Router) route(pattern string) *route {
+	for _, rte := range rt.routes {
+		if rte.pattern == pattern {
+			return rte
+		}
+	}
+
+	rte := &route{
+		pattern:  pattern,
+		segments: strings.Split(pattern, "/"),
+		handlers: map[string]func(http.ResponseWriter, *http.Request){},
+		options:  rt.wrap(pattern, func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }),
+		rejected: rt.wrap(pattern, func(w http.ResponseWriter, r *http.Request) { rt.MethodNotAllowed(w, r) }),
+	}
+	rt.routes = append(rt.routes, rte)
+
+	return rte
+}
+
+// match the path to the route with the most literal segments
+func (rt *Router) match(path string) (*route, map[string]string) {
+	segments := strings.Split(path, "/")
+
+	var best *route
+	var bestParams map[string]string
+	bestLiterals := -1
+	for _, rte := range rt.routes {
+		params, literals, ok := rte.match(segments)
+		if ok && literals > bestLiterals {
+			best, bestParams, bestLiterals = rte, params, literals
+		}
+	}
+
+	return best, bestParams
+}
+
+func (rte *route) match(segments []string) (map[string]string, int, bool) {
+	if len(segments) != len(rte.segments) {
+		return nil, 0, false
+	}
+
+	var params map[string]string
+	literals := 0
+	for i, segment := range rte.segments {
+		if name, ok := paramName(segment); ok && len(segments[i]) != 0 {
+			if params == nil {
+				params = map[string]string{}
+			}
+			params[name] = segments[i]
+			continue
+		}
+
+		if segment != segments[i] {
+			return nil, 0, false
+		}
+		literals++
+	}
+
+	return params, literals, true
+}
+
+// allow lists the methods of the route for the Allow header
+func (rte *route) allow() string {
+	methods := []string{http.MethodOptions}
+	for method := range rte.handlers {
+		methods = append(methods, method)
+	}
+	if _, ok := rte.handlers[http.MethodGet]; ok {
+		if _, ok := rte.handlers[http.MethodHead]; !ok {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
+func paramName(segment string) (string, bool) {
+	if len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+		return segment[1 : len(segment)-1], true
+	}
+
+	return "", false
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,149 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serve(rt *Router, method string, path string) *httptest.ResponseRecorder {
+	r, _ := http.NewRequest(method, path, nil)
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, r)
+
+	return w
+}
+
+func write(body string) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}
+}
+
+func TestMethodMatching(t *testing.T) {
+	rt := New()
+	rt.Handle(http.MethodGet, "/policies", write("list"))
+	rt.Handle(http.MethodPost, "/policies", write("create"))
+
+	if w := serve(rt, http.MethodGet, "/policies"); w.Body.String() != "list" {
+		t.Errorf("Router served wrong handler: got %v want %v", w.Body.String(), "list")
+	}
+
+	if w := serve(rt, http.MethodPost, "/policies"); w.Body.String() != "create" {
+		t.Errorf("Router served wrong handler: got %v want %v", w.Body.String(), "create")
+	}
+
+	if w := serve(rt, http.MethodHead, "/policies"); w.Code != http.StatusOK {
+		t.Errorf("Router should serve HEAD with the GET handler: got %v", w.Code)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	rt := New()
+	rt.Handle(http.MethodPost, "/evaluate", write("evaluate"))
+
+	w := serve(rt, http.MethodGet, "/evaluate")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Router returned wrong status code: got %v want %v", w.Code, http.StatusMethodNotAllowed)
+	}
+
+	if w.Header().Get("Allow") != "OPTIONS, POST" {
+		t.Errorf("Router returned wrong Allow header: got %v want %v", w.Header().Get("Allow"), "OPTIONS, POST")
+	}
+
+	rt.MethodNotAllowed = func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "custom", http.StatusMethodNotAllowed)
+	}
+	if w := serve(rt, http.MethodDelete, "/evaluate"); w.Body.String() != "custom\n" {
+		t.Errorf("Router ignored the MethodNotAllowed handler: got %v", w.Body.String())
+	}
+}
+
+func TestOptions(t *testing.T) {
+	rt := New()
+	rt.Handle(http.MethodGet, "/health", write("UP"))
+
+	w := serve(rt, http.MethodOptions, "/health")
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Router returned wrong status code: got %v want %v", w.Code, http.StatusNoContent)
+	}
+
+	if w.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("Router returned wrong Allow header: got %v want %v", w.Header().Get("Allow"), "GET, HEAD, OPTIONS")
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	rt := New()
+	rt.Handle(http.MethodGet, "/health", write("UP"))
+
+	for _, path := range []string{"/", "/health/", "/healthz", "/health/live"} {
+		if w := serve(rt, http.MethodGet, path); w.Code != http.StatusNotFound {
+			t.Errorf("Router returned wrong status code for %v: got %v want %v", path, w.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestPathParameters(t *testing.T) {
+	rt := New()
+	rt.Handle(http.MethodGet, "/policies/{id}", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "policy "+Param(r, "id"))
+	})
+	rt.Handle(http.MethodGet, "/policies/{id}/versions/{version}", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, Param(r, "id")+"@"+Param(r, "version"))
+	})
+	rt.Handle(http.MethodGet, "/policies/latest", write("latest"))
+
+	expected := map[string]string{
+		"/policies/fr":                 "policy fr",
+		"/policies/latest":             "latest",
+		"/policies/fr/versions/1.0.0":  "fr@1.0.0",
+		"/policies/en-US/versions/2.1": "en-US@2.1",
+	}
+	for path, body := range expected {
+		if w := serve(rt, http.MethodGet, path); w.Body.String() != body {
+			t.Errorf("Router served wrong body for %v: got %v want %v", path, w.Body.String(), body)
+		}
+	}
+
+	if w := serve(rt, http.MethodGet, "/policies/"); w.Code != http.StatusNotFound {
+		t.Errorf("Router matched an empty path parameter: got %v", w.Code)
+	}
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(route string, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+			return func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name+" "+route)
+				handler(w, r)
+			}
+		}
+	}
+
+	rt := New(record("outer"), record("inner"))
+	rt.Handle(http.MethodPost, "/evaluate", write("evaluate"))
+
+	serve(rt, http.MethodPost, "/evaluate")
+	serve(rt, http.MethodGet, "/evaluate")
+	serve(rt, http.MethodGet, "/missing")
+
+	expected := "outer /evaluate,inner /evaluate,outer /evaluate,inner /evaluate,outer unmatched,inner unmatched"
+	if strings.Join(calls, ",") != expected {
+		t.Errorf("Router applied Middleware in wrong order: got %v want %v", strings.Join(calls, ","), expected)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	rt := New()
+	rt.Handle(http.MethodGet, "/health", write("UP"))
+	rt.Handle(http.MethodPost, "/evaluate", write("evaluate"))
+	rt.Handle(http.MethodGet, "/health", write("UP"))
+
+	if strings.Join(rt.Routes(), ",") != "/health,/evaluate" {
+		t.Errorf("Routes returned wrong patterns: got %v", rt.Routes())
+	}
+}
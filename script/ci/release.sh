@@ -7,6 +7,8 @@ then
   BINARY_VERSION=0.0.1
 fi
 
+export BUILD_TIME=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+
 echo "BINARY_VERSION: $BINARY_VERSION"
 
-CGO_ENABLED=0 GOOS=linux go build -a -installsuffix cgo -o release/service -ldflags "-X github.com/dukeluke16/sample-golang-webservice/config.BinaryVersion=${BINARY_VERSION} -X github.com/dukeluke16/sample-golang-webservice/web.EnableNewRelic=true" .
+CGO_ENABLED=0 GOOS=linux go build -a -installsuffix cgo -o release/service -ldflags "-X github.com/dukeluke16/sample-golang-webservice/config.BinaryVersion=${BINARY_VERSION} -X github.com/dukeluke16/sample-golang-webservice/config.BuildTime=${BUILD_TIME} -X github.com/dukeluke16/sample-golang-webservice/web.EnableNewRelic=true" .
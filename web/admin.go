@@ -0,0 +1,306 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"golang.org/x/text/language"
+
+	"github.com/dukeluke16/sample-golang-webservice/audit"
+	"github.com/dukeluke16/sample-golang-webservice/auth"
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/logger"
+	"github.com/dukeluke16/sample-golang-webservice/policy"
+	"github.com/dukeluke16/sample-golang-webservice/router"
+)
+
+// AdminPolicyLocalesPath for endpoint
+var AdminPolicyLocalesPath = "/admin/v1/policies/{id}/locales"
+
+// AdminPolicyLocalePath for endpoint
+var AdminPolicyLocalePath = "/admin/v1/policies/{id}/locales/{locale}"
+
+// defaultAuditLogFile beside the documents it records changes to
+const defaultAuditLogFile = "../data/audit.log"
+
+// auditLog of every change made through the admin API
+var auditLog = audit.NewLog(defaultAuditLogFile)
+
+// errPreconditionFailed returned by a change when If-Match or If-None-Match rejects the current document
+var errPreconditionFailed = errors.New("precondition failed")
+
+// AdminLocale of a policy document with the digest its ETag is made of
+type AdminLocale struct {
+	Locale string `json:"locale"`
+	Digest string `json:"digest"`
+}
+
+// AdminLocalesResponse for the admin locale list
+type AdminLocalesResponse struct {
+	ID      string        `json:"id"`
+	Version string        `json:"version"`
+	Locales []AdminLocale `json:"locales"`
+	// Drafts awaiting publication, including those of locales not yet published
+	Drafts []AdminLocale `json:"drafts"`
+}
+
+// withAdmin responds Forbidden unless the authenticated client is listed in ADMIN_CLIENTS
+func withAdmin(handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return withAuthentication(func(w http.ResponseWriter, r *http.Request) {
+		// Without AUTH_MODE there is no identity, and the admin API stays closed
+		identity, ok := auth.FromContext(r.Context())
+		if !ok {
+			statusResponseError(w, r, http.StatusForbidden, "the admin API requires authentication")
+			return
+		}
+
+		for _, client := range config.ListValue(config.AdminClientsKey) {
+			if client == identity.Subject {
+				handler(w, r)
+				return
+			}
+		}
+
+		statusResponseError(w, r, http.StatusForbidden, "client "+identity.Subject+" is not an admin")
+	})
+}
+
+// AdminPolicyLocalesGetHandler for handling routed requests
+func AdminPolicyLocalesGetHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := knownPolicy(w, r)
+	if !ok {
+		return
+	}
+
+	if !policyStore.Loaded() {
+		if err := policyStore.Load(); err != nil {
+			genericStatusResponseError(w, r, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response := AdminLocalesResponse{ID: id, Version: policyStore.Version(), Locales: []AdminLocale{}, Drafts: []AdminLocale{}}
+	for _, locale := range policyStore.Locales() {
+		response.Locales = append(response.Locales, AdminLocale{Locale: locale, Digest: policyStore.Digest(locale)})
+	}
+	for _, locale := range policyStore.Drafts() {
+		if draft, err := policyStore.Draft(locale); err == nil {
+			response.Drafts = append(response.Drafts, AdminLocale{Locale: locale, Digest: draft.Digest})
+		}
+	}
+
+	body, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(body)
+}
+
+// AdminPolicyLocaleGetHandler for handling routed requests
+func AdminPolicyLocaleGetHandler(w http.ResponseWriter, r *http.Request) {
+	id, locale, r, ok := adminPolicyLocale(w, r)
+	if !ok {
+		return
+	}
+
+	document, err := policyStore.Document(locale)
+	if err == policy.ErrNotFound {
+		statusResponseError(w, r, http.StatusNotFound, "no "+locale+" locale of policy "+id)
+		return
+	}
+	if err != nil {
+		genericStatusResponseError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("ETag", adminETag(locale))
+	w.Write(document)
+}
+
+// AdminPolicyLocalePutHandler for handling routed requests, creating or replacing the document once it is validated and audited
+func AdminPolicyLocalePutHandler(w http.ResponseWriter, r *http.Request) {
+	id, locale, r, ok := adminPolicyLocale(w, r)
+	if !ok {
+		return
+	}
+
+	var document json.RawMessage
+	if err := decodeJSONBody(w, r, &document, false); err != nil {
+		if err == errEmptyBody {
+			statusResponseError(w, r, http.StatusBadRequest, "no policy document")
+		}
+		return
+	}
+
+	if err := policy.Validate(document); err != nil {
+		statusResponseError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	change := adminChange{r: r, id: id, locale: locale}
+	created := false
+	err := policyStore.Put(locale, document, func(previous []byte) error {
+		if !adminPreconditions(r, previous) {
+			return errPreconditionFailed
+		}
+
+		created = previous == nil
+		action := audit.ActionUpdate
+		if created {
+			action = audit.ActionCreate
+		}
+		return change.record(action, previous, document)
+	})
+	if !change.committed(w, err) {
+		return
+	}
+
+	w.Header().Set("ETag", adminETag(locale))
+	if created {
+		w.Header().Set("Location", r.URL.Path)
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminPolicyLocaleDeleteHandler for handling routed requests, deleting the document once it is audited
+func AdminPolicyLocaleDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	id, locale, r, ok := adminPolicyLocale(w, r)
+	if !ok {
+		return
+	}
+
+	// Requests without a supported Accept-Language are answered in the default locale
+	if locale == language.AmericanEnglish.String() {
+		statusResponseError(w, r, http.StatusConflict, locale+" is the default locale")
+		return
+	}
+
+	change := adminChange{r: r, id: id, locale: locale}
+	err := policyStore.Delete(locale, func(previous []byte) error {
+		if !adminPreconditions(r, previous) {
+			return errPreconditionFailed
+		}
+		return change.record(audit.ActionDelete, previous, nil)
+	})
+	if err == policy.ErrNotFound {
+		statusResponseError(w, r, http.StatusNotFound, "no "+locale+" locale of policy "+id)
+		return
+	}
+	if !change.committed(w, err) {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminPolicyLocale from the path parameters, responding Not Found for an unknown policy and Bad Request for a malformed locale
+func adminPolicyLocale(w http.ResponseWriter, r *http.Request) (string, string, *http.Request, bool) {
+	id, ok := knownPolicy(w, r)
+	if !ok {
+		return id, "", r, false
+	}
+
+	tag, err := language.Parse(router.Param(r, "locale"))
+	if err != nil {
+		statusResponseError(w, r, http.StatusBadRequest, "invalid locale "+router.Param(r, "locale"))
+		return id, "", r, false
+	}
+
+	return id, tag.String(), withLogFields(r, "locale", tag.String()), true
+}
+
+// adminETag of the current document for the locale, strong so it can be sent in If-Match
+func adminETag(locale string) string {
+	return `"` + policyStore.Digest(locale) + `"`
+}
+
+// adminPreconditions of If-Match and If-None-Match against the document a change replaces
+func adminPreconditions(r *http.Request, previous []byte) bool {
+	etag := ""
+	if previous != nil {
+		etag = `"` + policy.Digest(previous) + `"`
+	}
+
+	if ifMatch := r.Header.Get("If-Match"); len(ifMatch) != 0 && (previous == nil || !etagMatches(ifMatch, etag)) {
+		return false
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); len(ifNoneMatch) != 0 && previous != nil && etagMatches(ifNoneMatch, etag) {
+		return false
+	}
+
+	return true
+}
+
+// adminChange made by a request, audited before the store commits it so no change is ever left unaudited
+type adminChange struct {
+	r      *http.Request
+	id     string
+	locale string
+	// recorded action, compensated by a failed entry when the commit fails
+	recorded string
+}
+
+// record the change in the audit log with the client that made it
+func (c *adminChange) record(action string, previous []byte, document []byte) error {
+	identity, _ := auth.FromContext(c.r.Context())
+	err := auditLog.Record(audit.Entry{
+		Actor:      identity.Subject,
+		AuthMethod: identity.Method,
+		RequestID:  RequestIDFromContext(c.r.Context()),
+		Action:     action,
+		Resource:   c.id,
+		Locale:     c.locale,
+		Diff:       audit.Diff(previous, document),
+	})
+	if err != nil {
+		return err
+	}
+
+	c.recorded = action
+	logger.FromContext(c.r.Context()).Info("Policy document changed", "action", action, "actor", identity.Subject)
+	return nil
+}
+
+// committed reports whether err is nil, otherwise voiding the recorded entry with a failed one and responding with the status for err
+func (c *adminChange) committed(w http.ResponseWriter, err error) bool {
+	if err != nil && len(c.recorded) != 0 {
+		identity, _ := auth.FromContext(c.r.Context())
+		failed := audit.Entry{
+			Actor:      identity.Subject,
+			AuthMethod: identity.Method,
+			RequestID:  RequestIDFromContext(c.r.Context()),
+			Action:     audit.ActionFailed,
+			Resource:   c.id,
+			Locale:     c.locale,
+			Failed:     c.recorded,
+			Error:      err.Error(),
+		}
+		if recordErr := auditLog.Record(failed); recordErr != nil {
+			logger.FromContext(c.r.Context()).Error("Failed policy document change not audited", "action", c.recorded, "error", recordErr)
+		}
+	}
+
+	return adminChangeCommitted(w, c.r, err)
+}
+
+// adminChangeCommitted reports whether err is nil, otherwise responding with the status for err
+func adminChangeCommitted(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch err {
+	case nil:
+		return true
+	case errPreconditionFailed:
+		genericStatusResponseError(w, r, http.StatusPreconditionFailed)
+	case policy.ErrLastDocument, policy.ErrScheduledVersions:
+		statusResponseError(w, r, http.StatusConflict, err.Error())
+	default:
+		logger.FromContext(r.Context()).Error("Policy document change failed", "error", err)
+		genericStatusResponseError(w, r, http.StatusInternalServerError)
+	}
+
+	return false
+}
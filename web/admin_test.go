@@ -0,0 +1,290 @@
+package web
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dukeluke16/sample-golang-webservice/audit"
+	"github.com/dukeluke16/sample-golang-webservice/auth"
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/policy"
+)
+
+const adminDocument = `[{"code": "US", "alert": "Alerte", "title": "Matières dangereuses", "body": ["Paragraphe"]}]`
+
+// setupAdmin with a data folder of the en-US and fr documents, an admin and a booking client, and an audit log in the folder
+func setupAdmin(t *testing.T) (folder string, restore func()) {
+	folder, _ = ioutil.TempDir("", "admin")
+	for _, locale := range []string{"en-US", "fr"} {
+		document, _ := ioutil.ReadFile(filepath.Join("../data", locale, policy.DocumentName))
+		os.Mkdir(filepath.Join(folder, locale), 0755)
+		writeAuthFile(t, filepath.Join(folder, locale), policy.DocumentName, string(document))
+	}
+
+	path := writeAuthFile(t, folder, "apikeys.json", `[
+		{"name": "content-editor", "hash": "`+auth.HashAPIKey("editor-secret")+`"},
+		{"name": "booking-engine", "hash": "`+auth.HashAPIKey("booking-secret")+`"}]`)
+	store, err := auth.NewAPIKeyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	originalStore, originalLog, originalAuthenticator := policyStore, auditLog, authenticator
+	policyStore = policy.NewStore(folder)
+	auditLog = audit.NewLog(filepath.Join(folder, "audit.log"))
+	authenticator = store
+	os.Setenv(config.AdminClientsKey, "content-editor")
+
+	return folder, func() {
+		policyStore, auditLog, authenticator = originalStore, originalLog, originalAuthenticator
+		os.Unsetenv(config.AdminClientsKey)
+		os.RemoveAll(folder)
+	}
+}
+
+func serveAdmin(method string, path string, route string, apiKey string, body string, headers map[string]string) *httptest.ResponseRecorder {
+	r, _ := http.NewRequest(method, path, strings.NewReader(body))
+	if len(body) == 0 {
+		r.Body = http.NoBody
+	}
+	r.Header.Set("Content-Type", "application/json")
+	if len(apiKey) != 0 {
+		r.Header.Set(APIKeyHeader, apiKey)
+	}
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, r)
+	recordExchange(method, route, w)
+
+	return w
+}
+
+func readAuditLog(t *testing.T) []audit.Entry {
+	data, _ := ioutil.ReadFile(auditLog.Path())
+
+	var entries []audit.Entry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry audit.Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestAdminAuthorization(t *testing.T) {
+	_, restore := setupAdmin(t)
+	defer restore()
+
+	path := "/admin/v1/policies/hazardousgoods/locales"
+	if w := serveAdmin(http.MethodGet, path, AdminPolicyLocalesPath, "", "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusUnauthorized)
+	}
+
+	w := serveAdmin(http.MethodGet, path, AdminPolicyLocalesPath, "booking-secret", "", nil)
+	if w.Code != http.StatusForbidden || !strings.HasPrefix(w.Body.String(), "Forbidden: client booking-engine is not an admin") {
+		t.Errorf("handler accepted a client that is not an admin: got %v %v", w.Code, w.Body.String())
+	}
+
+	// Without AUTH_MODE the admin API stays closed
+	authenticator = nil
+	if w := serveAdmin(http.MethodGet, path, AdminPolicyLocalesPath, "", "", nil); w.Code != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestAdminPolicyLocalesGetHandler(t *testing.T) {
+	_, restore := setupAdmin(t)
+	defer restore()
+
+	w := serveAdmin(http.MethodGet, "/admin/v1/policies/hazardousgoods/locales", AdminPolicyLocalesPath, "editor-secret", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+	}
+
+	var response AdminLocalesResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.Locales) != 2 || response.Locales[0].Locale != "en-US" || response.Locales[1].Digest != policyStore.Digest("fr") {
+		t.Errorf("handler returned wrong locales: got %+v", response)
+	}
+
+	if w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("handler returned wrong Cache-Control: got %v", w.Header().Get("Cache-Control"))
+	}
+}
+
+func TestAdminPolicyLocaleGetHandler(t *testing.T) {
+	_, restore := setupAdmin(t)
+	defer restore()
+
+	w := serveAdmin(http.MethodGet, "/admin/v1/policies/hazardousgoods/locales/fr", AdminPolicyLocalePath, "editor-secret", "", nil)
+	document, _ := policyStore.Document("fr")
+	if w.Code != http.StatusOK || w.Body.String() != string(document) {
+		t.Errorf("handler returned wrong document: got %v %v", w.Code, w.Body.String())
+	}
+
+	if w.Header().Get("ETag") != `"`+policyStore.Digest("fr")+`"` {
+		t.Errorf("handler returned wrong ETag: got %v", w.Header().Get("ETag"))
+	}
+
+	expected := map[string]int{
+		"/admin/v1/policies/hazardousgoods/locales/de":     http.StatusNotFound,
+		"/admin/v1/policies/unknown/locales/fr":            http.StatusNotFound,
+		"/admin/v1/policies/hazardousgoods/locales/not-a!": http.StatusBadRequest,
+	}
+	for path, status := range expected {
+		if w := serveAdmin(http.MethodGet, path, AdminPolicyLocalePath, "editor-secret", "", nil); w.Code != status {
+			t.Errorf("handler returned wrong status code for %v: got %v want %v", path, w.Code, status)
+		}
+	}
+}
+
+func TestAdminPolicyLocalePutHandler(t *testing.T) {
+	folder, restore := setupAdmin(t)
+	defer restore()
+
+	w := serveAdmin(http.MethodPut, "/admin/v1/policies/hazardousgoods/locales/fr-ca", AdminPolicyLocalePath, "editor-secret", adminDocument, nil)
+	if w.Code != http.StatusCreated || w.Header().Get("Location") != "/admin/v1/policies/hazardousgoods/locales/fr-ca" {
+		t.Fatalf("handler did not create the document: got %v %v", w.Code, w.Body.String())
+	}
+
+	if data, _ := ioutil.ReadFile(filepath.Join(folder, "fr-CA", policy.DocumentName)); string(data) != adminDocument {
+		t.Errorf("handler did not write the document: got %v", string(data))
+	}
+
+	// The public API serves the document right away
+	if w := servePolicyLocale("/v1/policies/hazardousgoods/locales/fr-CA", ""); w.Code != http.StatusOK || w.Body.String() != adminDocument {
+		t.Errorf("handler did not serve the created document: got %v %v", w.Code, w.Body.String())
+	}
+
+	etag := w.Header().Get("ETag")
+	updated := strings.Replace(adminDocument, "Paragraphe", "Nouveau paragraphe", 1)
+	w = serveAdmin(http.MethodPut, "/admin/v1/policies/hazardousgoods/locales/fr-CA", AdminPolicyLocalePath, "editor-secret", updated, map[string]string{"If-Match": etag, "X-Request-ID": "edit-42"})
+	if w.Code != http.StatusNoContent || w.Header().Get("ETag") == etag {
+		t.Errorf("handler did not update the document: got %v %v", w.Code, w.Header().Get("ETag"))
+	}
+
+	entries := readAuditLog(t)
+	if len(entries) != 2 {
+		t.Fatalf("handler did not audit every change: got %+v", entries)
+	}
+
+	if entries[0].Action != audit.ActionCreate || entries[1].Action != audit.ActionUpdate {
+		t.Errorf("handler audited wrong actions: got %v %v", entries[0].Action, entries[1].Action)
+	}
+
+	expected := audit.Entry{
+		Time:       entries[1].Time,
+		Actor:      "content-editor",
+		AuthMethod: auth.MethodAPIKey,
+		RequestID:  "edit-42",
+		Action:     audit.ActionUpdate,
+		Resource:   PolicyID,
+		Locale:     "fr-CA",
+		Diff:       "@@ -1 +1 @@\n-" + adminDocument + "\n+" + updated + "\n",
+	}
+	if entries[1] != expected || entries[1].Time.IsZero() {
+		t.Errorf("handler audited wrong entry: got %+v want %+v", entries[1], expected)
+	}
+}
+
+func TestAdminPolicyLocalePutHandlerRejected(t *testing.T) {
+	_, restore := setupAdmin(t)
+	defer restore()
+
+	path := "/admin/v1/policies/hazardousgoods/locales/fr"
+	tests := []struct {
+		name    string
+		body    string
+		headers map[string]string
+		status  int
+	}{
+		{"empty", "", nil, http.StatusBadRequest},
+		{"invalid", `[{"code": "US"}]`, nil, http.StatusBadRequest},
+		{"malformed", `[{`, nil, http.StatusBadRequest},
+		{"stale", adminDocument, map[string]string{"If-Match": `"0000000000000000"`}, http.StatusPreconditionFailed},
+		{"existing", adminDocument, map[string]string{"If-None-Match": "*"}, http.StatusPreconditionFailed},
+		{"not json", adminDocument, map[string]string{"Content-Type": "text/plain"}, http.StatusUnsupportedMediaType},
+	}
+	for _, test := range tests {
+		if w := serveAdmin(http.MethodPut, path, AdminPolicyLocalePath, "editor-secret", test.body, test.headers); w.Code != test.status {
+			t.Errorf("handler returned wrong status code for the %v document: got %v want %v", test.name, w.Code, test.status)
+		}
+	}
+
+	if w := serveAdmin(http.MethodPut, "/admin/v1/policies/hazardousgoods/locales/de", AdminPolicyLocalePath, "editor-secret", adminDocument, map[string]string{"If-Match": "*"}); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("handler created a document required to exist: got %v", w.Code)
+	}
+
+	if entries := readAuditLog(t); len(entries) != 0 {
+		t.Errorf("handler audited rejected changes: got %+v", entries)
+	}
+}
+
+func TestAdminPolicyLocalePutHandlerAuditFailure(t *testing.T) {
+	folder, restore := setupAdmin(t)
+	defer restore()
+
+	auditLog = audit.NewLog(filepath.Join(folder, "missing", "audit.log"))
+	w := serveAdmin(http.MethodPut, "/admin/v1/policies/hazardousgoods/locales/fr", AdminPolicyLocalePath, "editor-secret", adminDocument, nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusInternalServerError)
+	}
+
+	if document, _ := policyStore.Document("fr"); string(document) == adminDocument {
+		t.Errorf("handler changed the document without auditing it")
+	}
+}
+
+func TestAdminPolicyLocaleDeleteHandler(t *testing.T) {
+	folder, restore := setupAdmin(t)
+	defer restore()
+
+	path := "/admin/v1/policies/hazardousgoods/locales/fr"
+	if w := serveAdmin(http.MethodDelete, path, AdminPolicyLocalePath, "editor-secret", "", map[string]string{"If-Match": `"stale"`}); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusPreconditionFailed)
+	}
+
+	document, _ := policyStore.Document("fr")
+	if w := serveAdmin(http.MethodDelete, path, AdminPolicyLocalePath, "editor-secret", "", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("handler returned wrong status code: got %v want %v", w.Code, http.StatusNoContent)
+	}
+
+	if _, err := os.Stat(filepath.Join(folder, "fr", policy.DocumentName)); !os.IsNotExist(err) {
+		t.Errorf("handler did not remove the document: got %v", err)
+	}
+
+	if w := servePolicyLocale("/v1/policies/hazardousgoods/locales/fr", ""); w.Code != http.StatusNotFound {
+		t.Errorf("handler served a deleted document: got %v", w.Code)
+	}
+
+	entries := readAuditLog(t)
+	if len(entries) != 1 || entries[0].Action != audit.ActionDelete || !strings.HasPrefix(entries[0].Diff, "@@ -1,") || !strings.Contains(entries[0].Diff, "-"+strings.Split(string(document), "\n")[0]) {
+		t.Errorf("handler audited wrong entry: got %+v", entries)
+	}
+
+	expected := map[string]int{
+		path: http.StatusNotFound,
+		"/admin/v1/policies/hazardousgoods/locales/en-us": http.StatusConflict,
+	}
+	for path, status := range expected {
+		if w := serveAdmin(http.MethodDelete, path, AdminPolicyLocalePath, "editor-secret", "", nil); w.Code != status {
+			t.Errorf("handler returned wrong status code for %v: got %v want %v", path, w.Code, status)
+		}
+	}
+}
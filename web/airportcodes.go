@@ -0,0 +1,71 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/logger"
+)
+
+// airportCodePattern of a three letter IATA airport code
+var airportCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// normalizeAirportCodes upper-cases and de-duplicates the codes, separating out the invalid ones
+func normalizeAirportCodes(airportCodes []string) (valid []string, invalid []string) {
+	seen := map[string]bool{}
+	for _, airportCode := range airportCodes {
+		code := strings.ToUpper(strings.TrimSpace(airportCode))
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		if airportCodePattern.MatchString(code) {
+			valid = append(valid, code)
+		} else {
+			invalid = append(invalid, airportCode)
+		}
+	}
+
+	return valid, invalid
+}
+
+// validateAirportCodes before any upstream call, responding Bad Request when checkAirportCodes rejects the list
+func validateAirportCodes(w http.ResponseWriter, r *http.Request, airportCodes []string) ([]string, bool) {
+	valid, detail := checkAirportCodes(r.Context(), airportCodes)
+	if len(detail) != 0 {
+		statusResponseError(w, r, http.StatusBadRequest, detail)
+		return nil, false
+	}
+
+	return valid, true
+}
+
+// checkAirportCodes returns the normalized codes, or why the list is rejected: it is too long or, unless lenient, holds invalid codes
+func checkAirportCodes(ctx context.Context, airportCodes []string) ([]string, string) {
+	maxCodes := config.IntValue(config.AirportCodesMaxKey, 10)
+	if len(airportCodes) > maxCodes {
+		return nil, fmt.Sprintf("%d airport codes exceed the maximum of %d", len(airportCodes), maxCodes)
+	}
+
+	valid, invalid := normalizeAirportCodes(airportCodes)
+	if len(invalid) == 0 {
+		return valid, ""
+	}
+
+	quoted := make([]string, len(invalid))
+	for i, code := range invalid {
+		quoted[i] = fmt.Sprintf("%q", code)
+	}
+
+	if config.StringValue(config.AirportCodesLenientKey, "false") == "true" {
+		logger.FromContext(ctx).Info("Invalid airport codes skipped", "invalid", strings.Join(quoted, ", "))
+		return valid, ""
+	}
+
+	return nil, "invalid airport codes " + strings.Join(quoted, ", ")
+}
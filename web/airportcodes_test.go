@@ -0,0 +1,102 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dukeluke16/sample-golang-webservice/config"
+)
+
+// setupCountingServer records how many lookups reach the Location Services
+func setupCountingServer(lookups *int) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*lookups++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	setServiceEndpoint(ts.URL)
+	return ts
+}
+
+func TestNormalizeAirportCodes(t *testing.T) {
+	valid, invalid := normalizeAirportCodes([]string{"sea", "SEA", " lcy ", "", "ABCDEFGHIJKLMNOPQRST", "S3A", "lax", "", "sé"})
+
+	if strings.Join(valid, ",") != "SEA,LCY,LAX" {
+		t.Errorf("normalizeAirportCodes returned wrong valid codes: got %v want %v", valid, "SEA,LCY,LAX")
+	}
+
+	if strings.Join(invalid, ",") != ",ABCDEFGHIJKLMNOPQRST,S3A,sé" {
+		t.Errorf("normalizeAirportCodes returned wrong invalid codes: got %v", invalid)
+	}
+}
+
+func TestEvaluateResponseInvalidAirportCodes(t *testing.T) {
+	lookups := 0
+	ts := setupCountingServer(&lookups)
+	defer ts.Close()
+
+	w := setupPostRequestAndServe(strings.NewReader(`["sea", "", "toolong"]`), nil)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusBadRequest)
+	}
+
+	expected := `Bad Request: invalid airport codes "", "toolong"` + "\n"
+	if w.Body.String() != expected {
+		t.Errorf("handler returned unexpected body: got %v want %v", w.Body.String(), expected)
+	}
+
+	if lookups != 0 {
+		t.Errorf("handler called the Location Services for invalid input: got %v lookups", lookups)
+	}
+}
+
+func TestEvaluateResponseTooManyAirportCodes(t *testing.T) {
+	lookups := 0
+	ts := setupCountingServer(&lookups)
+	defer ts.Close()
+	os.Setenv(config.AirportCodesMaxKey, "2")
+	defer os.Unsetenv(config.AirportCodesMaxKey)
+
+	w := setupV2RequestAndServe(strings.NewReader(`{"airportCodes": ["sea", "lax", "jfk"]}`), nil)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusBadRequest)
+	}
+
+	expected := "Bad Request: 3 airport codes exceed the maximum of 2\n"
+	if w.Body.String() != expected {
+		t.Errorf("handler returned unexpected body: got %v want %v", w.Body.String(), expected)
+	}
+
+	if lookups != 0 {
+		t.Errorf("handler called the Location Services for too many codes: got %v lookups", lookups)
+	}
+}
+
+func TestEvaluateResponseLenientAirportCodes(t *testing.T) {
+	ts := setupFakeServerUSA()
+	defer ts.Close()
+	os.Setenv(config.AirportCodesLenientKey, "true")
+	defer os.Unsetenv(config.AirportCodesLenientKey)
+
+	w := setupPostRequestAndServe(strings.NewReader(`["", "sea", "SEA", "toolong"]`), nil)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+	}
+}
+
+func TestEvaluateResponseDuplicateAirportCodes(t *testing.T) {
+	lookups := 0
+	ts := setupCountingServer(&lookups)
+	defer ts.Close()
+
+	setupPostRequestAndServe(strings.NewReader(`["sea", "SEA", "Sea"]`), nil)
+
+	if lookups != 1 {
+		t.Errorf("handler looked up duplicates: got %v lookups want %v", lookups, 1)
+	}
+}
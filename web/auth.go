@@ -0,0 +1,104 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dukeluke16/sample-golang-webservice/accesslog"
+	"github.com/dukeluke16/sample-golang-webservice/auth"
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/logger"
+	"github.com/dukeluke16/sample-golang-webservice/metrics"
+)
+
+// Authentication Modes listed in AUTH_MODE
+const (
+	AuthModeNone   = "none"
+	AuthModeAPIKey = auth.MethodAPIKey
+	AuthModeJWT    = auth.MethodJWT
+)
+
+// authenticator of evaluation requests, nil to leave them open until Start configures AUTH_MODE
+var authenticator auth.Authenticator
+
+// configureAuthenticator for each mode listed in AUTH_MODE, reloading its file whenever it changes
+func configureAuthenticator() (auth.Authenticator, error) {
+	interval := config.DurationValue(config.AuthReloadIntervalKey, 30*time.Second)
+
+	var chain auth.Chain
+	for _, mode := range config.ListValue(config.AuthModeKey) {
+		switch mode {
+		case AuthModeNone:
+			continue
+		case AuthModeAPIKey:
+			store, err := auth.NewAPIKeyStore(config.StringValue(config.AuthAPIKeysFileKey, ""))
+			if err != nil {
+				return nil, err
+			}
+			watchAuthFile(store.Path(), interval, store.Load)
+			chain = append(chain, store)
+		case AuthModeJWT:
+			verifier, err := auth.NewJWTVerifier(auth.JWTOptions{
+				JWKSPath:  config.StringValue(config.AuthJWKSFileKey, ""),
+				Issuer:    config.StringValue(config.AuthJWTIssuerKey, ""),
+				Audience:  config.StringValue(config.AuthJWTAudienceKey, ""),
+				TierClaim: config.StringValue(config.AuthJWTTierClaimKey, ""),
+			})
+			if err != nil {
+				return nil, err
+			}
+			watchAuthFile(verifier.Path(), interval, verifier.Load)
+			chain = append(chain, verifier)
+		default:
+			return nil, errors.New("authentication mode " + mode + " is not supported")
+		}
+	}
+
+	if len(chain) == 0 {
+		logger.Warning("Authentication is bypassed")
+		return nil, nil
+	}
+
+	return chain, nil
+}
+
+func watchAuthFile(path string, interval time.Duration, reload func() error) {
+	auth.Watch(path, interval, func() error {
+		if err := reload(); err != nil {
+			return err
+		}
+		logger.Info("Authentication file reloaded", "path", path)
+		return nil
+	}, func(err error) {
+		logger.Warning("Authentication file kept its previous contents", "path", path, "error", err)
+	})
+}
+
+// withAuthentication responds Unauthorized unless the authenticator accepts the credentials, storing the client identity in the context
+func withAuthentication(handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if authenticator == nil {
+			handler(w, r)
+			return
+		}
+
+		identity, err := authenticator.Authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", authenticator.Challenge())
+			reason := "invalid"
+			if err == auth.ErrNoCredentials {
+				reason = "missing"
+			}
+			metrics.AuthenticationFailures.WithLabelValues(reason).Inc()
+			statusResponseError(w, r, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		accesslog.FromContext(r.Context()).Client = identity.Subject
+		r = withLogFields(r.WithContext(auth.NewContext(r.Context(), identity)),
+			"client", identity.Subject,
+			"authMethod", identity.Method)
+		handler(w, r)
+	}
+}
@@ -0,0 +1,160 @@
+package web
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/dukeluke16/sample-golang-webservice/accesslog"
+	"github.com/dukeluke16/sample-golang-webservice/auth"
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/metrics"
+)
+
+func writeAuthFile(t *testing.T, dir string, name string, contents string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func setupAPIKeyAuthenticator(t *testing.T) (restore func()) {
+	dir, _ := ioutil.TempDir("", "auth")
+	path := writeAuthFile(t, dir, "apikeys.json", `[{"name": "booking-engine", "hash": "`+auth.HashAPIKey("booking-secret")+`", "tier": "partner"}]`)
+
+	store, err := auth.NewAPIKeyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original := authenticator
+	authenticator = store
+	return func() {
+		authenticator = original
+		os.RemoveAll(dir)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	defer setupAPIKeyAuthenticator(t)()
+	os.Setenv(config.RateLimitTiersKey, "ip=1:3,partner=1:7")
+	defer os.Unsetenv(config.RateLimitTiersKey)
+	before := testutil.ToFloat64(metrics.AuthenticationFailures.WithLabelValues("missing"))
+
+	handler := NewRouter()
+	w := serveLimited(handler, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusUnauthorized)
+	}
+
+	if challenge := w.Header().Get("WWW-Authenticate"); challenge != `ApiKey header="X-Api-Key"` {
+		t.Errorf("handler returned wrong challenge: got %v", challenge)
+	}
+
+	if !strings.HasPrefix(w.Body.String(), "Unauthorized: no credentials") {
+		t.Errorf("handler returned unexpected body: got %v", w.Body.String())
+	}
+
+	actual := testutil.ToFloat64(metrics.AuthenticationFailures.WithLabelValues("missing")) - before
+	if actual != 1 {
+		t.Errorf("AuthenticationFailures does not match: got %v want %v", actual, 1)
+	}
+
+	if w := serveLimited(handler, "guess"); w.Code != http.StatusUnauthorized || !strings.HasPrefix(w.Body.String(), "Unauthorized: unknown API key") {
+		t.Errorf("handler accepted an unknown API key: got %v %v", w.Code, w.Body.String())
+	}
+
+	w = serveLimited(handler, "booking-secret")
+	if w.Code != http.StatusNoContent {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusNoContent)
+	}
+
+	if limit := w.Header().Get("RateLimit-Limit"); limit != "7" {
+		t.Errorf("handler did not limit the client by its tier: got %v want %v", limit, "7")
+	}
+}
+
+func TestAuthenticationExemptsHealth(t *testing.T) {
+	defer setupAPIKeyAuthenticator(t)()
+
+	for _, path := range []string{HealthPath, HealthLivePath} {
+		if w := serveRouter(http.MethodGet, path); w.Code != http.StatusOK {
+			t.Errorf("handler required authentication for %v: got %v", path, w.Code)
+		}
+	}
+}
+
+func TestAuthenticationIdentity(t *testing.T) {
+	defer setupAPIKeyAuthenticator(t)()
+
+	var identity auth.Identity
+	handler := withAuthentication(func(w http.ResponseWriter, r *http.Request) {
+		identity, _ = auth.FromContext(r.Context())
+	})
+
+	entry := &accesslog.Entry{}
+	r, _ := http.NewRequest(http.MethodPost, EvaluateV1Path, nil)
+	r.Header.Set(APIKeyHeader, "booking-secret")
+	handler(httptest.NewRecorder(), r.WithContext(accesslog.NewContext(r.Context(), entry)))
+
+	expected := auth.Identity{Subject: "booking-engine", Method: auth.MethodAPIKey, Tier: "partner"}
+	if identity != expected {
+		t.Errorf("handler stored wrong Identity: got %+v want %+v", identity, expected)
+	}
+
+	if entry.Client != "booking-engine" {
+		t.Errorf("handler logged wrong client: got %v want %v", entry.Client, "booking-engine")
+	}
+
+	r = r.WithContext(auth.NewContext(r.Context(), expected))
+	if tier, _, client := rateLimitClient(r); tier != "partner" || client != "apikey:booking-engine" {
+		t.Errorf("rateLimitClient returned wrong client: got %v %v", tier, client)
+	}
+}
+
+func TestConfigureAuthenticator(t *testing.T) {
+	defer os.Unsetenv(config.AuthModeKey)
+	defer os.Unsetenv(config.AuthAPIKeysFileKey)
+	defer os.Unsetenv(config.AuthJWKSFileKey)
+	defer os.Unsetenv(config.AuthJWTIssuerKey)
+	defer os.Unsetenv(config.AuthJWTAudienceKey)
+
+	for _, mode := range []string{"", AuthModeNone} {
+		os.Setenv(config.AuthModeKey, mode)
+		if configured, err := configureAuthenticator(); configured != nil || err != nil {
+			t.Errorf("configureAuthenticator should bypass mode %q: got %v %v", mode, configured, err)
+		}
+	}
+
+	for _, mode := range []string{"basic", AuthModeAPIKey, AuthModeJWT} {
+		os.Setenv(config.AuthModeKey, mode)
+		if _, err := configureAuthenticator(); err == nil {
+			t.Errorf("configureAuthenticator accepted mode %v without its configuration", mode)
+		}
+	}
+
+	dir, _ := ioutil.TempDir("", "auth")
+	defer os.RemoveAll(dir)
+	os.Setenv(config.AuthModeKey, "apikey, jwt")
+	os.Setenv(config.AuthAPIKeysFileKey, writeAuthFile(t, dir, "apikeys.json", `[]`))
+	os.Setenv(config.AuthJWKSFileKey, writeAuthFile(t, dir, "jwks.json", `{"keys": []}`))
+	os.Setenv(config.AuthJWTIssuerKey, "https://issuer.example.com")
+	os.Setenv(config.AuthJWTAudienceKey, "hazardousgoods")
+
+	configured, err := configureAuthenticator()
+	if err != nil {
+		t.Fatalf("configureAuthenticator returned an error: %v", err)
+	}
+
+	if challenge := configured.Challenge(); challenge != `ApiKey header="X-Api-Key", Bearer` {
+		t.Errorf("configureAuthenticator returned wrong challenge: got %v", challenge)
+	}
+}
@@ -0,0 +1,66 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dukeluke16/sample-golang-webservice/compression"
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/policy"
+)
+
+// defaultPolicyCacheControl lets clients and shared caches reuse a policy document for an hour
+const defaultPolicyCacheControl = "public, max-age=3600"
+
+// policyVary of policy responses, which depend on the negotiated locale and, for previews, on the credentials of the client
+const policyVary = "Accept-Language, " + PolicyPreviewHeader + ", Authorization, " + APIKeyHeader
+
+// policyETag for the locale and version, changing whenever the document does; each content encoding has its own
+func policyETag(locale string, version policy.Version, encoding string) string {
+	etag := locale + "-" + version.Version + "-" + version.Digest
+	if encoding != compression.EncodingIdentity {
+		etag += "-" + encoding
+	}
+
+	return `"` + etag + `"`
+}
+
+// writePolicyDocument with its validators, answering Not Modified to a GET when the client already holds the document
+func writePolicyDocument(w http.ResponseWriter, r *http.Request, locale string, version policy.Version) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Language", locale)
+	w.Header().Add("Vary", policyVary)
+	policyVersionMetadata(w, version)
+
+	encoding, body := encodePolicyDocument(w, r, locale, version)
+	etag := policyETag(locale, version, encoding)
+	w.Header().Set("ETag", etag)
+
+	// Evaluations depend on the request body, so only GET responses may be cached or revalidated
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		cacheControl := config.StringValue(config.PolicyCacheControlKey, defaultPolicyCacheControl)
+		w.Header().Set("Cache-Control", cacheControlUntil(cacheControl, policyStore.NextChange(locale, policyClock())))
+		// Previews are only for the client that asked, and drafts change without notice
+		if previewing(r.Context()) {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.Write(body)
+}
+
+// etagMatches the If-None-Match header using the weak comparison RFC 7232 requires for it
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
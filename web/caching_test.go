@@ -0,0 +1,65 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/dukeluke16/sample-golang-webservice/compression"
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/policy"
+)
+
+var testPolicyVersion = policy.Version{Version: "1.0.0", Document: []byte("[]"), Digest: policy.Digest([]byte("[]"))}
+
+// currentPolicyETag of the version of the document for the locale in force now
+func currentPolicyETag(locale string, encoding string) string {
+	version, _ := policyStore.At(locale, policyClock())
+	return policyETag(locale, version, encoding)
+}
+
+func TestEtagMatches(t *testing.T) {
+	expected := map[string]bool{
+		``:         false,
+		`"a"`:      true,
+		`W/"a"`:    true,
+		`"b", "a"`: true,
+		`*`:        true,
+		`"b"`:      false,
+		`a`:        false,
+	}
+	for ifNoneMatch, matches := range expected {
+		if etagMatches(ifNoneMatch, `"a"`) != matches {
+			t.Errorf("etagMatches returned wrong result for %v: got %v want %v", ifNoneMatch, !matches, matches)
+		}
+	}
+}
+
+func TestWritePolicyDocumentCacheControl(t *testing.T) {
+	os.Setenv(config.PolicyCacheControlKey, "no-cache")
+	defer os.Unsetenv(config.PolicyCacheControlKey)
+
+	r, _ := http.NewRequest(http.MethodGet, "/v1/policies/hazardousgoods/locales/en-US", nil)
+	w := httptest.NewRecorder()
+	writePolicyDocument(w, r, "en-US", testPolicyVersion)
+
+	if w.Header().Get("Cache-Control") != "no-cache" || w.Header().Get("Vary") != policyVary {
+		t.Errorf("writePolicyDocument returned wrong caching headers: got %v", w.Header())
+	}
+}
+
+func TestWritePolicyDocumentEvaluation(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodPost, EvaluateV1Path, nil)
+	r.Header.Set("If-None-Match", "*")
+	w := httptest.NewRecorder()
+	writePolicyDocument(w, r, "en-US", testPolicyVersion)
+
+	if w.Code != http.StatusOK || w.Body.String() != "[]" {
+		t.Errorf("writePolicyDocument answered Not Modified to an evaluation: got %v", w.Code)
+	}
+
+	if w.Header().Get("ETag") != policyETag("en-US", testPolicyVersion, compression.EncodingIdentity) || len(w.Header().Get("Cache-Control")) != 0 {
+		t.Errorf("writePolicyDocument returned wrong validators for an evaluation: got %v", w.Header())
+	}
+}
@@ -0,0 +1,54 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/dukeluke16/sample-golang-webservice/compression"
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/policy"
+)
+
+// defaultCompressionEncodings in order of preference
+const defaultCompressionEncodings = "br,gzip"
+
+// policyVariants caches the compressed policy documents of each locale and version, replaced when the document changes
+var policyVariants = compression.NewCache()
+
+// compressionEncodings from COMPRESSION_ENCODINGS, skipping unsupported ones so none disables compression
+func compressionEncodings() []string {
+	var encodings []string
+	for _, encoding := range listOrDefault(config.CompressionEncodingsKey, defaultCompressionEncodings) {
+		if encoding == compression.EncodingBrotli || encoding == compression.EncodingGzip {
+			encodings = append(encodings, encoding)
+		}
+	}
+
+	return encodings
+}
+
+func compressionMinBytes() int {
+	return config.IntValue(config.CompressionMinBytesKey, 256)
+}
+
+// withCompression compresses text responses of at least COMPRESSION_MIN_BYTES with the negotiated encoding
+func withCompression(_ string, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return compression.Middleware(compressionEncodings(), compressionMinBytes(), handler)
+}
+
+// encodePolicyDocument with the negotiated encoding from the cache of compressed variants
+func encodePolicyDocument(w http.ResponseWriter, r *http.Request, locale string, version policy.Version) (string, []byte) {
+	document := version.Document
+	encodings := compressionEncodings()
+	if len(encodings) == 0 {
+		return compression.EncodingIdentity, document
+	}
+	compression.AddVary(w.Header())
+
+	encoding := compression.Negotiate(r.Header.Get("Accept-Encoding"), encodings)
+	if encoding == compression.EncodingIdentity || len(document) < compressionMinBytes() {
+		return compression.EncodingIdentity, document
+	}
+
+	w.Header().Set("Content-Encoding", encoding)
+	return encoding, policyVariants.Encoded(locale+"-"+version.Version, version.Digest, encoding, document)
+}
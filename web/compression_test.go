@@ -0,0 +1,103 @@
+package web
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/dukeluke16/sample-golang-webservice/compression"
+	"github.com/dukeluke16/sample-golang-webservice/config"
+)
+
+func serveCompressed(path string, acceptEncoding string) *httptest.ResponseRecorder {
+	r, _ := http.NewRequest(http.MethodGet, path, nil)
+	r.Header.Set("Accept-Encoding", acceptEncoding)
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, r)
+
+	return w
+}
+
+func TestCompressionPolicyDocument(t *testing.T) {
+	document, _ := policyStore.Document("en-US")
+
+	w := serveCompressed("/v1/policies/hazardousgoods/locales/en-US", "gzip;q=0.5, br")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != compression.EncodingBrotli {
+		t.Fatalf("handler returned wrong encoding: got %v %v", w.Code, w.Header().Get("Content-Encoding"))
+	}
+
+	if etag := w.Header().Get("ETag"); etag != currentPolicyETag("en-US", compression.EncodingBrotli) || !strings.HasSuffix(etag, `-br"`) {
+		t.Errorf("handler returned wrong ETag: got %v", etag)
+	}
+
+	if vary := w.Header()["Vary"]; strings.Join(vary, ", ") != policyVary+", Accept-Encoding" {
+		t.Errorf("handler returned wrong Vary: got %v", vary)
+	}
+
+	decoded, err := ioutil.ReadAll(brotli.NewReader(bytes.NewReader(w.Body.Bytes())))
+	if err != nil || !bytes.Equal(decoded, document) {
+		t.Errorf("handler returned wrong body: got %v %v", string(decoded), err)
+	}
+
+	// The cached variant is revalidated by its own ETag
+	r, _ := http.NewRequest(http.MethodGet, "/v1/policies/hazardousgoods/locales/en-US", nil)
+	r.Header.Set("Accept-Encoding", "br")
+	r.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	revalidated := httptest.NewRecorder()
+	NewRouter().ServeHTTP(revalidated, r)
+	if revalidated.Code != http.StatusNotModified {
+		t.Errorf("handler returned wrong status code: got %v want %v", revalidated.Code, http.StatusNotModified)
+	}
+}
+
+func TestCompressionIdentity(t *testing.T) {
+	document, _ := policyStore.Document("en-US")
+
+	w := serveCompressed("/v1/policies/hazardousgoods/locales/en-US", "identity")
+	recordExchange(http.MethodGet, PolicyLocalePath, w)
+	if len(w.Header().Get("Content-Encoding")) != 0 || !bytes.Equal(w.Body.Bytes(), document) {
+		t.Errorf("handler compressed the response: got %v", w.Header().Get("Content-Encoding"))
+	}
+
+	if w.Header().Get("ETag") != currentPolicyETag("en-US", compression.EncodingIdentity) {
+		t.Errorf("handler returned wrong ETag: got %v", w.Header().Get("ETag"))
+	}
+}
+
+func TestCompressionRouterResponses(t *testing.T) {
+	w := serveCompressed(OpenAPIPath, "gzip")
+	if w.Header().Get("Content-Encoding") != compression.EncodingGzip {
+		t.Errorf("handler returned wrong encoding: got %v want %v", w.Header().Get("Content-Encoding"), compression.EncodingGzip)
+	}
+
+	if w := serveCompressed(HealthLivePath, "gzip"); len(w.Header().Get("Content-Encoding")) != 0 {
+		t.Errorf("handler compressed a response below the minimum size: got %v", w.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestCompressionDisabled(t *testing.T) {
+	os.Setenv(config.CompressionEncodingsKey, "none")
+	defer os.Unsetenv(config.CompressionEncodingsKey)
+
+	for _, path := range []string{OpenAPIPath, "/v1/policies/hazardousgoods/locales/en-US"} {
+		w := serveCompressed(path, "br, gzip")
+		if len(w.Header().Get("Content-Encoding")) != 0 || strings.Contains(w.Header().Get("Vary"), "Accept-Encoding") {
+			t.Errorf("handler compressed %v: got %v", path, w.Header())
+		}
+	}
+}
+
+func TestCompressionEncodings(t *testing.T) {
+	os.Setenv(config.CompressionEncodingsKey, "gzip, deflate")
+	defer os.Unsetenv(config.CompressionEncodingsKey)
+
+	if encodings := compressionEncodings(); len(encodings) != 1 || encodings[0] != compression.EncodingGzip {
+		t.Errorf("compressionEncodings returned wrong encodings: got %v", encodings)
+	}
+}
@@ -0,0 +1,62 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/cors"
+	"github.com/dukeluke16/sample-golang-webservice/logger"
+	"github.com/dukeluke16/sample-golang-webservice/router"
+)
+
+// CORS defaults, covering the headers a browser sends to and reads from the evaluate routes
+const (
+	defaultCORSAllowedMethods = "GET,HEAD,POST"
+	defaultCORSAllowedHeaders = "Accept-Language,Content-Type,Authorization,X-Api-Key,X-Request-ID,Policy-Preview"
+	defaultCORSExposedHeaders = "Content-Language,Policy-Version,X-Request-ID,Retry-After,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset"
+)
+
+// corsPolicy for browser requests, disabled until Start configures allowed origins
+var corsPolicy = cors.New(cors.Options{})
+
+// configureCORS from the environment; CORS stays disabled without CORS_ALLOWED_ORIGINS
+func configureCORS() *cors.Policy {
+	origins := config.ListValue(config.CORSAllowedOriginsKey)
+	credentials := config.StringValue(config.CORSAllowCredentialsKey, "false") == "true"
+	for _, origin := range origins {
+		if origin == "*" && credentials {
+			logger.Warning("CORS credentials are not allowed for any origin", "origins", origins)
+			credentials = false
+		}
+	}
+
+	return cors.New(cors.Options{
+		AllowedOrigins:   origins,
+		AllowedMethods:   listOrDefault(config.CORSAllowedMethodsKey, defaultCORSAllowedMethods),
+		AllowedHeaders:   listOrDefault(config.CORSAllowedHeadersKey, defaultCORSAllowedHeaders),
+		ExposedHeaders:   listOrDefault(config.CORSExposedHeadersKey, defaultCORSExposedHeaders),
+		AllowCredentials: credentials,
+		MaxAge:           config.DurationValue(config.CORSMaxAgeKey, 10*time.Minute),
+	})
+}
+
+func listOrDefault(key string, fallback string) []string {
+	if values := config.ListValue(key); len(values) != 0 {
+		return values
+	}
+
+	return strings.Split(fallback, ",")
+}
+
+// withCORS answers preflight requests and adds CORS headers for allowed origins; unmatched routes are left untouched
+func withCORS(route string, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	if route == router.UnmatchedRoute {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		corsPolicy.Handler(handler)(w, r)
+	}
+}
@@ -0,0 +1,83 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/dukeluke16/sample-golang-webservice/config"
+)
+
+func setupCORS(origins string) (restore func()) {
+	os.Setenv(config.CORSAllowedOriginsKey, origins)
+	defer os.Unsetenv(config.CORSAllowedOriginsKey)
+
+	original := corsPolicy
+	corsPolicy = configureCORS()
+	return func() { corsPolicy = original }
+}
+
+func TestCORSPreflight(t *testing.T) {
+	defer setupCORS("https://*.example.com")()
+
+	r, _ := http.NewRequest(http.MethodOptions, EvaluateV1Path, nil)
+	r.Header.Set("Origin", "https://book.example.com")
+	r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	r.Header.Set("Access-Control-Request-Headers", "Content-Type, Accept-Language, X-Api-Key")
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, r)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusNoContent)
+	}
+
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":  "https://book.example.com",
+		"Access-Control-Allow-Methods": "GET, HEAD, POST",
+		"Access-Control-Max-Age":       "600",
+	}
+	for header, value := range expected {
+		if w.Header().Get(header) != value {
+			t.Errorf("handler returned wrong %v header: got %v want %v", header, w.Header().Get(header), value)
+		}
+	}
+}
+
+func TestCORSActualRequest(t *testing.T) {
+	defer setupCORS("https://book.example.com")()
+
+	r, _ := http.NewRequest(http.MethodGet, HealthPath, nil)
+	r.Header.Set("Origin", "https://book.example.com")
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, r)
+	recordExchange(http.MethodGet, HealthPath, w)
+
+	if origin := w.Header().Get("Access-Control-Allow-Origin"); origin != "https://book.example.com" {
+		t.Errorf("handler returned wrong origin: got %v", origin)
+	}
+
+	if exposed := w.Header().Get("Access-Control-Expose-Headers"); exposed != "Content-Language, Policy-Version, X-Request-ID, Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset" {
+		t.Errorf("handler returned wrong exposed headers: got %v", exposed)
+	}
+}
+
+func TestCORSUnmatchedRoute(t *testing.T) {
+	defer setupCORS("*")()
+
+	r, _ := http.NewRequest(http.MethodOptions, "/unknown", nil)
+	r.Header.Set("Origin", "https://book.example.com")
+	r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound || len(w.Header().Get("Access-Control-Allow-Origin")) != 0 {
+		t.Errorf("handler answered a preflight for an unmatched route: got %v", w.Code)
+	}
+}
+
+func TestConfigureCORSDisabledByDefault(t *testing.T) {
+	if configureCORS().Enabled() {
+		t.Errorf("configureCORS should be disabled without allowed origins!")
+	}
+}
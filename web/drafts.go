@@ -0,0 +1,142 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/dukeluke16/sample-golang-webservice/audit"
+	"github.com/dukeluke16/sample-golang-webservice/policy"
+)
+
+// AdminPolicyDraftPath for endpoint
+var AdminPolicyDraftPath = "/admin/v1/policies/{id}/locales/{locale}/draft"
+
+// AdminPolicyPublishPath for endpoint
+var AdminPolicyPublishPath = "/admin/v1/policies/{id}/locales/{locale}/publish"
+
+// AdminPolicyDraftGetHandler for handling routed requests
+func AdminPolicyDraftGetHandler(w http.ResponseWriter, r *http.Request) {
+	id, locale, r, ok := adminPolicyLocale(w, r)
+	if !ok {
+		return
+	}
+
+	draft, err := policyStore.Draft(locale)
+	if err == policy.ErrNotFound {
+		statusResponseError(w, r, http.StatusNotFound, "no draft of the "+locale+" locale of policy "+id)
+		return
+	}
+	if err != nil {
+		genericStatusResponseError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("ETag", `"`+draft.Digest+`"`)
+	w.Write(draft.Document)
+}
+
+// AdminPolicyDraftPutHandler for handling routed requests, creating or replacing the draft once it is validated and audited
+func AdminPolicyDraftPutHandler(w http.ResponseWriter, r *http.Request) {
+	id, locale, r, ok := adminPolicyLocale(w, r)
+	if !ok {
+		return
+	}
+
+	var document json.RawMessage
+	if err := decodeJSONBody(w, r, &document, false); err != nil {
+		if err == errEmptyBody {
+			statusResponseError(w, r, http.StatusBadRequest, "no policy document")
+		}
+		return
+	}
+
+	if err := policy.Validate(document); err != nil {
+		statusResponseError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	change := adminChange{r: r, id: id, locale: locale}
+	created := false
+	err := policyStore.PutDraft(locale, document, func(previous []byte) error {
+		if !adminPreconditions(r, previous) {
+			return errPreconditionFailed
+		}
+
+		created = previous == nil
+		return change.record(audit.ActionDraft, previous, document)
+	})
+	if !change.committed(w, err) {
+		return
+	}
+
+	w.Header().Set("ETag", `"`+policy.Digest(document)+`"`)
+	if created {
+		w.Header().Set("Location", r.URL.Path)
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminPolicyDraftDeleteHandler for handling routed requests, discarding the draft once it is audited
+func AdminPolicyDraftDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	id, locale, r, ok := adminPolicyLocale(w, r)
+	if !ok {
+		return
+	}
+
+	change := adminChange{r: r, id: id, locale: locale}
+	err := policyStore.DeleteDraft(locale, func(previous []byte) error {
+		if !adminPreconditions(r, previous) {
+			return errPreconditionFailed
+		}
+		return change.record(audit.ActionDiscard, previous, nil)
+	})
+	if err == policy.ErrNotFound {
+		statusResponseError(w, r, http.StatusNotFound, "no draft of the "+locale+" locale of policy "+id)
+		return
+	}
+	if !change.committed(w, err) {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminPolicyPublishPostHandler for handling routed requests, replacing the document with its draft in one step once it is audited
+func AdminPolicyPublishPostHandler(w http.ResponseWriter, r *http.Request) {
+	id, locale, r, ok := adminPolicyLocale(w, r)
+	if !ok {
+		return
+	}
+
+	change := adminChange{r: r, id: id, locale: locale}
+	created := false
+	err := policyStore.Publish(locale, func(previous []byte, draft []byte) error {
+		// If-Match names the draft that was previewed, so a later edit is not published unseen
+		if !adminPreconditions(r, draft) {
+			return errPreconditionFailed
+		}
+
+		created = previous == nil
+		return change.record(audit.ActionPublish, previous, draft)
+	})
+	if err == policy.ErrNotFound {
+		statusResponseError(w, r, http.StatusNotFound, "no draft of the "+locale+" locale of policy "+id)
+		return
+	}
+	if !change.committed(w, err) {
+		return
+	}
+
+	w.Header().Set("ETag", adminETag(locale))
+	if created {
+		w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/publish"))
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
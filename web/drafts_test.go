@@ -0,0 +1,166 @@
+package web
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dukeluke16/sample-golang-webservice/audit"
+	"github.com/dukeluke16/sample-golang-webservice/policy"
+)
+
+func TestAdminPolicyDraftPutHandler(t *testing.T) {
+	folder, restore := setupAdmin(t)
+	defer restore()
+
+	published, _ := policyStore.Document("fr")
+	path := "/admin/v1/policies/hazardousgoods/locales/fr/draft"
+	w := serveAdmin(http.MethodPut, path, AdminPolicyDraftPath, "editor-secret", adminDocument, nil)
+	if w.Code != http.StatusCreated || w.Header().Get("Location") != path {
+		t.Fatalf("handler did not create the draft: got %v %v", w.Code, w.Body.String())
+	}
+
+	if data, _ := ioutil.ReadFile(filepath.Join(folder, "fr", policy.DraftName)); string(data) != adminDocument {
+		t.Errorf("handler did not write the draft: got %v", string(data))
+	}
+
+	// The document in force is untouched until the draft is published
+	if document, _ := policyStore.Document("fr"); string(document) != string(published) {
+		t.Errorf("handler replaced the document with the draft: got %v", string(document))
+	}
+
+	etag := w.Header().Get("ETag")
+	w = serveAdmin(http.MethodGet, path, AdminPolicyDraftPath, "editor-secret", "", nil)
+	if w.Code != http.StatusOK || w.Body.String() != adminDocument || w.Header().Get("ETag") != etag || w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("handler returned wrong draft: got %v %v %v", w.Code, w.Header(), w.Body.String())
+	}
+
+	updated := strings.Replace(adminDocument, "Paragraphe", "Nouveau paragraphe", 1)
+	if w := serveAdmin(http.MethodPut, path, AdminPolicyDraftPath, "editor-secret", updated, map[string]string{"If-Match": `"0000000000000000"`}); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusPreconditionFailed)
+	}
+
+	w = serveAdmin(http.MethodPut, path, AdminPolicyDraftPath, "editor-secret", updated, map[string]string{"If-Match": etag})
+	if w.Code != http.StatusNoContent || w.Header().Get("ETag") != `"`+policy.Digest([]byte(updated))+`"` {
+		t.Errorf("handler did not update the draft: got %v %v", w.Code, w.Header().Get("ETag"))
+	}
+
+	if w := serveAdmin(http.MethodPut, path, AdminPolicyDraftPath, "editor-secret", `[]`, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusBadRequest)
+	}
+
+	entries := readAuditLog(t)
+	if len(entries) != 2 || entries[0].Action != audit.ActionDraft || entries[1].Action != audit.ActionDraft || entries[1].Diff != "@@ -1 +1 @@\n-"+adminDocument+"\n+"+updated+"\n" {
+		t.Errorf("handler audited wrong changes: got %+v", entries)
+	}
+
+	w = serveAdmin(http.MethodGet, "/admin/v1/policies/hazardousgoods/locales", AdminPolicyLocalesPath, "editor-secret", "", nil)
+	var response AdminLocalesResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.Drafts) != 1 || response.Drafts[0].Locale != "fr" || response.Drafts[0].Digest != policy.Digest([]byte(updated)) {
+		t.Errorf("handler returned wrong drafts: got %+v", response.Drafts)
+	}
+}
+
+func TestAdminPolicyDraftPutHandlerCommitFailure(t *testing.T) {
+	folder, restore := setupAdmin(t)
+	defer restore()
+
+	// A directory in place of the draft fails the rename once the change is audited
+	policyStore.Document("fr")
+	if err := os.MkdirAll(filepath.Join(folder, "de", policy.DraftName, "blocked"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serveAdmin(http.MethodPut, "/admin/v1/policies/hazardousgoods/locales/de/draft", AdminPolicyDraftPath, "editor-secret", adminDocument, nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusInternalServerError)
+	}
+
+	entries := readAuditLog(t)
+	if len(entries) != 2 || entries[1].Action != audit.ActionFailed || entries[1].Failed != audit.ActionDraft || entries[1].RequestID != entries[0].RequestID || len(entries[1].Error) == 0 {
+		t.Errorf("handler did not void the audited change: got %+v", entries)
+	}
+}
+
+func TestAdminPolicyDraftDeleteHandler(t *testing.T) {
+	folder, restore := setupAdmin(t)
+	defer restore()
+
+	path := "/admin/v1/policies/hazardousgoods/locales/fr/draft"
+	if w := serveAdmin(http.MethodDelete, path, AdminPolicyDraftPath, "editor-secret", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusNotFound)
+	}
+
+	serveAdmin(http.MethodPut, path, AdminPolicyDraftPath, "editor-secret", adminDocument, nil)
+	if w := serveAdmin(http.MethodDelete, path, AdminPolicyDraftPath, "editor-secret", "", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("handler returned wrong status code: got %v want %v", w.Code, http.StatusNoContent)
+	}
+
+	if _, err := os.Stat(filepath.Join(folder, "fr", policy.DraftName)); !os.IsNotExist(err) {
+		t.Errorf("handler did not discard the draft: got %v", err)
+	}
+	if w := serveAdmin(http.MethodGet, path, AdminPolicyDraftPath, "editor-secret", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusNotFound)
+	}
+
+	if entries := readAuditLog(t); len(entries) != 2 || entries[1].Action != audit.ActionDiscard {
+		t.Errorf("handler audited wrong changes: got %+v", entries)
+	}
+}
+
+func TestAdminPolicyPublishPostHandler(t *testing.T) {
+	folder, restore := setupAdmin(t)
+	defer restore()
+
+	path := "/admin/v1/policies/hazardousgoods/locales/fr/publish"
+	if w := serveAdmin(http.MethodPost, path, AdminPolicyPublishPath, "editor-secret", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusNotFound)
+	}
+
+	previous, _ := policyStore.Document("fr")
+	w := serveAdmin(http.MethodPut, "/admin/v1/policies/hazardousgoods/locales/fr/draft", AdminPolicyDraftPath, "editor-secret", adminDocument, nil)
+	etag := w.Header().Get("ETag")
+
+	// Only the draft that was previewed is published
+	if w := serveAdmin(http.MethodPost, path, AdminPolicyPublishPath, "editor-secret", "", map[string]string{"If-Match": `"0000000000000000"`}); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusPreconditionFailed)
+	}
+
+	w = serveAdmin(http.MethodPost, path, AdminPolicyPublishPath, "editor-secret", "", map[string]string{"If-Match": etag})
+	if w.Code != http.StatusNoContent || w.Header().Get("ETag") != etag {
+		t.Fatalf("handler did not publish the draft: got %v %v", w.Code, w.Body.String())
+	}
+
+	// The public API serves the published draft right away
+	if w := servePolicyLocale("/v1/policies/hazardousgoods/locales/fr", ""); w.Code != http.StatusOK || w.Body.String() != adminDocument {
+		t.Errorf("handler did not serve the published draft: got %v %v", w.Code, w.Body.String())
+	}
+
+	if data, _ := ioutil.ReadFile(filepath.Join(folder, "fr", policy.DocumentName)); string(data) != adminDocument {
+		t.Errorf("handler did not write the document: got %v", string(data))
+	}
+	if _, err := os.Stat(filepath.Join(folder, "fr", policy.DraftName)); !os.IsNotExist(err) {
+		t.Errorf("handler kept the published draft: got %v", err)
+	}
+
+	entries := readAuditLog(t)
+	if len(entries) != 2 || entries[1].Action != audit.ActionPublish || !strings.Contains(entries[1].Diff, "-"+strings.Split(string(previous), "\n")[0]) {
+		t.Errorf("handler audited wrong changes: got %+v", entries)
+	}
+
+	// Publishing the draft of a new locale creates it
+	serveAdmin(http.MethodPut, "/admin/v1/policies/hazardousgoods/locales/de/draft", AdminPolicyDraftPath, "editor-secret", adminDocument, nil)
+	w = serveAdmin(http.MethodPost, "/admin/v1/policies/hazardousgoods/locales/de/publish", AdminPolicyPublishPath, "editor-secret", "", nil)
+	if w.Code != http.StatusCreated || w.Header().Get("Location") != "/admin/v1/policies/hazardousgoods/locales/de" {
+		t.Errorf("handler did not publish the new locale: got %v %v", w.Code, w.Header())
+	}
+
+	if w := serveAdmin(http.MethodPost, path, AdminPolicyPublishPath, "booking-secret", "", nil); w.Code != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusForbidden)
+	}
+}
@@ -1,25 +1,33 @@
 package web
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
 	"golang.org/x/text/language"
 
-	"github.com/newrelic/go-agent"
+	"github.com/dukeluke16/sample-golang-webservice/accesslog"
+	"github.com/dukeluke16/sample-golang-webservice/apm"
+	"github.com/dukeluke16/sample-golang-webservice/circuit"
 	"github.com/dukeluke16/sample-golang-webservice/config"
 	"github.com/dukeluke16/sample-golang-webservice/logger"
+	"github.com/dukeluke16/sample-golang-webservice/metrics"
+	"github.com/dukeluke16/sample-golang-webservice/policy"
 )
 
-var defaultDataFolder = "../data/"
+// policyStore of localized policy documents
+var policyStore = policy.NewStore("../data/")
 
-const hazardousGoodsPolicyPath = "/hazardousGoodsPolicy.json"
+// locationServicesBreaker guards calls to the Location Services
+var locationServicesBreaker = circuit.NewBreaker(
+	config.IntValue(config.CircuitBreakerThresholdKey, 5),
+	config.DurationValue(config.CircuitBreakerCooldownKey, 30*time.Second))
 
 const contentType = "application/json; charset=UTF-8"
 
@@ -61,95 +69,183 @@ var serverLanguageMatcher = language.NewMatcher([]language.Tag{
 	language.SimplifiedChinese,    // zh-Hans, zh-TW
 })
 
-// EvaluatePath for endpoint
+// EvaluatePath for the legacy endpoint, an alias of EvaluateV1Path
 var EvaluatePath = "/policy/hazardousgoods/evaluate"
 
+// EvaluateV1Path for endpoint
+var EvaluateV1Path = "/v1/policy/hazardousgoods/evaluate"
+
 // EvaluatePostHandler for handling routed requests
 func EvaluatePostHandler(w http.ResponseWriter, r *http.Request) {
-	// Enforce POST Only
-	if r.Method != http.MethodPost {
-		genericStatusResponseError(w, r, http.StatusMethodNotAllowed)
+	tag, r, ok := negotiateLocale(w, r)
+	if !ok {
 		return
 	}
 
+	evaluateLogicHandler(w, r, tag)
+}
+
+// negotiateLocale from Accept-Language, responding Not Acceptable when no supported locale matches
+func negotiateLocale(w http.ResponseWriter, r *http.Request) (language.Tag, *http.Request, bool) {
 	// Parse Accept-Language
 	tag := parseAcceptLanguageHeader(r)
 	if tag == language.Und {
 		if r.Header.Get("Accept-Language") != "" {
 			genericStatusResponseError(w, r, http.StatusNotAcceptable)
-			return
+			return tag, r, false
 		}
 		tag = language.AmericanEnglish
 	}
+	metrics.NegotiatedLocales.WithLabelValues(tag.String()).Inc()
+	r = withLogFields(r, "locale", tag.String())
+	accesslog.FromContext(r.Context()).Locale = tag.String()
 
-	evaluateLogicHandler(w, r, tag)
+	return tag, r, true
 }
 
 // evaluateLogicHandler for handling routed requests
 func evaluateLogicHandler(w http.ResponseWriter, r *http.Request, tag language.Tag) {
-	currentTransaction, _ := w.(newrelic.Transaction)
+	// Deserialize Array of AirportCodes
+	// Parsing Error receives BadRequest Response
+	var airportCodes []string
+	err := decodeJSONBody(w, r, &airportCodes, false)
+	if err != nil && err != errEmptyBody {
+		return
+	}
 
 	// Empty Body receives Empty Response
-	if r.Body == nil {
+	if len(airportCodes) == 0 {
 		emptyResponse(w, tag)
 		return
 	}
 
-	bytes, _ := ioutil.ReadAll(r.Body)
-
-	// Empty Body receives Empty Response
-	body := string(bytes)
-	if body == "" || body == "[]" {
-		emptyResponse(w, tag)
+	airportCodes, ok := validateAirportCodes(w, r, airportCodes)
+	if !ok {
 		return
 	}
 
-	// Deserialize Array of AirportCodes
-	// Parsing Error receives BadRequest Response
-	var airportCodes []string
-	err := json.Unmarshal(bytes, &airportCodes)
+	airportInsideUSA, err := evaluateAirportCodes(r.Context(), airportCodes)
+	// An airport code Location Services do not know is a mistake of the client, not an outage
+	if code, ok := unknownAirport(err); ok {
+		statusResponseError(w, r, http.StatusBadRequest, "unknown airport code "+code)
+		return
+	}
 	if err != nil {
-		genericStatusResponseError(w, r, http.StatusBadRequest)
+		genericStatusResponseError(w, r, http.StatusServiceUnavailable)
 		return
 	}
 
-	// Check if any AirportCode is inside USA
-	airportInsideUSA := false
+	if airportInsideUSA {
+		hazardousGoodsPolicyResponse(w, r, tag, policyClock())
+		return
+	}
+
+	emptyResponse(w, tag)
+	return
+}
+
+// evaluateAirportCodes is the evaluation core shared by every API version: the policy applies when any airport is inside the USA
+func evaluateAirportCodes(ctx context.Context, airportCodes []string) (bool, error) {
+	airportInsideUSA, err := policyApplies(airportCodes, func(airportCode string) (string, error) {
+		return checkAirportLocationCode(ctx, airportCode)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// Decide if policy is applicable
+	metrics.ObservePolicyDecision(airportInsideUSA)
+	return airportInsideUSA, nil
+}
+
+// policyApplies once an airport inside the USA is found, checking the airport codes in order; a lookup failing before then fails the evaluation
+func policyApplies(airportCodes []string, country func(airportCode string) (string, error)) (bool, error) {
 	for _, airportCode := range airportCodes {
-		result, serviceError := checkAirportLocationCode(airportCode, currentTransaction)
-		if serviceError != nil {
-			genericStatusResponseError(w, r, http.StatusServiceUnavailable)
-			return
+		result, err := country(airportCode)
+		if err != nil {
+			return false, err
 		}
 
-		airportInsideUSA = result == "US"
-		if airportInsideUSA {
-			break
+		if result == "US" {
+			return true, nil
 		}
 	}
 
-	// Decide if policy is applicable
-	if airportInsideUSA {
-		hazardousGoodsPolicyResponse(w, r, tag)
-		return
+	return false, nil
+}
+
+func checkAirportLocationCode(ctx context.Context, inputCode string) (string, error) {
+	ctx, span := apm.StartSpan(ctx, "checkAirportLocationCode")
+	span.SetAttribute("airport.code", strings.ToUpper(inputCode))
+	defer span.End()
+
+	if !locationServicesBreaker.Allow() {
+		span.RecordError(circuit.ErrOpen)
+		return "", circuit.ErrOpen
 	}
 
-	emptyResponse(w, tag)
-	return
+	accesslog.FromContext(ctx).AddUpstreamLookup()
+	started := time.Now()
+	countryCode, err := lookupAirportLocationCode(ctx, inputCode)
+	metrics.ObserveUpstream("locationServices", started, err)
+	if err != nil {
+		span.RecordError(err)
+		// Only a Location Services outage trips the breaker; an unknown airport code was still answered
+		var outage *locationServicesError
+		if errors.As(err, &outage) {
+			locationServicesBreaker.Failure()
+		} else {
+			locationServicesBreaker.Success()
+		}
+		return "", err
+	}
+
+	span.SetAttribute("airport.country", countryCode)
+	locationServicesBreaker.Success()
+	return countryCode, nil
 }
 
-func buildErrorMessage(message string, r *http.Request) string {
-	var buffer bytes.Buffer
-	buffer.WriteString(strings.Replace(message, "\n", " |", -1))
+// unknownAirportError when Location Services know no airport for the code, a mistake of the client rather than an outage
+type unknownAirportError struct {
+	code string
+}
+
+func (e *unknownAirportError) Error() string {
+	return "unknown airport code " + e.code
+}
 
-	for k, v := range r.Header {
-		buffer.WriteString(fmt.Sprint(" Request Header - key: ", k, " value: ", v, " |"))
+// unknownAirport code named by err, when it is an unknownAirportError
+func unknownAirport(err error) (string, bool) {
+	var unknown *unknownAirportError
+	if errors.As(err, &unknown) {
+		return unknown.code, true
 	}
 
-	return buffer.String()
+	return "", false
+}
+
+// locationServicesError when Location Services are unreachable, fail, or answer something other than airports
+type locationServicesError struct {
+	err error
+}
+
+func (e *locationServicesError) Error() string {
+	return e.err.Error()
+}
+
+// locationServicesResponse of the fields read from a Location Services search
+type locationServicesResponse struct {
+	Airports []struct {
+		AlternateIDs []struct {
+			Code string `json:"code"`
+		} `json:"alternateIds"`
+		Country struct {
+			Code string `json:"code"`
+		} `json:"country"`
+	} `json:"airports"`
 }
 
-func checkAirportLocationCode(inputCode string, txn newrelic.Transaction) (string, error) {
+func lookupAirportLocationCode(ctx context.Context, inputCode string) (string, error) {
 	airportCode := strings.ToUpper(inputCode)
 
 	requestTemplate := `{
@@ -167,64 +263,100 @@ func checkAirportLocationCode(inputCode string, txn newrelic.Transaction) (strin
 	apiRequestBody := fmt.Sprintf(requestTemplate, airportCode)
 
 	client := &http.Client{}
-	client.Transport = newrelic.NewRoundTripper(txn, nil)
+	client.Transport = tracer.Transport(nil)
 	req, _ := http.NewRequest(http.MethodPost, config.LocationServicesURI(), strings.NewReader(apiRequestBody))
+	req = req.WithContext(ctx)
+	if requestID := RequestIDFromContext(ctx); len(requestID) != 0 {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return "", &locationServicesError{err}
 	}
 	defer resp.Body.Close()
 
-	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return "", &locationServicesError{errors.New("location services responded " + resp.Status)}
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", &locationServicesError{err}
+	}
 
 	// decode the json
-	var j map[string]interface{}
+	var j locationServicesResponse
 	err = json.Unmarshal(body, &j)
 	if err != nil {
-		return "", err
+		return "", &locationServicesError{err}
 	}
 
-	airport := j["airports"].([]interface{})[0]
-	returnAirportCode := airport.(map[string]interface{})["alternateIds"].([]interface{})[0].(map[string]interface{})["code"].(string)
-	returnCountryCode := airport.(map[string]interface{})["country"].(map[string]interface{})["code"].(string)
+	// Unknown codes are answered with no airports rather than an error
+	if len(j.Airports) == 0 || len(j.Airports[0].AlternateIDs) == 0 {
+		return "", &unknownAirportError{airportCode}
+	}
 
-	if strings.ToUpper(returnAirportCode) == airportCode {
-		return returnCountryCode, nil
+	airport := j.Airports[0]
+	if strings.ToUpper(airport.AlternateIDs[0].Code) == airportCode {
+		return airport.Country.Code, nil
 	}
 	return "", errors.New("mismatched IATA code returned")
 }
 
-func hazardousGoodsPolicyResponse(w http.ResponseWriter, r *http.Request, tag language.Tag) {
-	defaultResponse, err := getHazardousGoodsPolicy(tag)
+// hazardousGoodsPolicyResponse with the version of the policy in force at t
+func hazardousGoodsPolicyResponse(w http.ResponseWriter, r *http.Request, tag language.Tag, t time.Time) {
+	defaultResponse, err := getHazardousGoodsPolicy(r.Context(), tag, t)
 	if err != nil {
 		genericStatusResponseError(w, r, http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", contentType)
-	w.Header().Set("Content-Language", tag.String())
-	io.WriteString(w, string(defaultResponse))
+	writePolicyDocument(w, r, tag.String(), defaultResponse)
 }
 
 func emptyResponse(w http.ResponseWriter, tag language.Tag) {
 	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("Content-Language", tag.String())
+	w.Header().Add("Vary", policyVary)
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func getHazardousGoodsPolicy(tag language.Tag) (b []byte, err error) {
-	policyFile := defaultDataFolder + tag.String() + hazardousGoodsPolicyPath
-	return ioutil.ReadFile(policyFile)
+func getHazardousGoodsPolicy(ctx context.Context, tag language.Tag, t time.Time) (version policy.Version, err error) {
+	_, span := apm.StartSpan(ctx, "getHazardousGoodsPolicy")
+	span.SetAttribute("policy.locale", tag.String())
+	defer span.End()
+
+	version, err = policyVersionAt(ctx, tag.String(), t)
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.SetAttribute("policy.version", version.Version)
+
+	return version, err
 }
 
 func genericStatusResponseError(w http.ResponseWriter, r *http.Request, statusCode int) {
-	http.Error(w, http.StatusText(statusCode), statusCode)
-	message := fmt.Sprintln(statusCode, ":", http.StatusText(statusCode))
-	errorMessage := buildErrorMessage(message, r)
-	if logger.Initialized {
-		logger.Error.Println(errorMessage)
+	statusResponseError(w, r, statusCode, "")
+}
+
+// statusResponseError with a detail for the client appended to the status text
+func statusResponseError(w http.ResponseWriter, r *http.Request, statusCode int, detail string) {
+	body := http.StatusText(statusCode)
+	if len(detail) != 0 {
+		body = body + ": " + detail
 	}
+	if requestID := RequestIDFromContext(r.Context()); len(requestID) != 0 {
+		body = fmt.Sprintf("%s (Request ID: %s)", body, requestID)
+	}
+
+	http.Error(w, body, statusCode)
+	logger.FromContext(r.Context()).Error(http.StatusText(statusCode),
+		"status", statusCode,
+		"method", r.Method,
+		"path", r.URL.Path,
+		"detail", detail,
+		"headers", redactionPolicy.Headers(r.Header))
 }
 
 func parseAcceptLanguageHeader(r *http.Request) (tag language.Tag) {
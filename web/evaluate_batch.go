@@ -0,0 +1,252 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/metrics"
+	"github.com/dukeluke16/sample-golang-webservice/policy"
+)
+
+// EvaluateBatchPath for endpoint
+var EvaluateBatchPath = "/v2/policy/hazardousgoods/evaluate/batch"
+
+// batchLookupConcurrency bounds the Location Services calls in flight for one batch
+const batchLookupConcurrency = 8
+
+// EvaluateBatchRequest body
+type EvaluateBatchRequest struct {
+	Itineraries []BatchItinerary `json:"itineraries"`
+}
+
+// BatchItinerary to evaluate, identified by the client
+type BatchItinerary struct {
+	ID           string   `json:"id,omitempty"`
+	AirportCodes []string `json:"airportCodes"`
+	TravelDate   string   `json:"travelDate,omitempty"`
+}
+
+// EvaluateBatchResponse body with one result per itinerary, in request order
+type EvaluateBatchResponse struct {
+	Locale  string        `json:"locale"`
+	Results []BatchResult `json:"results"`
+	// Policy in force at the time of the request, included once when it applies to any itinerary
+	Policy json.RawMessage `json:"policy,omitempty"`
+	// Policies of the other versions the travel dates of the itineraries select, by version
+	Policies map[string]json.RawMessage `json:"policies,omitempty"`
+}
+
+// BatchResult of one itinerary; a failed itinerary carries its own status and error without failing the batch
+type BatchResult struct {
+	ID            string `json:"id,omitempty"`
+	Status        int    `json:"status"`
+	PolicyApplied bool   `json:"policyApplied"`
+	PolicyVersion string `json:"policyVersion,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// airportLookup result of the Location Services for one airport code
+type airportLookup struct {
+	country string
+	err     error
+}
+
+// EvaluateBatchPostHandler for handling routed requests
+func EvaluateBatchPostHandler(w http.ResponseWriter, r *http.Request) {
+	tag, r, ok := negotiateLocale(w, r)
+	if !ok {
+		return
+	}
+
+	var request EvaluateBatchRequest
+	if err := decodeJSONBody(w, r, &request, true); err != nil {
+		if err == errEmptyBody {
+			genericStatusResponseError(w, r, http.StatusBadRequest)
+		}
+		return
+	}
+
+	maxItineraries := config.IntValue(config.BatchMaxItinerariesKey, 100)
+	switch {
+	case len(request.Itineraries) == 0:
+		statusResponseError(w, r, http.StatusBadRequest, "no itineraries")
+		return
+	case len(request.Itineraries) > maxItineraries:
+		statusResponseError(w, r, http.StatusBadRequest,
+			fmt.Sprintf("%d itineraries exceed the maximum of %d", len(request.Itineraries), maxItineraries))
+		return
+	}
+
+	// Validate every itinerary first so each airport is looked up once across the batch
+	results := make([]BatchResult, len(request.Itineraries))
+	itineraryCodes := make([][]string, len(request.Itineraries))
+	itineraryTimes := make([]time.Time, len(request.Itineraries))
+	var uniqueCodes []string
+	seen := map[string]bool{}
+	for i, itinerary := range request.Itineraries {
+		results[i] = BatchResult{ID: itinerary.ID, Status: http.StatusOK}
+
+		var detail string
+		if itineraryTimes[i], detail = checkTravelDate(itinerary.TravelDate); len(detail) != 0 {
+			results[i].Status, results[i].Error = http.StatusBadRequest, detail
+			continue
+		}
+
+		codes, detail := checkAirportCodes(r.Context(), itinerary.AirportCodes)
+		if len(detail) != 0 {
+			results[i].Status, results[i].Error = http.StatusBadRequest, detail
+			continue
+		}
+
+		itineraryCodes[i] = codes
+		for _, code := range codes {
+			if !seen[code] {
+				seen[code] = true
+				uniqueCodes = append(uniqueCodes, code)
+			}
+		}
+	}
+
+	// Every policy version is read before any lookup, so a batch whose response could grow too large is rejected without spending Location Services calls
+	current, currentErr := getHazardousGoodsPolicy(r.Context(), tag, policyClock())
+	versions := make([]policy.Version, len(request.Itineraries))
+	versionErrs := make([]error, len(request.Itineraries))
+	for i, itinerary := range request.Itineraries {
+		if results[i].Status != http.StatusOK {
+			continue
+		}
+
+		versions[i], versionErrs[i] = current, currentErr
+		if len(itinerary.TravelDate) != 0 {
+			versions[i], versionErrs[i] = getHazardousGoodsPolicy(r.Context(), tag, itineraryTimes[i])
+		}
+	}
+
+	maxBytes := config.IntValue(config.BatchMaxResponseBytesKey, 1024*1024)
+	if bound := batchResponseBound(tag.String(), results, versions, versionErrs, current); bound > maxBytes {
+		statusResponseError(w, r, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("response of up to %d bytes exceeds the maximum of %d, send fewer itineraries", bound, maxBytes))
+		return
+	}
+
+	lookups := lookupAirportCodes(r.Context(), uniqueCodes)
+
+	response := EvaluateBatchResponse{Locale: tag.String(), Results: results}
+	for i, codes := range itineraryCodes {
+		if results[i].Status != http.StatusOK {
+			continue
+		}
+
+		// Decided as for a single evaluation, from the lookups made for the whole batch
+		applied, err := policyApplies(codes, func(code string) (string, error) {
+			return lookups[code].country, lookups[code].err
+		})
+		if code, ok := unknownAirport(err); ok {
+			results[i].Status, results[i].Error = http.StatusBadRequest, "unknown airport code "+code
+			continue
+		}
+		if err != nil {
+			results[i].Status, results[i].Error = http.StatusServiceUnavailable, batchLookupFailed
+			continue
+		}
+
+		results[i].PolicyApplied = applied
+		metrics.ObservePolicyDecision(results[i].PolicyApplied)
+		if !results[i].PolicyApplied {
+			continue
+		}
+
+		if versionErrs[i] != nil {
+			results[i].Status, results[i].Error = http.StatusInternalServerError, "policy document unavailable"
+			continue
+		}
+
+		results[i].PolicyVersion = versions[i].Version
+		includePolicy(&response, versions[i], current)
+	}
+
+	body, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Language", tag.String())
+	w.Header().Add("Vary", policyVary)
+	w.Write(body)
+}
+
+// batchLookupFailed error of an itinerary whose lookup failed, the longest error an itinerary may fail with after its lookups
+const batchLookupFailed = "Location Services unavailable"
+
+// includePolicy of the version in the response once: the current version as the policy, any other among the policies
+func includePolicy(response *EvaluateBatchResponse, version policy.Version, current policy.Version) {
+	if version.Version == current.Version && version.Digest == current.Digest {
+		response.Policy = current.Document
+		return
+	}
+
+	if response.Policies == nil {
+		response.Policies = map[string]json.RawMessage{}
+	}
+	response.Policies[version.Version] = version.Document
+}
+
+// batchResponseBound on the size of the response, as if every itinerary still to be looked up failed with the longest error and applied its policy
+func batchResponseBound(locale string, results []BatchResult, versions []policy.Version, versionErrs []error, current policy.Version) int {
+	bound := EvaluateBatchResponse{Locale: locale, Results: make([]BatchResult, len(results))}
+	for i, result := range results {
+		bound.Results[i] = result
+		if result.Status != http.StatusOK {
+			continue
+		}
+
+		bound.Results[i].PolicyApplied, bound.Results[i].Error = true, batchLookupFailed
+		if versionErrs[i] == nil {
+			bound.Results[i].PolicyVersion = versions[i].Version
+			includePolicy(&bound, versions[i], current)
+		}
+	}
+
+	body, _ := json.Marshal(bound)
+	return len(body)
+}
+
+// lookupAirportCodes concurrently, at most batchLookupConcurrency at a time
+func lookupAirportCodes(ctx context.Context, airportCodes []string) map[string]airportLookup {
+	lookups := make(map[string]airportLookup, len(airportCodes))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, batchLookupConcurrency)
+
+	// The URI is loaded lazily into a global, so load it before the lookups read it concurrently
+	if len(airportCodes) != 0 {
+		config.LocationServicesURI()
+	}
+
+	for _, code := range airportCodes {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(code string) {
+			defer func() { <-slots; wg.Done() }()
+
+			// net/http only recovers panics of the handler goroutine, so a lookup must not take the process down
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					mu.Lock()
+					lookups[code] = airportLookup{err: fmt.Errorf("lookup of %v panicked: %v", code, recovered)}
+					mu.Unlock()
+				}
+			}()
+
+			country, err := checkAirportLocationCode(ctx, code)
+			mu.Lock()
+			lookups[code] = airportLookup{country: country, err: err}
+			mu.Unlock()
+		}(code)
+	}
+	wg.Wait()
+
+	return lookups
+}
@@ -0,0 +1,309 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dukeluke16/sample-golang-webservice/circuit"
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/policy"
+)
+
+// setupCountryServer answers each airport code with its country, failing for codes without one, and counts lookups per code
+func setupCountryServer(countries map[string]string, lookups map[string]int) *httptest.Server {
+	var mu sync.Mutex
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Request struct {
+				SearchText string `json:"searchText"`
+			} `json:"request"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		code := body.Request.SearchText
+
+		mu.Lock()
+		lookups[code]++
+		mu.Unlock()
+
+		country, ok := countries[code]
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		// Codes mapped to no country are unknown, answered with no airports
+		if len(country) == 0 {
+			fmt.Fprint(w, `{"airports": []}`)
+			return
+		}
+		fmt.Fprintf(w, `{"airports": [{"country": {"code": %q}, "alternateIds": [{"source": "IATA", "code": %q}]}]}`, country, code)
+	}))
+	setServiceEndpoint(ts.URL)
+	return ts
+}
+
+func serveBatch(body string, acceptLanguage string) *httptest.ResponseRecorder {
+	r, _ := http.NewRequest(http.MethodPost, EvaluateBatchPath, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if len(acceptLanguage) != 0 {
+		r.Header.Set("Accept-Language", acceptLanguage)
+	}
+
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, r)
+	recordExchange(http.MethodPost, EvaluateBatchPath, w)
+
+	return w
+}
+
+func TestEvaluateBatch(t *testing.T) {
+	defer func(original *circuit.Breaker) { locationServicesBreaker = original }(locationServicesBreaker)
+	locationServicesBreaker = circuit.NewBreaker(100, time.Minute)
+
+	lookups := map[string]int{}
+	ts := setupCountryServer(map[string]string{"SEA": "US", "LAX": "US", "LHR": "GB", "CDG": "FR"}, lookups)
+	defer ts.Close()
+	defer resetServiceEndpoint()
+
+	w := serveBatch(`{"itineraries": [
+		{"id": "a", "airportCodes": ["lhr", "sea"]},
+		{"id": "b", "airportCodes": ["LHR", "CDG"]},
+		{"id": "c", "airportCodes": ["LHR", "toolong"]},
+		{"id": "d", "airportCodes": ["CDG", "ERR"]},
+		{"id": "e", "airportCodes": ["ERR", "LAX"]},
+		{"id": "f", "airportCodes": []}
+	]}`, "fr")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %v", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var response EvaluateBatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Parsing error: %v", err)
+	}
+
+	expected := []BatchResult{
+		{ID: "a", Status: http.StatusOK, PolicyApplied: true, PolicyVersion: "1.0.0"},
+		{ID: "b", Status: http.StatusOK},
+		{ID: "c", Status: http.StatusBadRequest, Error: `invalid airport codes "toolong"`},
+		{ID: "d", Status: http.StatusServiceUnavailable, Error: "Location Services unavailable"},
+		{ID: "e", Status: http.StatusServiceUnavailable, Error: "Location Services unavailable"},
+		{ID: "f", Status: http.StatusOK},
+	}
+	if len(response.Results) != len(expected) {
+		t.Fatalf("handler returned wrong number of results: got %v want %v", len(response.Results), len(expected))
+	}
+	for i, result := range expected {
+		if response.Results[i] != result {
+			t.Errorf("handler returned wrong result %v: got %+v want %+v", i, response.Results[i], result)
+		}
+	}
+
+	var policy []struct{ Code string }
+	if err := json.Unmarshal(response.Policy, &policy); err != nil || len(policy) == 0 || response.Locale != "fr" {
+		t.Errorf("handler returned wrong policy: got %v %v", response.Locale, string(response.Policy))
+	}
+
+	for code, count := range lookups {
+		if count != 1 {
+			t.Errorf("handler looked up %v %v times, want once", code, count)
+		}
+	}
+	if len(lookups) != 5 {
+		t.Errorf("handler looked up wrong airports: got %v", lookups)
+	}
+}
+
+func TestEvaluateBatchMatchesV2(t *testing.T) {
+	defer func(original *circuit.Breaker) { locationServicesBreaker = original }(locationServicesBreaker)
+	locationServicesBreaker = circuit.NewBreaker(100, time.Minute)
+
+	ts := setupCountryServer(map[string]string{"LAX": "US", "LHR": "GB"}, map[string]int{})
+	defer ts.Close()
+	defer resetServiceEndpoint()
+
+	// The batch decides each itinerary as the single evaluation does, whatever order the lookups complete in
+	for _, codes := range []string{`["ERR", "LAX"]`, `["LAX", "ERR"]`, `["LHR", "ERR"]`, `["LHR", "LAX"]`} {
+		single := setupV2RequestAndServe(strings.NewReader(`{"airportCodes": `+codes+`}`), nil)
+		applied := single.Code == http.StatusOK && decodeV2Response(t, single).PolicyApplied
+
+		var response EvaluateBatchResponse
+		json.Unmarshal(serveBatch(`{"itineraries": [{"airportCodes": `+codes+`}]}`, "").Body.Bytes(), &response)
+		if len(response.Results) != 1 || response.Results[0].Status != single.Code || response.Results[0].PolicyApplied != applied {
+			t.Errorf("handler decided %v differently from a single evaluation: got %+v want %v %v", codes, response.Results, single.Code, applied)
+		}
+	}
+}
+
+func TestEvaluateBatchOmitsPolicyWhenNotApplied(t *testing.T) {
+	lookups := map[string]int{}
+	ts := setupCountryServer(map[string]string{"LHR": "GB"}, lookups)
+	defer ts.Close()
+	defer resetServiceEndpoint()
+
+	w := serveBatch(`{"itineraries": [{"airportCodes": ["LHR"]}]}`, "")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), `"policy"`) {
+		t.Errorf("handler returned unexpected response: got %v %v", w.Code, w.Body.String())
+	}
+}
+
+func TestEvaluateBatchUnknownAirport(t *testing.T) {
+	defer func(original *circuit.Breaker) { locationServicesBreaker = original }(locationServicesBreaker)
+	locationServicesBreaker = circuit.NewBreaker(100, time.Minute)
+
+	// Location Services answer unknown codes with no airports, which a lookup goroutine must not panic on
+	ts := setupFakeServer(`{"airports": []}`)
+	defer ts.Close()
+	defer resetServiceEndpoint()
+
+	w := serveBatch(`{"itineraries": [{"id": "a", "airportCodes": ["ZZZ"]}]}`, "")
+
+	var response EvaluateBatchResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || len(response.Results) != 1 || response.Results[0].Status != http.StatusBadRequest {
+		t.Errorf("handler returned unexpected response: got %v %v", w.Code, w.Body.String())
+	}
+}
+
+func TestEvaluateBatchUnknownAirportIsClientError(t *testing.T) {
+	defer func(original *circuit.Breaker) { locationServicesBreaker = original }(locationServicesBreaker)
+	locationServicesBreaker = circuit.NewBreaker(100, time.Minute)
+
+	ts := setupCountryServer(map[string]string{"LHR": "GB", "LAX": "US", "ZZZ": ""}, map[string]int{})
+	defer ts.Close()
+	defer resetServiceEndpoint()
+
+	w := serveBatch(`{"itineraries": [
+		{"id": "a", "airportCodes": ["LHR", "ZZZ"]},
+		{"id": "b", "airportCodes": ["LAX", "ZZZ"]},
+		{"id": "c", "airportCodes": ["ERR"]}
+	]}`, "")
+
+	var response EvaluateBatchResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	expected := []BatchResult{
+		{ID: "a", Status: http.StatusBadRequest, Error: "unknown airport code ZZZ"},
+		{ID: "b", Status: http.StatusOK, PolicyApplied: true, PolicyVersion: "1.0.0"},
+		{ID: "c", Status: http.StatusServiceUnavailable, Error: "Location Services unavailable"},
+	}
+	if len(response.Results) != len(expected) {
+		t.Fatalf("handler returned unexpected response: got %v %v", w.Code, w.Body.String())
+	}
+	for i, result := range expected {
+		if response.Results[i] != result {
+			t.Errorf("handler returned wrong result %v: got %+v want %+v", i, response.Results[i], result)
+		}
+	}
+}
+
+func TestEvaluateBatchPolicyUnavailable(t *testing.T) {
+	defer func(original *circuit.Breaker) { locationServicesBreaker = original }(locationServicesBreaker)
+	locationServicesBreaker = circuit.NewBreaker(100, time.Minute)
+	defer func(original *policy.Store) { policyStore = original }(policyStore)
+	policyStore = policy.NewStore("../badDataFolder/")
+
+	ts := setupCountryServer(map[string]string{"SEA": "US", "LHR": "GB"}, map[string]int{})
+	defer ts.Close()
+	defer resetServiceEndpoint()
+
+	w := serveBatch(`{"itineraries": [
+		{"id": "a", "airportCodes": ["SEA"], "travelDate": "2027-01-15"},
+		{"id": "b", "airportCodes": ["SEA"]},
+		{"id": "c", "airportCodes": ["LHR"]}
+	]}`, "")
+
+	var response EvaluateBatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || w.Code != http.StatusOK || len(response.Results) != 3 {
+		t.Fatalf("handler failed the batch: got %v %v", w.Code, w.Body.String())
+	}
+
+	// Only the itineraries the policy applies to need the document
+	for i, status := range []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK} {
+		if response.Results[i].Status != status {
+			t.Errorf("handler returned wrong result %v: got %+v want status %v", i, response.Results[i], status)
+		}
+	}
+}
+
+func TestEvaluateBatchLimits(t *testing.T) {
+	lookups := 0
+	ts := setupCountingServer(&lookups)
+	defer ts.Close()
+	defer resetServiceEndpoint()
+	os.Setenv(config.BatchMaxItinerariesKey, "2")
+	defer os.Unsetenv(config.BatchMaxItinerariesKey)
+
+	expected := map[string]string{
+		`{"itineraries": []}`: "Bad Request: no itineraries",
+		`{"itineraries": [{"airportCodes": ["SEA"]}, {"airportCodes": ["LAX"]}, {"airportCodes": ["LHR"]}]}`: "Bad Request: 3 itineraries exceed the maximum of 2",
+		`{"itineraries": [{"airportCodes": ["SEA"], "date": "2017-06-01"}]}`:                                 `Bad Request: unknown field "date"`,
+	}
+	for body, message := range expected {
+		w := serveBatch(body, "")
+		if w.Code != http.StatusBadRequest || !strings.HasPrefix(w.Body.String(), message) {
+			t.Errorf("handler returned unexpected response: got %v %v want %v", w.Code, w.Body.String(), message)
+		}
+	}
+
+	if lookups != 0 {
+		t.Errorf("handler called the Location Services for a rejected batch: got %v lookups", lookups)
+	}
+}
+
+func TestEvaluateBatchResponseLimit(t *testing.T) {
+	lookups := map[string]int{}
+	ts := setupCountryServer(map[string]string{"LHR": "GB"}, lookups)
+	defer ts.Close()
+	defer resetServiceEndpoint()
+	os.Setenv(config.BatchMaxResponseBytesKey, "64")
+	defer os.Unsetenv(config.BatchMaxResponseBytesKey)
+
+	w := serveBatch(`{"itineraries": [{"airportCodes": ["LHR"]}, {"airportCodes": ["LHR"]}, {"airportCodes": ["LHR"]}]}`, "")
+	if w.Code != http.StatusRequestEntityTooLarge || !strings.Contains(w.Body.String(), "exceeds the maximum of 64, send fewer itineraries") {
+		t.Errorf("handler returned unexpected response: got %v %v", w.Code, w.Body.String())
+	}
+
+	// The bound is known before any lookup, so a rejected batch costs no Location Services calls
+	if len(lookups) != 0 {
+		t.Errorf("handler called the Location Services for a rejected batch: got %v", lookups)
+	}
+
+	// A batch whose bound fits is served, and its response stays within the bound
+	os.Setenv(config.BatchMaxResponseBytesKey, "4096")
+	w = serveBatch(`{"itineraries": [{"airportCodes": ["LHR"]}]}`, "")
+	if w.Code != http.StatusOK || w.Body.Len() > 4096 {
+		t.Errorf("handler returned unexpected response: got %v %v", w.Code, w.Body.String())
+	}
+}
+
+func TestBatchResponseBound(t *testing.T) {
+	current := policy.Version{Version: "1.0.0", Document: json.RawMessage(`[{"code": "US"}]`), Digest: "a"}
+	scheduled := policy.Version{Version: "2.0.0", Document: json.RawMessage(`[{"code": "US", "title": "2"}]`), Digest: "b"}
+	results := []BatchResult{{ID: "a", Status: http.StatusOK}, {ID: "b", Status: http.StatusOK}, {ID: "c", Status: http.StatusBadRequest, Error: "invalid"}}
+	versions := []policy.Version{current, scheduled, {}}
+	bound := batchResponseBound("en-US", results, versions, make([]error, 3), current)
+
+	// Whatever the lookups return, the response is no larger than the bound
+	for _, outcomes := range [][]BatchResult{
+		{{ID: "a", Status: http.StatusOK, PolicyApplied: true, PolicyVersion: "1.0.0"}, {ID: "b", Status: http.StatusOK, PolicyApplied: true, PolicyVersion: "2.0.0"}, results[2]},
+		{{ID: "a", Status: http.StatusServiceUnavailable, Error: batchLookupFailed}, {ID: "b", Status: http.StatusBadRequest, Error: "unknown airport code ZZZ"}, results[2]},
+	} {
+		response := EvaluateBatchResponse{Locale: "en-US", Results: outcomes}
+		for i, result := range outcomes {
+			if result.PolicyApplied {
+				includePolicy(&response, versions[i], current)
+			}
+		}
+
+		if body, _ := json.Marshal(response); len(body) > bound {
+			t.Errorf("batchResponseBound is below the response: got %v want at least %v", bound, len(body))
+		}
+	}
+}
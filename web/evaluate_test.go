@@ -1,32 +1,47 @@
 package web
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/dukeluke16/sample-golang-webservice/apm"
+	"github.com/dukeluke16/sample-golang-webservice/circuit"
 	"github.com/dukeluke16/sample-golang-webservice/config"
 	"github.com/dukeluke16/sample-golang-webservice/logger"
+	"github.com/dukeluke16/sample-golang-webservice/metrics"
+	"github.com/dukeluke16/sample-golang-webservice/policy"
 )
 
 func TestEvaluateResponse_HTTPMethodFailure(t *testing.T) {
-	w := setupRequestAndServe(http.MethodGet, nil, nil)
+	r, _ := http.NewRequest(http.MethodGet, EvaluatePath, nil)
+	r.Header.Set(RequestIDHeader, "booking-123")
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, r)
+	recordExchange(http.MethodGet, EvaluatePath, w)
 
 	if w.Code != http.StatusMethodNotAllowed {
-		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusMethodNotAllowed)
 	}
 
-	expected := http.StatusText(http.StatusMethodNotAllowed) + "\n"
+	expected := http.StatusText(http.StatusMethodNotAllowed) + " (Request ID: booking-123)\n"
 	if w.Body.String() != expected {
 		t.Errorf("handler returned unexpected body: got %v want %v",
 			w.Body.String(), expected)
 	}
+
+	if w.Header().Get("Allow") != "OPTIONS, POST" {
+		t.Errorf("handler returned wrong Allow header: got %v want %v", w.Header().Get("Allow"), "OPTIONS, POST")
+	}
 }
 
 func TestEvaluateResponse_NilRequestBody(t *testing.T) {
@@ -199,9 +214,50 @@ func TestEvaluateResponseFailureBadJSON(t *testing.T) {
 	}
 }
 
+func TestEvaluateResponseUnknownAirport(t *testing.T) {
+	ts := setupFakeServer(`{"airports": []}`)
+	w := setupPostRequestAndServe(strings.NewReader(`["zzz"]`), nil)
+	defer ts.Close()
+
+	if w.Code != http.StatusBadRequest || !strings.HasPrefix(w.Body.String(), "Bad Request: unknown airport code ZZZ") {
+		t.Errorf("handler returned wrong response: got %v %v want %v", w.Code, w.Body.String(), http.StatusBadRequest)
+	}
+
+	w = setupV2RequestAndServe(strings.NewReader(`{"airportCodes": ["zzz"]}`), nil)
+	if w.Code != http.StatusBadRequest || !strings.HasPrefix(w.Body.String(), "Bad Request: unknown airport code ZZZ") {
+		t.Errorf("handler returned wrong response: got %v %v want %v", w.Code, w.Body.String(), http.StatusBadRequest)
+	}
+
+	if _, err := lookupAirportLocationCode(context.Background(), "zzz"); err == nil || err.Error() != "unknown airport code ZZZ" {
+		t.Errorf("lookupAirportLocationCode returned wrong error: got %v want %v", err, "unknown airport code ZZZ")
+	}
+}
+
+func TestCheckAirportLocationCodeBreaker(t *testing.T) {
+	defer func(original *circuit.Breaker) { locationServicesBreaker = original }(locationServicesBreaker)
+	locationServicesBreaker = circuit.NewBreaker(1, time.Minute)
+
+	// Unknown airport codes are answered, so they do not trip the breaker
+	ts := setupFakeServer(`{"airports": []}`)
+	if _, err := checkAirportLocationCode(context.Background(), "zzz"); err == nil || locationServicesBreaker.State() != circuit.Closed {
+		t.Errorf("checkAirportLocationCode tripped the breaker on an unknown airport: got %v %v", err, locationServicesBreaker.State())
+	}
+	ts.Close()
+
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+	setServiceEndpoint(ts.URL)
+	if _, err := checkAirportLocationCode(context.Background(), "sea"); err == nil || locationServicesBreaker.State() != circuit.Open {
+		t.Errorf("checkAirportLocationCode did not trip the breaker on an upstream failure: got %v %v", err, locationServicesBreaker.State())
+	}
+}
+
 func TestEvaluateResponseDefaultPolicyNotFound(t *testing.T) {
 	ts := setupFakeServerUSA()
-	defaultDataFolder = "../badDataFolder/"
+	policyStore = policy.NewStore("../badDataFolder/")
+	defer func() { policyStore = policy.NewStore("../data/") }()
 	w := setupPostRequestAndServe(strings.NewReader(`["sea"]`), nil)
 	defer ts.Close()
 
@@ -227,10 +283,93 @@ func TestEvaluateResponseServiceNotAvailable(t *testing.T) {
 	}
 }
 
+func TestEvaluateResponseMetrics(t *testing.T) {
+	ts := setupFakeServerUSA()
+	defer ts.Close()
+
+	applied := testutil.ToFloat64(metrics.PolicyDecisions.WithLabelValues("true"))
+	notApplied := testutil.ToFloat64(metrics.PolicyDecisions.WithLabelValues("false"))
+	locale := testutil.ToFloat64(metrics.NegotiatedLocales.WithLabelValues("en-US"))
+
+	setupPostRequestAndServe(strings.NewReader(`["sea"]`), nil)
+
+	if testutil.ToFloat64(metrics.PolicyDecisions.WithLabelValues("true")) != applied+1 {
+		t.Errorf("PolicyDecisions did not count the applied policy!")
+	}
+
+	if testutil.ToFloat64(metrics.PolicyDecisions.WithLabelValues("false")) != notApplied {
+		t.Errorf("PolicyDecisions counted an unexpected decision!")
+	}
+
+	if testutil.ToFloat64(metrics.NegotiatedLocales.WithLabelValues("en-US")) != locale+1 {
+		t.Errorf("NegotiatedLocales did not count the default locale!")
+	}
+}
+
+type recordingExporter struct {
+	spans []apm.SpanData
+}
+
+func (e *recordingExporter) ExportSpans(spans []apm.SpanData) error {
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *recordingExporter) Shutdown() error {
+	return nil
+}
+
+func TestEvaluateResponseTracePropagation(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer = apm.NewOpenTelemetryTracer("test", exporter)
+	defer func() { tracer = apm.NewNoopTracer() }()
+
+	ts := setupFakeServerUSA()
+	defer ts.Close()
+
+	_, handler := tracer.WrapHandleFunc(EvaluatePath, EvaluatePostHandler)
+	r, _ := http.NewRequest(http.MethodPost, EvaluatePath, strings.NewReader(`["sea"]`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(apm.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+	}
+
+	upstreamTraceparent := fakeServerRequestHeader.Get(apm.TraceparentHeader)
+	if !strings.HasPrefix(upstreamTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-") {
+		t.Errorf("Location Services received wrong traceparent: got %v", upstreamTraceparent)
+	}
+
+	names := map[string]apm.SpanData{}
+	for _, span := range exporter.spans {
+		names[span.Name] = span
+		if span.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("Span %v did not continue the inbound trace: got %v", span.Name, span.TraceID)
+		}
+	}
+
+	for _, expected := range []string{EvaluatePath, "checkAirportLocationCode", "getHazardousGoodsPolicy"} {
+		if _, found := names[expected]; !found {
+			t.Errorf("Span %v was not exported: got %v", expected, exporter.spans)
+		}
+	}
+
+	if names[EvaluatePath].ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("Server span has wrong parent: got %v", names[EvaluatePath].ParentSpanID)
+	}
+
+	if names["checkAirportLocationCode"].Attributes["airport.code"] != "SEA" {
+		t.Errorf("Lookup span has wrong attributes: got %v", names["checkAirportLocationCode"].Attributes)
+	}
+}
+
 func init() {
 	resetServiceEndpoint()
-	logger.Init(ioutil.Discard, os.Stdout, os.Stdout, os.Stderr)
-	defaultDataFolder = "../data/"
+	logger.Init(os.Stderr, logger.LevelInfo, logger.FormatLogfmt)
+	policyStore = policy.NewStore("../data/")
 }
 
 func resetServiceEndpoint() {
@@ -332,8 +471,12 @@ func setupFakeServerUSA() *httptest.Server {
 		}`)
 }
 
+// fakeServerRequestHeader received by the most recent fake server call
+var fakeServerRequestHeader http.Header
+
 func setupFakeServer(data string) *httptest.Server {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fakeServerRequestHeader = r.Header
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Set("Accept-Language", "application/json")
 		fmt.Fprintln(w, data)
@@ -348,6 +491,7 @@ func setupPostRequestAndServe(dataReader io.Reader, tag *string) *httptest.Respo
 
 func setupRequestAndServe(method string, dataReader io.Reader, tag *string) *httptest.ResponseRecorder {
 	r, _ := http.NewRequest(method, EvaluatePath, dataReader)
+	r.Header.Set("Content-Type", "application/json")
 	r.Header.Set("Authorization", "Bearer abc123")
 	r.Header.Set("correlationid", "123456789")
 	if tag != nil {
@@ -357,6 +501,7 @@ func setupRequestAndServe(method string, dataReader io.Reader, tag *string) *htt
 	w := httptest.NewRecorder()
 	handler := http.HandlerFunc(EvaluatePostHandler)
 	handler.ServeHTTP(w, r)
+	recordExchange(method, EvaluatePath, w)
 
 	return w
 }
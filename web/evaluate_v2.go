@@ -0,0 +1,80 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// EvaluateV2Path for endpoint
+var EvaluateV2Path = "/v2/policy/hazardousgoods/evaluate"
+
+// EvaluateV2Request body
+type EvaluateV2Request struct {
+	AirportCodes []string `json:"airportCodes"`
+	// TravelDate selecting the policy version in force then, e.g. 2027-01-15, instead of the time of the request
+	TravelDate string `json:"travelDate,omitempty"`
+}
+
+// EvaluateV2Response body, always returned so clients need not special case No Content
+type EvaluateV2Response struct {
+	PolicyApplied bool            `json:"policyApplied"`
+	Locale        string          `json:"locale"`
+	PolicyVersion string          `json:"policyVersion,omitempty"`
+	Policy        json.RawMessage `json:"policy,omitempty"`
+}
+
+// EvaluateV2PostHandler for handling routed requests
+func EvaluateV2PostHandler(w http.ResponseWriter, r *http.Request) {
+	tag, r, ok := negotiateLocale(w, r)
+	if !ok {
+		return
+	}
+
+	// Missing or unparsable Body receives BadRequest Response
+	var request EvaluateV2Request
+	if err := decodeJSONBody(w, r, &request, true); err != nil {
+		if err == errEmptyBody {
+			genericStatusResponseError(w, r, http.StatusBadRequest)
+		}
+		return
+	}
+
+	at, ok := travelTime(w, r, request.TravelDate)
+	if !ok {
+		return
+	}
+
+	airportCodes, ok := validateAirportCodes(w, r, request.AirportCodes)
+	if !ok {
+		return
+	}
+
+	applied, err := evaluateAirportCodes(r.Context(), airportCodes)
+	// An airport code Location Services do not know is a mistake of the client, not an outage
+	if code, ok := unknownAirport(err); ok {
+		statusResponseError(w, r, http.StatusBadRequest, "unknown airport code "+code)
+		return
+	}
+	if err != nil {
+		genericStatusResponseError(w, r, http.StatusServiceUnavailable)
+		return
+	}
+
+	response := EvaluateV2Response{PolicyApplied: applied, Locale: tag.String()}
+	if applied {
+		version, err := getHazardousGoodsPolicy(r.Context(), tag, at)
+		if err != nil {
+			genericStatusResponseError(w, r, http.StatusInternalServerError)
+			return
+		}
+		response.PolicyVersion, response.Policy = version.Version, version.Document
+		policyVersionMetadata(w, version)
+	}
+
+	body, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Language", tag.String())
+	w.Header().Add("Vary", policyVary)
+	io.WriteString(w, string(body))
+}
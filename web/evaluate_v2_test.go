@@ -0,0 +1,92 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func setupV2RequestAndServe(dataReader io.Reader, tag *string) *httptest.ResponseRecorder {
+	r, _ := http.NewRequest(http.MethodPost, EvaluateV2Path, dataReader)
+	r.Header.Set("Content-Type", "application/json")
+	if tag != nil {
+		r.Header.Set("Accept-Language", *tag)
+	}
+
+	w := httptest.NewRecorder()
+	EvaluateV2PostHandler(w, r)
+	recordExchange(http.MethodPost, EvaluateV2Path, w)
+
+	return w
+}
+
+func decodeV2Response(t *testing.T, w *httptest.ResponseRecorder) EvaluateV2Response {
+	var response EvaluateV2Response
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Parsing error: %v", w.Body.String())
+	}
+
+	return response
+}
+
+func TestEvaluateV2PolicyApplied(t *testing.T) {
+	ts := setupFakeServerUSA()
+	defer ts.Close()
+
+	tag := "fr"
+	w := setupV2RequestAndServe(strings.NewReader(`{"airportCodes": ["sea"]}`), &tag)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+	}
+
+	response := decodeV2Response(t, w)
+	if !response.PolicyApplied || response.Locale != "fr" || len(response.Policy) == 0 {
+		t.Errorf("handler returned unexpected response: got %v", w.Body.String())
+	}
+
+	var policy []struct{ Code string }
+	json.Unmarshal(response.Policy, &policy)
+	if len(policy) != 1 || policy[0].Code != "US" {
+		t.Errorf("handler returned an unexpected policy: got %v", string(response.Policy))
+	}
+}
+
+func TestEvaluateV2PolicyNotApplied(t *testing.T) {
+	ts := setupFakeServerEMEA()
+	defer ts.Close()
+
+	w := setupV2RequestAndServe(strings.NewReader(`{"airportCodes": ["lcy"]}`), nil)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+	}
+
+	expected := `{"policyApplied":false,"locale":"en-US"}`
+	if w.Body.String() != expected {
+		t.Errorf("handler returned unexpected body: got %v want %v", w.Body.String(), expected)
+	}
+}
+
+func TestEvaluateV2BadRequest(t *testing.T) {
+	for _, body := range []io.Reader{nil, strings.NewReader(`["sea"]`), strings.NewReader(`{"airportCodes": "sea"}`)} {
+		w := setupV2RequestAndServe(body, nil)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestEvaluateV2ServiceNotAvailable(t *testing.T) {
+	setServiceEndpoint("http://localhost")
+
+	w := setupV2RequestAndServe(strings.NewReader(`{"airportCodes": ["sea"]}`), nil)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusServiceUnavailable)
+	}
+}
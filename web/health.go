@@ -1,18 +1,100 @@
 package web
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
+	"github.com/dukeluke16/sample-golang-webservice/circuit"
 	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/health"
 )
 
 // HealthPath for endpoint
 var HealthPath = "/health"
 
+// HealthLivePath for endpoint
+var HealthLivePath = "/health/live"
+
+// HealthReadyPath for endpoint
+var HealthReadyPath = "/health/ready"
+
+// readiness checks run by the HealthReadyGetHandler
+var readiness = health.NewRegistry()
+
+// probeClient for the Location Services reachability probe
+var probeClient = &http.Client{Timeout: 5 * time.Second}
+
 // HealthGetHandler for handling routed requests
 func HealthGetHandler(w http.ResponseWriter, r *http.Request) {
 	packageVersion := fmt.Sprintf("Service Version: %v", config.BinaryVersion)
 	io.WriteString(w, packageVersion)
 }
+
+// HealthLiveGetHandler for handling routed requests
+func HealthLiveGetHandler(w http.ResponseWriter, r *http.Request) {
+	healthResponse(w, http.StatusOK, health.Report{Status: health.StatusUp, Checks: []health.Result{}})
+}
+
+// HealthReadyGetHandler for handling routed requests
+func HealthReadyGetHandler(w http.ResponseWriter, r *http.Request) {
+	report := readiness.Run()
+
+	statusCode := http.StatusOK
+	if report.Status != health.StatusUp {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	healthResponse(w, statusCode, report)
+}
+
+func healthResponse(w http.ResponseWriter, statusCode int, report health.Report) {
+	body, _ := json.Marshal(struct {
+		health.Report
+		Version string `json:"version"`
+	}{report, config.BinaryVersion})
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
+
+// registerReadinessChecks for the dependencies of the evaluate endpoint
+func registerReadinessChecks() {
+	readiness.Register(health.NewCheck("policyStore", checkPolicyStore))
+	readiness.Register(health.Cached(
+		health.NewCheck("locationServices", checkLocationServices),
+		config.DurationValue(config.HealthProbeTTLKey, 30*time.Second)))
+	readiness.Register(health.NewCheck("circuitBreaker", checkCircuitBreaker))
+}
+
+func checkPolicyStore() error {
+	if !policyStore.Loaded() {
+		return policyStore.Load()
+	}
+
+	return nil
+}
+
+// checkLocationServices treats any HTTP response as reachable
+func checkLocationServices() error {
+	resp, err := probeClient.Head(config.LocationServicesURI())
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+func checkCircuitBreaker() error {
+	state := locationServicesBreaker.State()
+	if state == circuit.Open {
+		return errors.New("location services circuit breaker is " + state.String())
+	}
+
+	return nil
+}
@@ -1,9 +1,15 @@
 package web
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/dukeluke16/sample-golang-webservice/circuit"
+	"github.com/dukeluke16/sample-golang-webservice/health"
+	"github.com/dukeluke16/sample-golang-webservice/policy"
 )
 
 func TestHealthCheckResponses(t *testing.T) {
@@ -16,6 +22,7 @@ func TestHealthCheckResponses(t *testing.T) {
 	handler := http.HandlerFunc(HealthGetHandler)
 
 	handler.ServeHTTP(w, r)
+	recordExchange(http.MethodGet, HealthPath, w)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
@@ -27,3 +34,121 @@ func TestHealthCheckResponses(t *testing.T) {
 			w.Body.String(), expected)
 	}
 }
+
+func TestHealthLiveResponse(t *testing.T) {
+	w := serveHealth(HealthLivePath, HealthLiveGetHandler)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+	}
+
+	report := parseHealthReport(t, w)
+	if report.Status != health.StatusUp || report.Version != "0.0.dev" {
+		t.Errorf("handler returned unexpected body: got %v", w.Body.String())
+	}
+}
+
+func TestHealthReadyResponse(t *testing.T) {
+	ts := setupFakeServerUSA()
+	defer ts.Close()
+	readiness = health.NewRegistry()
+	registerReadinessChecks()
+
+	w := serveHealth(HealthReadyPath, HealthReadyGetHandler)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+	}
+
+	report := parseHealthReport(t, w)
+	if report.Status != health.StatusUp || len(report.Checks) != 3 {
+		t.Errorf("handler returned unexpected body: got %v", w.Body.String())
+	}
+
+	for _, check := range report.Checks {
+		if check.Status != health.StatusUp {
+			t.Errorf("handler returned unexpected check: got %v", check)
+		}
+	}
+}
+
+func TestHealthReadyUpstreamUnreachable(t *testing.T) {
+	ts := setupFakeServerUSA()
+	ts.Close()
+	readiness = health.NewRegistry()
+	registerReadinessChecks()
+
+	w := serveHealth(HealthReadyPath, HealthReadyGetHandler)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusServiceUnavailable)
+	}
+
+	report := parseHealthReport(t, w)
+	if report.Status != health.StatusDown || report.Checks[1].Status != health.StatusDown {
+		t.Errorf("handler returned unexpected body: got %v", w.Body.String())
+	}
+}
+
+func TestHealthReadyPolicyStoreMissing(t *testing.T) {
+	ts := setupFakeServerUSA()
+	defer ts.Close()
+	policyStore = policy.NewStore("../badDataFolder/")
+	defer func() { policyStore = policy.NewStore("../data/") }()
+	readiness = health.NewRegistry()
+	registerReadinessChecks()
+
+	w := serveHealth(HealthReadyPath, HealthReadyGetHandler)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusServiceUnavailable)
+	}
+
+	report := parseHealthReport(t, w)
+	if report.Checks[0].Name != "policyStore" || report.Checks[0].Status != health.StatusDown {
+		t.Errorf("handler returned unexpected body: got %v", w.Body.String())
+	}
+}
+
+func TestHealthReadyCircuitBreakerOpen(t *testing.T) {
+	ts := setupFakeServerUSA()
+	defer ts.Close()
+	locationServicesBreaker = circuit.NewBreaker(1, time.Minute)
+	defer func() { locationServicesBreaker = circuit.NewBreaker(5, 30*time.Second) }()
+	locationServicesBreaker.Failure()
+	readiness = health.NewRegistry()
+	registerReadinessChecks()
+
+	w := serveHealth(HealthReadyPath, HealthReadyGetHandler)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusServiceUnavailable)
+	}
+
+	report := parseHealthReport(t, w)
+	if report.Checks[2].Name != "circuitBreaker" || report.Checks[2].Error != "location services circuit breaker is open" {
+		t.Errorf("handler returned unexpected body: got %v", w.Body.String())
+	}
+}
+
+type healthReportModel struct {
+	health.Report
+	Version string
+}
+
+func serveHealth(path string, handlerFunc http.HandlerFunc) *httptest.ResponseRecorder {
+	r, _ := http.NewRequest(http.MethodGet, path, nil)
+	w := httptest.NewRecorder()
+	handlerFunc.ServeHTTP(w, r)
+	recordExchange(http.MethodGet, path, w)
+
+	return w
+}
+
+func parseHealthReport(t *testing.T, w *httptest.ResponseRecorder) healthReportModel {
+	var report healthReportModel
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Errorf("Parsing error.")
+	}
+	return report
+}
@@ -0,0 +1,48 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dukeluke16/sample-golang-webservice/config"
+)
+
+// InfoPath for endpoint
+var InfoPath = "/info"
+
+// PolicyDataInfo describing the loaded policy data folder
+type PolicyDataInfo struct {
+	Version  string   `json:"version"`
+	Checksum string   `json:"checksum"`
+	Locales  []string `json:"locales"`
+}
+
+// Info describing exactly what is deployed
+type Info struct {
+	Build      config.BuildInfo `json:"build"`
+	PolicyData PolicyDataInfo   `json:"policyData"`
+}
+
+// CurrentInfo method to return the build and policy data details
+func CurrentInfo() Info {
+	if !policyStore.Loaded() {
+		policyStore.Load()
+	}
+
+	return Info{
+		Build: config.Build(),
+		PolicyData: PolicyDataInfo{
+			Version:  policyStore.Version(),
+			Checksum: policyStore.Checksum(),
+			Locales:  policyStore.Locales(),
+		},
+	}
+}
+
+// InfoGetHandler for handling routed requests
+func InfoGetHandler(w http.ResponseWriter, r *http.Request) {
+	body, _ := json.Marshal(CurrentInfo())
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
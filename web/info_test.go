@@ -0,0 +1,39 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+)
+
+func TestInfoResponse(t *testing.T) {
+	r, err := http.NewRequest(http.MethodGet, InfoPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	handler := http.HandlerFunc(InfoGetHandler)
+
+	handler.ServeHTTP(w, r)
+	recordExchange(http.MethodGet, InfoPath, w)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+	}
+
+	var info Info
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Errorf("Parsing error.")
+	}
+
+	if info.Build.Version != "0.0.dev" || info.Build.GoVersion != runtime.Version() {
+		t.Errorf("handler returned unexpected build: got %v", info.Build)
+	}
+
+	if info.PolicyData.Version != "1.0.0" || len(info.PolicyData.Checksum) == 0 || len(info.PolicyData.Locales) != 33 {
+		t.Errorf("handler returned unexpected policy data: got %v", info.PolicyData)
+	}
+}
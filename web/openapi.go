@@ -0,0 +1,1058 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dukeluke16/sample-golang-webservice/config"
+)
+
+// OpenAPIPath for endpoint
+var OpenAPIPath = "/openapi.json"
+
+// OpenAPIGetHandler for handling routed requests
+func OpenAPIGetHandler(w http.ResponseWriter, r *http.Request) {
+	var document map[string]interface{}
+	json.Unmarshal([]byte(openAPIDocument), &document)
+	document["info"].(map[string]interface{})["version"] = config.BinaryVersion
+
+	body, _ := json.Marshal(document)
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// openAPIDocument describing every route; openapi_test.go validates the handler responses against it
+const openAPIDocument = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "sample-golang-webservice",
+    "description": "Evaluates whether the localized hazardous goods policy applies to an itinerary.",
+    "version": "0.0.dev"
+  },
+  "paths": {
+    "/v1/policy/hazardousgoods/evaluate": {
+      "post": {
+        "summary": "Evaluate the hazardous goods policy for airport codes",
+        "operationId": "evaluateV1",
+        "security": [{}, {"APIKey": []}, {"BearerJWT": []}],
+        "parameters": [
+          {"$ref": "#/components/parameters/AcceptLanguage"},
+          {"$ref": "#/components/parameters/PolicyPreview"},
+          {"$ref": "#/components/parameters/RequestID"}
+        ],
+        "requestBody": {"$ref": "#/components/requestBodies/EvaluateV1"},
+        "responses": {
+          "200": {"$ref": "#/components/responses/Policy"},
+          "204": {"$ref": "#/components/responses/PolicyNotApplied"},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "406": {"$ref": "#/components/responses/Error"},
+          "413": {"$ref": "#/components/responses/Error"},
+          "415": {"$ref": "#/components/responses/Error"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"},
+          "500": {"$ref": "#/components/responses/Error"},
+          "503": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/v2/policy/hazardousgoods/evaluate": {
+      "post": {
+        "summary": "Evaluate the hazardous goods policy for airport codes",
+        "operationId": "evaluateV2",
+        "security": [{}, {"APIKey": []}, {"BearerJWT": []}],
+        "parameters": [
+          {"$ref": "#/components/parameters/AcceptLanguage"},
+          {"$ref": "#/components/parameters/PolicyPreview"},
+          {"$ref": "#/components/parameters/RequestID"}
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {"schema": {"$ref": "#/components/schemas/EvaluateV2Request"}}
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "The evaluation, with the localized policy when it applies",
+            "headers": {
+              "Content-Language": {"$ref": "#/components/headers/ContentLanguage"},
+              "Policy-Version": {"$ref": "#/components/headers/PolicyVersion"}
+            },
+            "content": {
+              "application/json": {"schema": {"$ref": "#/components/schemas/EvaluateV2Response"}}
+            }
+          },
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "406": {"$ref": "#/components/responses/Error"},
+          "413": {"$ref": "#/components/responses/Error"},
+          "415": {"$ref": "#/components/responses/Error"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"},
+          "500": {"$ref": "#/components/responses/Error"},
+          "503": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/v2/policy/hazardousgoods/evaluate/batch": {
+      "post": {
+        "summary": "Evaluate the hazardous goods policy for many itineraries",
+        "description": "Each airport is looked up once across the batch. Results are in request order; an itinerary that fails carries its own status and error without failing the batch.",
+        "operationId": "evaluateBatch",
+        "security": [{}, {"APIKey": []}, {"BearerJWT": []}],
+        "parameters": [
+          {"$ref": "#/components/parameters/AcceptLanguage"},
+          {"$ref": "#/components/parameters/PolicyPreview"},
+          {"$ref": "#/components/parameters/RequestID"}
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {"schema": {"$ref": "#/components/schemas/EvaluateBatchRequest"}}
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "One result per itinerary, with the localized policy once when it applies to any",
+            "headers": {
+              "Content-Language": {"$ref": "#/components/headers/ContentLanguage"}
+            },
+            "content": {
+              "application/json": {"schema": {"$ref": "#/components/schemas/EvaluateBatchResponse"}}
+            }
+          },
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "406": {"$ref": "#/components/responses/Error"},
+          "413": {"$ref": "#/components/responses/Error"},
+          "415": {"$ref": "#/components/responses/Error"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/policy/hazardousgoods/evaluate": {
+      "post": {
+        "summary": "Alias of /v1/policy/hazardousgoods/evaluate",
+        "description": "Responses carry a Deprecation header with the RFC 9745 date the route was deprecated (@ followed by epoch seconds), a Sunset header, and a Link header naming the v1 successor.",
+        "operationId": "evaluateLegacy",
+        "deprecated": true,
+        "security": [{}, {"APIKey": []}, {"BearerJWT": []}],
+        "parameters": [
+          {"$ref": "#/components/parameters/AcceptLanguage"},
+          {"$ref": "#/components/parameters/PolicyPreview"},
+          {"$ref": "#/components/parameters/RequestID"}
+        ],
+        "requestBody": {"$ref": "#/components/requestBodies/EvaluateV1"},
+        "responses": {
+          "200": {"$ref": "#/components/responses/Policy"},
+          "204": {"$ref": "#/components/responses/PolicyNotApplied"},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "406": {"$ref": "#/components/responses/Error"},
+          "413": {"$ref": "#/components/responses/Error"},
+          "415": {"$ref": "#/components/responses/Error"},
+          "429": {"$ref": "#/components/responses/TooManyRequests"},
+          "500": {"$ref": "#/components/responses/Error"},
+          "503": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/v1/policies": {
+      "get": {
+        "summary": "List the policies and the locales each is available in",
+        "operationId": "listPolicies",
+        "parameters": [
+          {"$ref": "#/components/parameters/RequestID"}
+        ],
+        "responses": {
+          "200": {
+            "description": "The policies",
+            "content": {
+              "application/json": {"schema": {"$ref": "#/components/schemas/PolicyList"}}
+            }
+          },
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/v1/policies/{id}": {
+      "get": {
+        "summary": "Fetch a policy document in the negotiated locale",
+        "operationId": "getPolicy",
+        "security": [{}, {"APIKey": []}, {"BearerJWT": []}],
+        "parameters": [
+          {"$ref": "#/components/parameters/PolicyID"},
+          {"$ref": "#/components/parameters/At"},
+          {"$ref": "#/components/parameters/AcceptLanguage"},
+          {"$ref": "#/components/parameters/IfNoneMatch"},
+          {"$ref": "#/components/parameters/PolicyPreview"},
+          {"$ref": "#/components/parameters/RequestID"}
+        ],
+        "responses": {
+          "200": {"$ref": "#/components/responses/PolicyDocument"},
+          "304": {"$ref": "#/components/responses/NotModified"},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "406": {"$ref": "#/components/responses/Error"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/v1/policies/{id}/versions": {
+      "get": {
+        "summary": "List the versions of a policy with the window each is in force",
+        "description": "Scheduled versions are listed before they come into force; fetch one with the at parameter to preview it.",
+        "operationId": "listPolicyVersions",
+        "parameters": [
+          {"$ref": "#/components/parameters/PolicyID"},
+          {"$ref": "#/components/parameters/RequestID"}
+        ],
+        "responses": {
+          "200": {
+            "description": "The versions",
+            "content": {
+              "application/json": {"schema": {"$ref": "#/components/schemas/PolicyVersionList"}}
+            }
+          },
+          "404": {"$ref": "#/components/responses/Error"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/v1/policies/{id}/locales/{locale}": {
+      "get": {
+        "summary": "Fetch a policy document by locale",
+        "operationId": "getPolicyLocale",
+        "security": [{}, {"APIKey": []}, {"BearerJWT": []}],
+        "parameters": [
+          {"$ref": "#/components/parameters/PolicyID"},
+          {
+            "name": "locale",
+            "in": "path",
+            "required": true,
+            "description": "Locale of the document, e.g. en-US",
+            "schema": {"type": "string"}
+          },
+          {"$ref": "#/components/parameters/At"},
+          {"$ref": "#/components/parameters/IfNoneMatch"},
+          {"$ref": "#/components/parameters/PolicyPreview"},
+          {"$ref": "#/components/parameters/RequestID"}
+        ],
+        "responses": {
+          "200": {"$ref": "#/components/responses/PolicyDocument"},
+          "304": {"$ref": "#/components/responses/NotModified"},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/admin/v1/policies/{id}/locales": {
+      "get": {
+        "summary": "List the stored locales of a policy with their digests",
+        "operationId": "adminListPolicyLocales",
+        "security": [{"APIKey": []}, {"BearerJWT": []}],
+        "parameters": [
+          {"$ref": "#/components/parameters/PolicyID"},
+          {"$ref": "#/components/parameters/RequestID"}
+        ],
+        "responses": {
+          "200": {
+            "description": "The stored locales",
+            "content": {
+              "application/json": {"schema": {"$ref": "#/components/schemas/AdminLocaleList"}}
+            }
+          },
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/admin/v1/policies/{id}/locales/{locale}": {
+      "get": {
+        "summary": "Fetch the stored policy document of a locale",
+        "operationId": "adminGetPolicyLocale",
+        "security": [{"APIKey": []}, {"BearerJWT": []}],
+        "parameters": [
+          {"$ref": "#/components/parameters/PolicyID"},
+          {"$ref": "#/components/parameters/AdminLocale"},
+          {"$ref": "#/components/parameters/RequestID"}
+        ],
+        "responses": {
+          "200": {
+            "description": "The stored document",
+            "headers": {
+              "ETag": {"$ref": "#/components/headers/AdminETag"}
+            },
+            "content": {
+              "application/json": {"schema": {"$ref": "#/components/schemas/HazardousGoodsPolicy"}}
+            }
+          },
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "put": {
+        "summary": "Create or replace the policy document of a locale",
+        "description": "The document is validated, recorded in the audit log with a diff, and written atomically to the data folder.",
+        "operationId": "adminPutPolicyLocale",
+        "security": [{"APIKey": []}, {"BearerJWT": []}],
+        "parameters": [
+          {"$ref": "#/components/parameters/PolicyID"},
+          {"$ref": "#/components/parameters/AdminLocale"},
+          {"$ref": "#/components/parameters/IfMatch"},
+          {
+            "name": "If-None-Match",
+            "in": "header",
+            "description": "* to only create the document; 412 when it already exists",
+            "schema": {"type": "string"}
+          },
+          {"$ref": "#/components/parameters/RequestID"}
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {"schema": {"$ref": "#/components/schemas/HazardousGoodsPolicy"}}
+          }
+        },
+        "responses": {
+          "201": {
+            "description": "The document was created",
+            "headers": {
+              "ETag": {"$ref": "#/components/headers/AdminETag"},
+              "Location": {
+                "description": "Path of the document",
+                "required": true,
+                "schema": {"type": "string"}
+              }
+            }
+          },
+          "204": {
+            "description": "The document was replaced",
+            "headers": {
+              "ETag": {"$ref": "#/components/headers/AdminETag"}
+            }
+          },
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "412": {"$ref": "#/components/responses/Error"},
+          "413": {"$ref": "#/components/responses/Error"},
+          "415": {"$ref": "#/components/responses/Error"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "delete": {
+        "summary": "Delete the policy document of a locale",
+        "description": "The deletion is recorded in the audit log. The default en-US locale and the last document cannot be deleted.",
+        "operationId": "adminDeletePolicyLocale",
+        "security": [{"APIKey": []}, {"BearerJWT": []}],
+        "parameters": [
+          {"$ref": "#/components/parameters/PolicyID"},
+          {"$ref": "#/components/parameters/AdminLocale"},
+          {"$ref": "#/components/parameters/IfMatch"},
+          {"$ref": "#/components/parameters/RequestID"}
+        ],
+        "responses": {
+          "204": {"description": "The document was deleted"},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "409": {"$ref": "#/components/responses/Error"},
+          "412": {"$ref": "#/components/responses/Error"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/admin/v1/policies/{id}/locales/{locale}/draft": {
+      "get": {
+        "summary": "Fetch the draft of the policy document of a locale",
+        "operationId": "adminGetPolicyDraft",
+        "security": [{"APIKey": []}, {"BearerJWT": []}],
+        "parameters": [
+          {"$ref": "#/components/parameters/PolicyID"},
+          {"$ref": "#/components/parameters/AdminLocale"},
+          {"$ref": "#/components/parameters/RequestID"}
+        ],
+        "responses": {
+          "200": {
+            "description": "The draft",
+            "headers": {
+              "ETag": {"$ref": "#/components/headers/AdminETag"}
+            },
+            "content": {
+              "application/json": {"schema": {"$ref": "#/components/schemas/HazardousGoodsPolicy"}}
+            }
+          },
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "put": {
+        "summary": "Create or replace the draft of the policy document of a locale",
+        "description": "The draft is validated and recorded in the audit log like a document, but is only served to previews until it is published.",
+        "operationId": "adminPutPolicyDraft",
+        "security": [{"APIKey": []}, {"BearerJWT": []}],
+        "parameters": [
+          {"$ref": "#/components/parameters/PolicyID"},
+          {"$ref": "#/components/parameters/AdminLocale"},
+          {"$ref": "#/components/parameters/IfMatch"},
+          {
+            "name": "If-None-Match",
+            "in": "header",
+            "description": "* to only create the draft; 412 when it already exists",
+            "schema": {"type": "string"}
+          },
+          {"$ref": "#/components/parameters/RequestID"}
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {"schema": {"$ref": "#/components/schemas/HazardousGoodsPolicy"}}
+          }
+        },
+        "responses": {
+          "201": {
+            "description": "The draft was created",
+            "headers": {
+              "ETag": {"$ref": "#/components/headers/AdminETag"},
+              "Location": {
+                "description": "Path of the draft",
+                "required": true,
+                "schema": {"type": "string"}
+              }
+            }
+          },
+          "204": {
+            "description": "The draft was replaced",
+            "headers": {
+              "ETag": {"$ref": "#/components/headers/AdminETag"}
+            }
+          },
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "412": {"$ref": "#/components/responses/Error"},
+          "413": {"$ref": "#/components/responses/Error"},
+          "415": {"$ref": "#/components/responses/Error"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "delete": {
+        "summary": "Discard the draft of the policy document of a locale",
+        "operationId": "adminDeletePolicyDraft",
+        "security": [{"APIKey": []}, {"BearerJWT": []}],
+        "parameters": [
+          {"$ref": "#/components/parameters/PolicyID"},
+          {"$ref": "#/components/parameters/AdminLocale"},
+          {"$ref": "#/components/parameters/IfMatch"},
+          {"$ref": "#/components/parameters/RequestID"}
+        ],
+        "responses": {
+          "204": {"description": "The draft was discarded"},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "412": {"$ref": "#/components/responses/Error"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/admin/v1/policies/{id}/locales/{locale}/publish": {
+      "post": {
+        "summary": "Publish the draft of a locale as its policy document",
+        "description": "The draft replaces the document in one step, so every request is served one or the other, and the publication is recorded in the audit log.",
+        "operationId": "adminPublishPolicyDraft",
+        "security": [{"APIKey": []}, {"BearerJWT": []}],
+        "parameters": [
+          {"$ref": "#/components/parameters/PolicyID"},
+          {"$ref": "#/components/parameters/AdminLocale"},
+          {
+            "name": "If-Match",
+            "in": "header",
+            "description": "ETag of the draft that was previewed; 412 when it has changed since",
+            "schema": {"type": "string"}
+          },
+          {"$ref": "#/components/parameters/RequestID"}
+        ],
+        "responses": {
+          "201": {
+            "description": "The draft was published as the document of a new locale",
+            "headers": {
+              "ETag": {"$ref": "#/components/headers/AdminETag"},
+              "Location": {
+                "description": "Path of the document",
+                "required": true,
+                "schema": {"type": "string"}
+              }
+            }
+          },
+          "204": {
+            "description": "The draft was published",
+            "headers": {
+              "ETag": {"$ref": "#/components/headers/AdminETag"}
+            }
+          },
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Unauthorized"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "412": {"$ref": "#/components/responses/Error"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/health": {
+      "get": {
+        "summary": "Service version for existing monitors",
+        "operationId": "health",
+        "responses": {
+          "200": {
+            "description": "The Service Version",
+            "content": {
+              "text/plain": {"schema": {"type": "string", "pattern": "^Service Version: "}}
+            }
+          },
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"}
+        }
+      }
+    },
+    "/health/live": {
+      "get": {
+        "summary": "Liveness of the process",
+        "operationId": "healthLive",
+        "responses": {
+          "200": {"$ref": "#/components/responses/HealthReport"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"}
+        }
+      }
+    },
+    "/health/ready": {
+      "get": {
+        "summary": "Readiness of the policy data and upstream dependencies",
+        "operationId": "healthReady",
+        "responses": {
+          "200": {"$ref": "#/components/responses/HealthReport"},
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"},
+          "503": {"$ref": "#/components/responses/HealthReport"}
+        }
+      }
+    },
+    "/info": {
+      "get": {
+        "summary": "Build and policy data details",
+        "operationId": "info",
+        "responses": {
+          "200": {
+            "description": "What is deployed",
+            "content": {
+              "application/json": {"schema": {"$ref": "#/components/schemas/Info"}}
+            }
+          },
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"}
+        }
+      }
+    },
+    "/metrics": {
+      "get": {
+        "summary": "Prometheus metrics",
+        "operationId": "metrics",
+        "responses": {
+          "200": {
+            "description": "Metrics in the Prometheus text format",
+            "content": {
+              "text/plain": {"schema": {"type": "string"}}
+            }
+          },
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"}
+        }
+      }
+    },
+    "/openapi.json": {
+      "get": {
+        "summary": "This document",
+        "operationId": "openAPI",
+        "responses": {
+          "200": {
+            "description": "The OpenAPI document",
+            "content": {
+              "application/json": {"schema": {"type": "object", "required": ["openapi", "info", "paths"]}}
+            }
+          },
+          "405": {"$ref": "#/components/responses/MethodNotAllowed"}
+        }
+      }
+    }
+  },
+  "components": {
+    "parameters": {
+      "AcceptLanguage": {
+        "name": "Accept-Language",
+        "in": "header",
+        "description": "Preferred locales; en-US when absent, 406 when none is supported",
+        "schema": {"type": "string", "example": "fr-CA, fr;q=0.8"}
+      },
+      "PolicyID": {
+        "name": "id",
+        "in": "path",
+        "required": true,
+        "schema": {"type": "string", "enum": ["hazardousgoods"]}
+      },
+      "At": {
+        "name": "at",
+        "in": "query",
+        "description": "Previews the version in force at a date, e.g. 2027-01-15, or an RFC 3339 time; now when absent",
+        "schema": {"type": "string"}
+      },
+      "IfNoneMatch": {
+        "name": "If-None-Match",
+        "in": "header",
+        "description": "ETag of a cached document; 304 when it is still current",
+        "schema": {"type": "string"}
+      },
+      "AdminLocale": {
+        "name": "locale",
+        "in": "path",
+        "required": true,
+        "description": "BCP 47 locale of the document, e.g. en-US",
+        "schema": {"type": "string"}
+      },
+      "IfMatch": {
+        "name": "If-Match",
+        "in": "header",
+        "description": "ETag of the stored document the change was made from; 412 when it has changed since",
+        "schema": {"type": "string"}
+      },
+      "PolicyPreview": {
+        "name": "Policy-Preview",
+        "in": "header",
+        "description": "draft to be served the drafts of policy documents in place of the documents in force; 403 unless the client is authenticated and listed in PREVIEW_CLIENTS or ADMIN_CLIENTS",
+        "schema": {"type": "string", "enum": ["draft"]}
+      },
+      "RequestID": {
+        "name": "X-Request-ID",
+        "in": "header",
+        "description": "Client request ID of up to 128 printable ASCII characters; generated when absent or invalid",
+        "schema": {"type": "string", "maxLength": 128}
+      }
+    },
+    "headers": {
+      "ContentLanguage": {
+        "description": "Negotiated locale of the response",
+        "required": true,
+        "schema": {"type": "string", "example": "en-US"}
+      },
+      "ETag": {
+        "description": "Validator of the document, stable for each locale, policy version, and content encoding",
+        "required": true,
+        "schema": {"type": "string", "example": "\"en-US-1.0.0-3f7c2a9d1b0e4c58\""}
+      },
+      "PolicyVersion": {
+        "description": "Version of the policy document in the response, draft for a preview",
+        "schema": {"type": "string", "example": "1.0.0"}
+      },
+      "AdminETag": {
+        "description": "Digest of the stored document, for If-Match",
+        "required": true,
+        "schema": {"type": "string", "example": "\"3f7c2a9d1b0e4c58\""}
+      },
+      "ContentEncoding": {
+        "description": "Negotiated compression of the body, br or gzip, when the client accepts it",
+        "schema": {"type": "string", "enum": ["br", "gzip"]}
+      },
+      "CacheControl": {
+        "description": "Caching directives from POLICY_CACHE_CONTROL, or no-store for previews",
+        "required": true,
+        "schema": {"type": "string", "example": "public, max-age=3600"}
+      },
+      "RetryAfter": {
+        "description": "Seconds until the client may send the next request",
+        "required": true,
+        "schema": {"type": "integer"}
+      },
+      "RateLimitLimit": {
+        "description": "Requests the client may send at once",
+        "schema": {"type": "integer"}
+      },
+      "RateLimitRemaining": {
+        "description": "Requests the client may still send at once",
+        "schema": {"type": "integer"}
+      },
+      "RateLimitReset": {
+        "description": "Seconds until the full limit is available again",
+        "schema": {"type": "integer"}
+      },
+      "WWWAuthenticate": {
+        "description": "Challenges for the authentication modes the service accepts",
+        "required": true,
+        "schema": {"type": "string", "example": "ApiKey header=\"X-Api-Key\", Bearer"}
+      },
+      "Allow": {
+        "description": "Methods supported by the route",
+        "required": true,
+        "schema": {"type": "string", "example": "OPTIONS, POST"}
+      }
+    },
+    "requestBodies": {
+      "EvaluateV1": {
+        "description": "Three letter airport codes of the itinerary, at most AIRPORT_CODES_MAX (default 10); duplicates are evaluated once and an empty body or array receives 204",
+        "content": {
+          "application/json": {"schema": {"$ref": "#/components/schemas/AirportCodes"}}
+        }
+      }
+    },
+    "responses": {
+      "Policy": {
+        "description": "The localized policy, which applies because an airport is inside the USA",
+        "headers": {
+          "Content-Language": {"$ref": "#/components/headers/ContentLanguage"},
+          "Policy-Version": {"$ref": "#/components/headers/PolicyVersion"},
+          "ETag": {"$ref": "#/components/headers/ETag"}
+        },
+        "content": {
+          "application/json": {"schema": {"$ref": "#/components/schemas/HazardousGoodsPolicy"}}
+        }
+      },
+      "PolicyDocument": {
+        "description": "The localized policy",
+        "headers": {
+          "Content-Language": {"$ref": "#/components/headers/ContentLanguage"},
+          "Policy-Version": {"$ref": "#/components/headers/PolicyVersion"},
+          "Content-Encoding": {"$ref": "#/components/headers/ContentEncoding"},
+          "ETag": {"$ref": "#/components/headers/ETag"},
+          "Cache-Control": {"$ref": "#/components/headers/CacheControl"}
+        },
+        "content": {
+          "application/json": {"schema": {"$ref": "#/components/schemas/HazardousGoodsPolicy"}}
+        }
+      },
+      "NotModified": {
+        "description": "The client already holds the current document",
+        "headers": {
+          "ETag": {"$ref": "#/components/headers/ETag"},
+          "Cache-Control": {"$ref": "#/components/headers/CacheControl"}
+        }
+      },
+      "PolicyNotApplied": {
+        "description": "The policy does not apply",
+        "headers": {
+          "Content-Language": {"$ref": "#/components/headers/ContentLanguage"}
+        }
+      },
+      "MethodNotAllowed": {
+        "description": "The route does not support the method",
+        "headers": {
+          "Allow": {"$ref": "#/components/headers/Allow"}
+        },
+        "content": {
+          "text/plain": {"schema": {"$ref": "#/components/schemas/Error"}}
+        }
+      },
+      "Unauthorized": {
+        "description": "The credentials are missing or rejected by the AUTH_MODE of the service",
+        "headers": {
+          "WWW-Authenticate": {"$ref": "#/components/headers/WWWAuthenticate"}
+        },
+        "content": {
+          "text/plain": {"schema": {"$ref": "#/components/schemas/Error"}}
+        }
+      },
+      "TooManyRequests": {
+        "description": "The client has exceeded the rate limit of its tier",
+        "headers": {
+          "Retry-After": {"$ref": "#/components/headers/RetryAfter"},
+          "RateLimit-Limit": {"$ref": "#/components/headers/RateLimitLimit"},
+          "RateLimit-Remaining": {"$ref": "#/components/headers/RateLimitRemaining"},
+          "RateLimit-Reset": {"$ref": "#/components/headers/RateLimitReset"}
+        },
+        "content": {
+          "text/plain": {"schema": {"$ref": "#/components/schemas/Error"}}
+        }
+      },
+      "Error": {
+        "description": "The status text, followed by a detail such as the invalid airport codes and by the request ID when one was assigned",
+        "content": {
+          "text/plain": {"schema": {"$ref": "#/components/schemas/Error"}}
+        }
+      },
+      "HealthReport": {
+        "description": "The health report",
+        "content": {
+          "application/json": {"schema": {"$ref": "#/components/schemas/HealthReport"}}
+        }
+      }
+    },
+    "securitySchemes": {
+      "APIKey": {
+        "type": "apiKey",
+        "in": "header",
+        "name": "X-Api-Key",
+        "description": "Static API key, accepted when AUTH_MODE includes apikey"
+      },
+      "BearerJWT": {
+        "type": "http",
+        "scheme": "bearer",
+        "bearerFormat": "JWT",
+        "description": "RS256 or ES256 token of the configured issuer and audience, accepted when AUTH_MODE includes jwt"
+      }
+    },
+    "schemas": {
+      "AdminLocaleList": {
+        "type": "object",
+        "required": ["id", "version", "locales", "drafts"],
+        "properties": {
+          "id": {"type": "string", "example": "hazardousgoods"},
+          "version": {"type": "string", "example": "1.0.0"},
+          "locales": {
+            "type": "array",
+            "items": {"$ref": "#/components/schemas/AdminLocale"}
+          },
+          "drafts": {
+            "type": "array",
+            "description": "Drafts awaiting publication, including those of locales not yet published",
+            "items": {"$ref": "#/components/schemas/AdminLocale"}
+          }
+        }
+      },
+      "AdminLocale": {
+        "type": "object",
+        "required": ["locale", "digest"],
+        "properties": {
+          "locale": {"type": "string", "example": "en-US"},
+          "digest": {"type": "string", "example": "3f7c2a9d1b0e4c58"}
+        }
+      },
+      "PolicyList": {
+        "type": "object",
+        "required": ["policies"],
+        "properties": {
+          "policies": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "required": ["id", "version", "locales"],
+              "properties": {
+                "id": {"type": "string", "example": "hazardousgoods"},
+                "version": {"type": "string", "example": "1.0.0"},
+                "locales": {"type": "array", "items": {"type": "string", "example": "en-US"}}
+              }
+            }
+          }
+        }
+      },
+      "TravelDate": {
+        "type": "string",
+        "description": "Selects the version of the policy in force at the date, taken at the start of the day in UTC, or at an RFC 3339 time; the time of the request when absent",
+        "example": "2027-01-15"
+      },
+      "PolicyVersionList": {
+        "type": "object",
+        "required": ["id", "versions"],
+        "properties": {
+          "id": {"type": "string", "example": "hazardousgoods"},
+          "versions": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "required": ["version", "locales"],
+              "properties": {
+                "version": {"type": "string", "example": "2.0.0"},
+                "effectiveFrom": {"type": "string", "format": "date-time", "description": "Absent for the version of the data folder, in force unless a scheduled version is"},
+                "effectiveUntil": {"type": "string", "format": "date-time"},
+                "locales": {"type": "array", "items": {"type": "string", "example": "en-US"}}
+              }
+            }
+          }
+        }
+      },
+      "AirportCodes": {
+        "type": "array",
+        "items": {"type": "string", "example": "SEA"}
+      },
+      "EvaluateV2Request": {
+        "type": "object",
+        "additionalProperties": false,
+        "properties": {
+          "airportCodes": {"$ref": "#/components/schemas/AirportCodes"},
+          "travelDate": {"$ref": "#/components/schemas/TravelDate"}
+        }
+      },
+      "EvaluateV2Response": {
+        "type": "object",
+        "required": ["policyApplied", "locale"],
+        "properties": {
+          "policyApplied": {"type": "boolean"},
+          "locale": {"type": "string"},
+          "policyVersion": {"type": "string", "description": "Version of the policy, when it applies", "example": "1.0.0"},
+          "policy": {"$ref": "#/components/schemas/HazardousGoodsPolicy"}
+        }
+      },
+      "EvaluateBatchRequest": {
+        "type": "object",
+        "required": ["itineraries"],
+        "additionalProperties": false,
+        "properties": {
+          "itineraries": {
+            "type": "array",
+            "description": "At most BATCH_MAX_ITINERARIES (default 100)",
+            "items": {
+              "type": "object",
+              "required": ["airportCodes"],
+              "additionalProperties": false,
+              "properties": {
+                "id": {"type": "string", "example": "itinerary-1"},
+                "airportCodes": {"$ref": "#/components/schemas/AirportCodes"},
+                "travelDate": {"$ref": "#/components/schemas/TravelDate"}
+              }
+            }
+          }
+        }
+      },
+      "EvaluateBatchResponse": {
+        "type": "object",
+        "required": ["locale", "results"],
+        "properties": {
+          "locale": {"type": "string"},
+          "results": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "required": ["status", "policyApplied"],
+              "properties": {
+                "id": {"type": "string"},
+                "status": {"type": "integer", "enum": [200, 400, 500, 503]},
+                "policyApplied": {"type": "boolean"},
+                "policyVersion": {"type": "string", "description": "Version of the policy, when it applies", "example": "1.0.0"},
+                "error": {"type": "string", "example": "invalid airport codes \"XX\""}
+              }
+            }
+          },
+          "policy": {"$ref": "#/components/schemas/HazardousGoodsPolicy"},
+          "policies": {
+            "type": "object",
+            "description": "Other versions of the policy selected by travel dates, by version",
+            "additionalProperties": {"$ref": "#/components/schemas/HazardousGoodsPolicy"}
+          }
+        }
+      },
+      "HazardousGoodsPolicy": {
+        "type": "array",
+        "items": {
+          "type": "object",
+          "required": ["code", "alert", "title", "body"],
+          "properties": {
+            "code": {"type": "string", "example": "US"},
+            "alert": {"type": "string"},
+            "title": {"type": "string"},
+            "body": {"type": "array", "items": {"type": "string"}}
+          }
+        }
+      },
+      "Error": {
+        "type": "string",
+        "pattern": "^[A-Z][A-Za-z ]+(: .+?)?( \\(Request ID: [!-~]+\\))?\\n$",
+        "example": "Service Unavailable (Request ID: 4bf92f3577b34da6a3ce929d0e0e4736)\n"
+      },
+      "HealthReport": {
+        "type": "object",
+        "required": ["status", "checks", "version"],
+        "properties": {
+          "status": {"$ref": "#/components/schemas/HealthStatus"},
+          "version": {"type": "string"},
+          "checks": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "required": ["name", "status", "latencyMs"],
+              "properties": {
+                "name": {"type": "string"},
+                "status": {"$ref": "#/components/schemas/HealthStatus"},
+                "latencyMs": {"type": "number"},
+                "error": {"type": "string"}
+              }
+            }
+          }
+        }
+      },
+      "HealthStatus": {
+        "type": "string",
+        "enum": ["UP", "DOWN"]
+      },
+      "Info": {
+        "type": "object",
+        "required": ["build", "policyData"],
+        "properties": {
+          "build": {
+            "type": "object",
+            "required": ["version", "dirty", "goVersion", "platform", "dependencies"],
+            "properties": {
+              "version": {"type": "string"},
+              "revision": {"type": "string"},
+              "dirty": {"type": "boolean"},
+              "buildTime": {"type": "string"},
+              "goVersion": {"type": "string"},
+              "platform": {"type": "string"},
+              "dependencies": {
+                "type": "array",
+                "items": {
+                  "type": "object",
+                  "required": ["path", "version"],
+                  "properties": {
+                    "path": {"type": "string"},
+                    "version": {"type": "string"},
+                    "replace": {"type": "string"}
+                  }
+                }
+              }
+            }
+          },
+          "policyData": {
+            "type": "object",
+            "required": ["version", "checksum", "locales"],
+            "properties": {
+              "version": {"type": "string"},
+              "checksum": {"type": "string"},
+              "locales": {"type": "array", "items": {"type": "string"}}
+            }
+          }
+        }
+      }
+    }
+  }
+}`
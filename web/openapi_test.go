@@ -0,0 +1,259 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dukeluke16/sample-golang-webservice/router"
+)
+
+// exchange of a real handler response recorded by the test helpers
+type exchange struct {
+	method string
+	route  string
+	w      *httptest.ResponseRecorder
+}
+
+// exchanges recorded across the test suite and validated against the OpenAPI document by TestMain
+var exchanges []exchange
+
+func recordExchange(method string, route string, w *httptest.ResponseRecorder) {
+	exchanges = append(exchanges, exchange{method: method, route: route, w: w})
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if code == 0 {
+		for _, err := range validateExchanges(exchanges) {
+			fmt.Fprintln(os.Stderr, "OpenAPI drift:", err)
+			code = 1
+		}
+	}
+
+	os.Exit(code)
+}
+
+func openAPISpec() map[string]interface{} {
+	var document map[string]interface{}
+	if err := json.Unmarshal([]byte(openAPIDocument), &document); err != nil {
+		panic(err)
+	}
+
+	return document
+}
+
+func TestOpenAPIResponse(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, OpenAPIPath, nil)
+	w := httptest.NewRecorder()
+	OpenAPIGetHandler(w, r)
+	recordExchange(http.MethodGet, OpenAPIPath, w)
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &document); err != nil {
+		t.Fatalf("Parsing error: %v", err)
+	}
+
+	if document["openapi"] != "3.0.3" || document["info"].(map[string]interface{})["version"] != "0.0.dev" {
+		t.Errorf("handler returned unexpected document: got %v %v", document["openapi"], document["info"])
+	}
+}
+
+func TestOpenAPIDescribesEveryRoute(t *testing.T) {
+	paths := openAPISpec()["paths"].(map[string]interface{})
+
+	routes := NewRouter().(*router.Router).Routes()
+	for _, route := range routes {
+		if _, ok := paths[route]; !ok {
+			t.Errorf("OpenAPI document is missing route %v", route)
+		}
+	}
+
+	if len(paths) != len(routes) {
+		t.Errorf("OpenAPI document describes unrouted paths: got %v want %v", len(paths), len(routes))
+	}
+}
+
+func TestOpenAPIValidationDetectsDrift(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.WriteString(`[{"code": "US", "title": "missing alert and body"}]`)
+
+	errs := validateExchanges([]exchange{{method: http.MethodPost, route: EvaluateV1Path, w: w}})
+	if len(errs) == 0 {
+		t.Errorf("OpenAPI validation accepted a response without Content-Language, alert, and body!")
+	}
+
+	w = httptest.NewRecorder()
+	w.WriteHeader(http.StatusTeapot)
+	errs = validateExchanges([]exchange{{method: http.MethodGet, route: HealthPath, w: w}})
+	if len(errs) == 0 {
+		t.Errorf("OpenAPI validation accepted an undocumented status!")
+	}
+}
+
+// validateExchanges against the OpenAPI document, returning one error per mismatch
+func validateExchanges(recorded []exchange) []error {
+	document := openAPISpec()
+	paths := document["paths"].(map[string]interface{})
+
+	var errs []error
+	for _, e := range recorded {
+		pathItem, ok := paths[e.route].(map[string]interface{})
+		if !ok {
+			if e.w.Code != http.StatusNotFound {
+				errs = append(errs, fmt.Errorf("%v %v is not documented", e.method, e.route))
+			}
+			continue
+		}
+
+		operation, ok := pathItem[strings.ToLower(e.method)].(map[string]interface{})
+		if !ok {
+			if e.w.Code != http.StatusMethodNotAllowed && !(e.method == http.MethodOptions && e.w.Code == http.StatusNoContent) {
+				errs = append(errs, fmt.Errorf("%v %v is not documented", e.method, e.route))
+			}
+			continue
+		}
+
+		for _, err := range validateResponse(document, operation, e.w) {
+			errs = append(errs, fmt.Errorf("%v %v %v: %v", e.method, e.route, e.w.Code, err))
+		}
+	}
+
+	return errs
+}
+
+func validateResponse(document map[string]interface{}, operation map[string]interface{}, w *httptest.ResponseRecorder) []error {
+	responses := operation["responses"].(map[string]interface{})
+	response, ok := responses[strconv.Itoa(w.Code)].(map[string]interface{})
+	if !ok {
+		return []error{fmt.Errorf("status is not documented")}
+	}
+	response = resolve(document, response)
+
+	var errs []error
+	headers, _ := response["headers"].(map[string]interface{})
+	for name, header := range headers {
+		if resolve(document, header.(map[string]interface{}))["required"] == true && len(w.Header().Get(name)) == 0 {
+			errs = append(errs, fmt.Errorf("header %v is missing", name))
+		}
+	}
+
+	content, ok := response["content"].(map[string]interface{})
+	if !ok {
+		if w.Body.Len() != 0 {
+			errs = append(errs, fmt.Errorf("body is not documented"))
+		}
+		return errs
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	media, ok := content[mediaType].(map[string]interface{})
+	if !ok {
+		return append(errs, fmt.Errorf("content type %v is not documented", mediaType))
+	}
+
+	var body interface{} = w.Body.String()
+	if mediaType == "application/json" {
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			return append(errs, fmt.Errorf("body is not JSON: %v", err))
+		}
+	}
+
+	return append(errs, validateSchema(document, media["schema"].(map[string]interface{}), body, "body")...)
+}
+
+// validateSchema supports the subset of JSON Schema used by the OpenAPI document
+func validateSchema(document map[string]interface{}, schema map[string]interface{}, value interface{}, path string) []error {
+	schema = resolve(document, schema)
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			found = found || allowed == value
+		}
+		if !found {
+			return []error{fmt.Errorf("%v is %v, not one of %v", path, value, enum)}
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return []error{fmt.Errorf("%v is not an object", path)}
+		}
+
+		var errs []error
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := object[name.(string)]; !ok {
+				errs = append(errs, fmt.Errorf("%v.%v is required", path, name))
+			}
+		}
+
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, property := range properties {
+			if propertyValue, ok := object[name]; ok {
+				errs = append(errs, validateSchema(document, property.(map[string]interface{}), propertyValue, path+"."+name)...)
+			}
+		}
+		return errs
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return []error{fmt.Errorf("%v is not an array", path)}
+		}
+
+		var errs []error
+		for i, item := range array {
+			errs = append(errs, validateSchema(document, schema["items"].(map[string]interface{}), item, fmt.Sprintf("%v[%v]", path, i))...)
+		}
+		return errs
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return []error{fmt.Errorf("%v is not a string", path)}
+		}
+
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(text) {
+			return []error{fmt.Errorf("%v %q does not match %v", path, text, pattern)}
+		}
+		if maxLength, ok := schema["maxLength"].(float64); ok && float64(len(text)) > maxLength {
+			return []error{fmt.Errorf("%v is longer than %v", path, maxLength)}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []error{fmt.Errorf("%v is not a boolean", path)}
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return []error{fmt.Errorf("%v is not a number", path)}
+		}
+	}
+
+	return nil
+}
+
+// resolve a local $ref such as #/components/schemas/Error
+func resolve(document map[string]interface{}, object map[string]interface{}) map[string]interface{} {
+	ref, ok := object["$ref"].(string)
+	if !ok {
+		return object
+	}
+
+	var target interface{} = document
+	for _, name := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		target = target.(map[string]interface{})[name]
+	}
+
+	return resolve(document, target.(map[string]interface{}))
+}
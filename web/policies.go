@@ -0,0 +1,117 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"golang.org/x/text/language"
+
+	"github.com/dukeluke16/sample-golang-webservice/accesslog"
+	"github.com/dukeluke16/sample-golang-webservice/policy"
+	"github.com/dukeluke16/sample-golang-webservice/router"
+)
+
+// PolicyID of the hazardous goods policy
+const PolicyID = "hazardousgoods"
+
+// PoliciesPath for endpoint
+var PoliciesPath = "/v1/policies"
+
+// PolicyPath for endpoint
+var PolicyPath = "/v1/policies/{id}"
+
+// PolicyLocalePath for endpoint
+var PolicyLocalePath = "/v1/policies/{id}/locales/{locale}"
+
+// PolicySummary listing the locales a policy is available in
+type PolicySummary struct {
+	ID      string   `json:"id"`
+	Version string   `json:"version"`
+	Locales []string `json:"locales"`
+}
+
+// PoliciesResponse for the policy list
+type PoliciesResponse struct {
+	Policies []PolicySummary `json:"policies"`
+}
+
+// PoliciesGetHandler for handling routed requests
+func PoliciesGetHandler(w http.ResponseWriter, r *http.Request) {
+	if !policyStore.Loaded() {
+		if err := policyStore.Load(); err != nil {
+			genericStatusResponseError(w, r, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	body, _ := json.Marshal(PoliciesResponse{Policies: []PolicySummary{{
+		ID:      PolicyID,
+		Version: policyStore.Version(),
+		Locales: policyStore.Locales(),
+	}}})
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// PolicyGetHandler for handling routed requests, negotiating the locale like the evaluate routes
+func PolicyGetHandler(w http.ResponseWriter, r *http.Request) {
+	if _, ok := knownPolicy(w, r); !ok {
+		return
+	}
+
+	at, ok := policyTime(w, r)
+	if !ok {
+		return
+	}
+
+	tag, r, ok := negotiateLocale(w, r)
+	if !ok {
+		return
+	}
+
+	hazardousGoodsPolicyResponse(w, r, tag, at)
+}
+
+// PolicyLocaleGetHandler for handling routed requests
+func PolicyLocaleGetHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := knownPolicy(w, r)
+	if !ok {
+		return
+	}
+
+	at, ok := policyTime(w, r)
+	if !ok {
+		return
+	}
+
+	// Canonicalize the locale, e.g. en-us to en-US, to match the data folder names
+	locale := router.Param(r, "locale")
+	if tag, err := language.Parse(locale); err == nil {
+		locale = tag.String()
+	}
+
+	version, err := policyVersionAt(r.Context(), locale, at)
+	if err == policy.ErrNotFound {
+		statusResponseError(w, r, http.StatusNotFound, "no "+locale+" locale of policy "+id)
+		return
+	}
+	if err != nil {
+		genericStatusResponseError(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	accesslog.FromContext(r.Context()).Locale = locale
+	writePolicyDocument(w, withLogFields(r, "locale", locale), locale, version)
+}
+
+// knownPolicy responds Not Found unless the id path parameter names a policy
+func knownPolicy(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := router.Param(r, "id")
+	if id != PolicyID {
+		statusResponseError(w, r, http.StatusNotFound, "unknown policy "+id)
+		return id, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,155 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/language"
+
+	"github.com/dukeluke16/sample-golang-webservice/compression"
+)
+
+func servePolicyLocale(path string, ifNoneMatch string) *httptest.ResponseRecorder {
+	r, _ := http.NewRequest(http.MethodGet, path, nil)
+	if len(ifNoneMatch) != 0 {
+		r.Header.Set("If-None-Match", ifNoneMatch)
+	}
+
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, r)
+	recordExchange(http.MethodGet, PolicyLocalePath, w)
+
+	return w
+}
+
+func TestPolicyLocaleGetHandler(t *testing.T) {
+	w := servePolicyLocale("/v1/policies/hazardousgoods/locales/fr-ca", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+	}
+
+	expected, _ := policyStore.Document("fr-CA")
+	if w.Body.String() != string(expected) {
+		t.Errorf("handler returned unexpected body: got %v want %v", w.Body.String(), string(expected))
+	}
+
+	if language := w.Header().Get("Content-Language"); language != "fr-CA" {
+		t.Errorf("handler returned wrong Content-Language: got %v want %v", language, "fr-CA")
+	}
+
+	if etag := w.Header().Get("ETag"); etag != currentPolicyETag("fr-CA", compression.EncodingIdentity) || !strings.HasPrefix(etag, `"fr-CA-1.0.0-`) {
+		t.Errorf("handler returned wrong ETag: got %v", etag)
+	}
+
+	if cacheControl := w.Header().Get("Cache-Control"); cacheControl != defaultPolicyCacheControl {
+		t.Errorf("handler returned wrong Cache-Control: got %v want %v", cacheControl, defaultPolicyCacheControl)
+	}
+}
+
+func TestPolicyLocaleGetHandlerNotModified(t *testing.T) {
+	policyStore.Document("en-US")
+	etag := currentPolicyETag("en-US", compression.EncodingIdentity)
+	for _, ifNoneMatch := range []string{etag, `"other", ` + etag, "W/" + etag, "*"} {
+		w := servePolicyLocale("/v1/policies/hazardousgoods/locales/en-US", ifNoneMatch)
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("handler did not answer Not Modified for %v: got %v", ifNoneMatch, w.Code)
+		}
+
+		if w.Header().Get("ETag") != etag {
+			t.Errorf("handler returned wrong ETag: got %v want %v", w.Header().Get("ETag"), etag)
+		}
+	}
+
+	if w := servePolicyLocale("/v1/policies/hazardousgoods/locales/en-US", currentPolicyETag("fr", compression.EncodingIdentity)); w.Code != http.StatusOK {
+		t.Errorf("handler answered Not Modified for another locale: got %v", w.Code)
+	}
+}
+
+func TestPolicyLocaleGetHandlerNotFound(t *testing.T) {
+	expected := map[string]string{
+		"/v1/policies/baggage/locales/en-US":       "Not Found: unknown policy baggage",
+		"/v1/policies/hazardousgoods/locales/tlh":  "Not Found: no tlh locale of policy hazardousgoods",
+		"/v1/policies/hazardousgoods/locales/%21!": "Not Found: no !! locale of policy hazardousgoods",
+	}
+	for path, body := range expected {
+		w := servePolicyLocale(path, "")
+		if w.Code != http.StatusNotFound || !strings.HasPrefix(w.Body.String(), body) {
+			t.Errorf("handler returned unexpected response for %v: got %v %v want %v", path, w.Code, w.Body.String(), body)
+		}
+	}
+}
+
+func servePolicy(method string, route string, path string, acceptLanguage string) *httptest.ResponseRecorder {
+	r, _ := http.NewRequest(method, path, nil)
+	if len(acceptLanguage) != 0 {
+		r.Header.Set("Accept-Language", acceptLanguage)
+	}
+
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, r)
+	recordExchange(method, route, w)
+
+	return w
+}
+
+func TestPoliciesGetHandler(t *testing.T) {
+	w := servePolicy(http.MethodGet, PoliciesPath, PoliciesPath, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+	}
+
+	var response PoliciesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Parsing error: %v", err)
+	}
+
+	if len(response.Policies) != 1 || response.Policies[0].ID != PolicyID || response.Policies[0].Version != "1.0.0" {
+		t.Fatalf("handler returned unexpected policies: got %+v", response.Policies)
+	}
+
+	if locales := response.Policies[0].Locales; len(locales) != 33 || locales[0] != "bg" {
+		t.Errorf("handler returned unexpected locales: got %v", locales)
+	}
+}
+
+func TestPolicyGetHandlerNegotiatesLocale(t *testing.T) {
+	expected := map[string]string{
+		"":                "en-US",
+		"fr-CA, fr;q=0.8": "fr-CA",
+		"de-AT":           "de",
+		"pt-BR":           "pt-BR",
+	}
+	for acceptLanguage, locale := range expected {
+		w := servePolicy(http.MethodGet, PolicyPath, "/v1/policies/hazardousgoods", acceptLanguage)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Language") != locale {
+			t.Errorf("handler negotiated wrong locale for %q: got %v %v want %v", acceptLanguage, w.Code, w.Header().Get("Content-Language"), locale)
+			continue
+		}
+
+		document, _ := policyStore.Document(locale)
+		if w.Body.String() != string(document) {
+			t.Errorf("handler returned the wrong document for %v", locale)
+		}
+
+		if w.Header().Get("Vary") != policyVary || w.Header().Get("ETag") != currentPolicyETag(locale, compression.EncodingIdentity) {
+			t.Errorf("handler returned wrong caching headers: got %v", w.Header())
+		}
+	}
+}
+
+func TestPolicyGetHandlerErrors(t *testing.T) {
+	if w := servePolicy(http.MethodGet, PolicyPath, "/v1/policies/hazardousgoods", language.Und.String()); w.Code != http.StatusNotAcceptable {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusNotAcceptable)
+	}
+
+	if w := servePolicy(http.MethodGet, PolicyPath, "/v1/policies/baggage", ""); w.Code != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusNotFound)
+	}
+
+	if w := servePolicy(http.MethodPost, PolicyPath, "/v1/policies/hazardousgoods", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,161 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/dukeluke16/sample-golang-webservice/policy"
+)
+
+// PolicyVersionsPath for endpoint
+var PolicyVersionsPath = "/v1/policies/{id}/versions"
+
+// PolicyVersionHeader naming the version of the policy document in the response
+const PolicyVersionHeader = "Policy-Version"
+
+// policyClock for mocking the time the version in force is selected at
+var policyClock = time.Now
+
+// PolicyVersionSummary of a version and the locales it is available in
+type PolicyVersionSummary struct {
+	Version string `json:"version"`
+	// EffectiveFrom absent for the version of the locale folders, which is in force unless a scheduled version is
+	EffectiveFrom  *time.Time `json:"effectiveFrom,omitempty"`
+	EffectiveUntil *time.Time `json:"effectiveUntil,omitempty"`
+	Locales        []string   `json:"locales"`
+}
+
+// PolicyVersionsResponse for the version list
+type PolicyVersionsResponse struct {
+	ID       string                 `json:"id"`
+	Versions []PolicyVersionSummary `json:"versions"`
+}
+
+// maxAgePattern of the max-age directive of Cache-Control
+var maxAgePattern = regexp.MustCompile(`max-age=\d+`)
+
+// PolicyVersionsGetHandler for handling routed requests, listing every version with its window so clients can preview scheduled ones
+func PolicyVersionsGetHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := knownPolicy(w, r)
+	if !ok {
+		return
+	}
+
+	if !policyStore.Loaded() {
+		if err := policyStore.Load(); err != nil {
+			genericStatusResponseError(w, r, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// A version is listed once for all the locales it has the same window in
+	response := PolicyVersionsResponse{ID: id, Versions: []PolicyVersionSummary{}}
+	index := map[string]int{}
+	for _, locale := range policyStore.Locales() {
+		for _, version := range policyStore.Versions(locale) {
+			key := version.Version + "|" + version.EffectiveFrom.String() + "|" + version.EffectiveUntil.String()
+			i, found := index[key]
+			if !found {
+				i = len(response.Versions)
+				index[key] = i
+				response.Versions = append(response.Versions, PolicyVersionSummary{
+					Version:        version.Version,
+					EffectiveFrom:  optionalTime(version.EffectiveFrom),
+					EffectiveUntil: optionalTime(version.EffectiveUntil),
+					Locales:        []string{},
+				})
+			}
+			response.Versions[i].Locales = append(response.Versions[i].Locales, locale)
+		}
+	}
+
+	body, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// policyTime of the at query parameter previewing the version in force then, otherwise now; responds Bad Request when malformed
+func policyTime(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	at := r.URL.Query().Get("at")
+	if len(at) == 0 {
+		return policyClock(), true
+	}
+
+	t, err := parsePolicyTime(at)
+	if err != nil {
+		statusResponseError(w, r, http.StatusBadRequest, "invalid at "+strconv.Quote(at)+", expected a date such as 2027-01-15 or an RFC 3339 time")
+		return t, false
+	}
+
+	return t, true
+}
+
+// travelTime of the travel date of an itinerary, otherwise now; responds Bad Request when malformed
+func travelTime(w http.ResponseWriter, r *http.Request, travelDate string) (time.Time, bool) {
+	t, detail := checkTravelDate(travelDate)
+	if len(detail) != 0 {
+		statusResponseError(w, r, http.StatusBadRequest, detail)
+		return t, false
+	}
+
+	return t, true
+}
+
+// checkTravelDate returning the time to select the policy version at, otherwise the detail of why the date is invalid
+func checkTravelDate(travelDate string) (time.Time, string) {
+	if len(travelDate) == 0 {
+		return policyClock(), ""
+	}
+
+	t, err := parsePolicyTime(travelDate)
+	if err != nil {
+		return t, "invalid travelDate " + strconv.Quote(travelDate) + ", expected a date such as 2027-01-15 or an RFC 3339 time"
+	}
+
+	return t, ""
+}
+
+// parsePolicyTime of a date, e.g. a travel date of 2027-01-15 taken at the start of the day in UTC, or an RFC 3339 time
+func parsePolicyTime(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// cacheControlUntil caps the max-age of cacheControl so caches revalidate once the next version may come into force
+func cacheControlUntil(cacheControl string, next time.Time) string {
+	if next.IsZero() {
+		return cacheControl
+	}
+
+	remaining := int(next.Sub(policyClock()).Seconds())
+	if remaining < 0 {
+		remaining = 0
+	}
+	return maxAgePattern.ReplaceAllStringFunc(cacheControl, func(directive string) string {
+		if maxAge, _ := strconv.Atoi(directive[len("max-age="):]); maxAge > remaining {
+			return "max-age=" + strconv.Itoa(remaining)
+		}
+		return directive
+	})
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+// policyVersionMetadata of the version in the response headers
+func policyVersionMetadata(w http.ResponseWriter, version policy.Version) {
+	if len(version.Version) != 0 {
+		w.Header().Set(PolicyVersionHeader, version.Version)
+	}
+}
@@ -0,0 +1,205 @@
+package web
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dukeluke16/sample-golang-webservice/circuit"
+	"github.com/dukeluke16/sample-golang-webservice/policy"
+)
+
+// versionEffective when the scheduled 2.0.0 version of the en-US document comes into force
+var versionEffective = time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// setupPolicyVersions with 2.0.0 of the en-US document scheduled half an hour from the clock
+func setupPolicyVersions(t *testing.T) (restore func()) {
+	folder, _ := ioutil.TempDir("", "versions")
+	for _, locale := range []string{"en-US", "fr"} {
+		document, _ := ioutil.ReadFile(filepath.Join("../data", locale, policy.DocumentName))
+		os.Mkdir(filepath.Join(folder, locale), 0755)
+		writeAuthFile(t, filepath.Join(folder, locale), policy.DocumentName, string(document))
+	}
+	writeAuthFile(t, folder, policy.VersionName, "1.0.0\n")
+
+	document, _ := ioutil.ReadFile(filepath.Join("../data", "en-US", policy.DocumentName))
+	scheduled := strings.Replace(string(document), "Hazardous Materials Restrictions", "Dangerous Goods Restrictions", 1)
+	os.MkdirAll(filepath.Join(folder, "en-US", policy.VersionsFolder), 0755)
+	writeAuthFile(t, filepath.Join(folder, "en-US", policy.VersionsFolder), "2.0.0.json",
+		`{"version": "2.0.0", "effectiveFrom": "2027-01-01T00:00:00Z", "document": `+scheduled+`}`)
+
+	originalStore, originalClock := policyStore, policyClock
+	policyStore = policy.NewStore(folder)
+	policyClock = func() time.Time { return versionEffective.Add(-30 * time.Minute) }
+
+	return func() {
+		policyStore, policyClock = originalStore, originalClock
+		os.RemoveAll(folder)
+	}
+}
+
+func TestPolicyLocaleVersions(t *testing.T) {
+	restore := setupPolicyVersions(t)
+	defer restore()
+
+	w := servePolicy(http.MethodGet, PolicyLocalePath, "/v1/policies/hazardousgoods/locales/en-US", "")
+	if w.Code != http.StatusOK || w.Header().Get(PolicyVersionHeader) != "1.0.0" || strings.Contains(w.Body.String(), "Dangerous Goods") {
+		t.Errorf("handler returned wrong version: got %v %v", w.Code, w.Header().Get(PolicyVersionHeader))
+	}
+
+	if cacheControl := w.Header().Get("Cache-Control"); cacheControl != "public, max-age=1800" {
+		t.Errorf("handler returned wrong Cache-Control: got %v want %v", cacheControl, "public, max-age=1800")
+	}
+
+	w = servePolicy(http.MethodGet, PolicyLocalePath, "/v1/policies/hazardousgoods/locales/en-US?at=2027-01-15", "")
+	if w.Code != http.StatusOK || w.Header().Get(PolicyVersionHeader) != "2.0.0" || !strings.Contains(w.Body.String(), "Dangerous Goods") {
+		t.Errorf("handler returned wrong preview: got %v %v", w.Code, w.Header().Get(PolicyVersionHeader))
+	}
+
+	w = servePolicy(http.MethodGet, PolicyLocalePath, "/v1/policies/hazardousgoods/locales/fr?at=2027-01-15", "")
+	if w.Header().Get(PolicyVersionHeader) != "1.0.0" || w.Header().Get("Cache-Control") != defaultPolicyCacheControl {
+		t.Errorf("handler returned wrong version without a scheduled one: got %v", w.Header())
+	}
+
+	w = servePolicy(http.MethodGet, PolicyPath, "/v1/policies/hazardousgoods?at=tomorrow", "en-US")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestPolicyVersionsGetHandler(t *testing.T) {
+	restore := setupPolicyVersions(t)
+	defer restore()
+
+	w := servePolicy(http.MethodGet, PolicyVersionsPath, "/v1/policies/hazardousgoods/versions", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+	}
+
+	var response PolicyVersionsResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.Versions) != 2 {
+		t.Fatalf("handler returned wrong versions: got %v", w.Body.String())
+	}
+
+	current, scheduled := response.Versions[0], response.Versions[1]
+	if current.Version != "1.0.0" || current.EffectiveFrom != nil || strings.Join(current.Locales, ",") != "en-US,fr" {
+		t.Errorf("handler returned wrong current version: got %+v", current)
+	}
+	if scheduled.Version != "2.0.0" || !scheduled.EffectiveFrom.Equal(versionEffective) || scheduled.EffectiveUntil != nil || strings.Join(scheduled.Locales, ",") != "en-US" {
+		t.Errorf("handler returned wrong scheduled version: got %+v", scheduled)
+	}
+
+	w = servePolicy(http.MethodGet, PolicyVersionsPath, "/v1/policies/unknown/versions", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestEvaluateV2TravelDate(t *testing.T) {
+	restore := setupPolicyVersions(t)
+	defer restore()
+	ts := setupFakeServerUSA()
+	defer ts.Close()
+
+	w := setupV2RequestAndServe(strings.NewReader(`{"airportCodes": ["sea"], "travelDate": "2027-02-01"}`), nil)
+	response := decodeV2Response(t, w)
+	if response.PolicyVersion != "2.0.0" || w.Header().Get(PolicyVersionHeader) != "2.0.0" || !strings.Contains(string(response.Policy), "Dangerous Goods") {
+		t.Errorf("handler returned wrong version for the travel date: got %v", w.Body.String())
+	}
+
+	w = setupV2RequestAndServe(strings.NewReader(`{"airportCodes": ["sea"]}`), nil)
+	if response := decodeV2Response(t, w); response.PolicyVersion != "1.0.0" {
+		t.Errorf("handler returned wrong version without a travel date: got %v", response.PolicyVersion)
+	}
+
+	w = setupV2RequestAndServe(strings.NewReader(`{"airportCodes": ["sea"], "travelDate": "01/02/2027"}`), nil)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid travelDate") {
+		t.Errorf("handler returned wrong response: got %v %v", w.Code, w.Body.String())
+	}
+}
+
+func TestEvaluateBatchTravelDates(t *testing.T) {
+	restore := setupPolicyVersions(t)
+	defer restore()
+	defer func(original *circuit.Breaker) { locationServicesBreaker = original }(locationServicesBreaker)
+	locationServicesBreaker = circuit.NewBreaker(100, time.Minute)
+
+	ts := setupCountryServer(map[string]string{"SEA": "US", "LHR": "GB"}, map[string]int{})
+	defer ts.Close()
+	defer resetServiceEndpoint()
+
+	w := serveBatch(`{"itineraries": [
+		{"id": "a", "airportCodes": ["LHR", "SEA"]},
+		{"id": "b", "airportCodes": ["LHR", "SEA"], "travelDate": "2027-02-01"},
+		{"id": "c", "airportCodes": ["LHR", "SEA"], "travelDate": "2026-12-01"},
+		{"id": "d", "airportCodes": ["LHR", "SEA"], "travelDate": "soon"}
+	]}`, "en-US")
+
+	var response EvaluateBatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || len(response.Results) != 4 {
+		t.Fatalf("handler returned wrong response: got %v %v", w.Code, w.Body.String())
+	}
+
+	for i, version := range []string{"1.0.0", "2.0.0", "1.0.0"} {
+		if result := response.Results[i]; result.Status != http.StatusOK || result.PolicyVersion != version {
+			t.Errorf("handler returned wrong result %v: got %+v want version %v", i, result, version)
+		}
+	}
+	if result := response.Results[3]; result.Status != http.StatusBadRequest || !strings.Contains(result.Error, "invalid travelDate") {
+		t.Errorf("handler returned wrong result for an invalid travel date: got %+v", result)
+	}
+
+	if strings.Contains(string(response.Policy), "Dangerous Goods") || len(response.Policies) != 1 || !strings.Contains(string(response.Policies["2.0.0"]), "Dangerous Goods") {
+		t.Errorf("handler returned wrong policies: got %v", w.Body.String())
+	}
+}
+
+func TestParsePolicyTime(t *testing.T) {
+	expected := map[string]time.Time{
+		"2027-01-15":                time.Date(2027, 1, 15, 0, 0, 0, 0, time.UTC),
+		"2027-01-15T08:30:00Z":      time.Date(2027, 1, 15, 8, 30, 0, 0, time.UTC),
+		"2027-01-15T08:30:00+01:00": time.Date(2027, 1, 15, 7, 30, 0, 0, time.UTC),
+	}
+	for value, at := range expected {
+		if actual, err := parsePolicyTime(value); err != nil || !actual.Equal(at) {
+			t.Errorf("parsePolicyTime returned wrong time for %v: got %v %v want %v", value, actual, err, at)
+		}
+	}
+
+	for _, value := range []string{"", "tomorrow", "2027-13-01", "15/01/2027"} {
+		if _, err := parsePolicyTime(value); err == nil {
+			t.Errorf("parsePolicyTime accepted %q", value)
+		}
+	}
+}
+
+func TestCacheControlUntil(t *testing.T) {
+	defer func(original func() time.Time) { policyClock = original }(policyClock)
+	policyClock = func() time.Time { return versionEffective }
+
+	expected := map[time.Duration]string{
+		0:                "public, max-age=3600",
+		time.Minute:      "public, max-age=60",
+		2 * time.Hour:    "public, max-age=3600",
+		-5 * time.Minute: "public, max-age=0",
+	}
+	for offset, cacheControl := range expected {
+		next := versionEffective.Add(offset)
+		if offset == 0 {
+			next = time.Time{}
+		}
+		if actual := cacheControlUntil("public, max-age=3600", next); actual != cacheControl {
+			t.Errorf("cacheControlUntil returned wrong Cache-Control for %v: got %v want %v", offset, actual, cacheControl)
+		}
+	}
+
+	if actual := cacheControlUntil("no-cache", versionEffective.Add(time.Minute)); actual != "no-cache" {
+		t.Errorf("cacheControlUntil changed Cache-Control without max-age: got %v", actual)
+	}
+}
@@ -0,0 +1,88 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dukeluke16/sample-golang-webservice/auth"
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/policy"
+)
+
+// PolicyPreviewHeader requesting the drafts of policy documents in place of the documents in force
+const PolicyPreviewHeader = "Policy-Preview"
+
+type previewKey struct{}
+
+// withPreview serves drafts to requests sending Policy-Preview: draft once the client is authenticated and listed in PREVIEW_CLIENTS or ADMIN_CLIENTS
+func withPreview(handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		preview := r.Header.Get(PolicyPreviewHeader)
+		if len(preview) == 0 {
+			handler(w, r)
+			return
+		}
+
+		if preview != policy.DraftVersion {
+			statusResponseError(w, r, http.StatusBadRequest, "invalid "+PolicyPreviewHeader+" "+strconv.Quote(preview)+", expected draft")
+			return
+		}
+
+		authorized := func(w http.ResponseWriter, r *http.Request) {
+			// Without AUTH_MODE there is no identity, and drafts stay invisible
+			identity, ok := auth.FromContext(r.Context())
+			if !ok {
+				statusResponseError(w, r, http.StatusForbidden, "previewing drafts requires authentication")
+				return
+			}
+
+			if !previewClient(identity.Subject) {
+				statusResponseError(w, r, http.StatusForbidden, "client "+identity.Subject+" may not preview drafts")
+				return
+			}
+
+			r = withLogFields(r.WithContext(context.WithValue(r.Context(), previewKey{}, true)), "preview", preview)
+			handler(w, r)
+		}
+
+		// The evaluate routes have already authenticated the client; the policy routes only do so to preview
+		if _, ok := auth.FromContext(r.Context()); ok {
+			authorized(w, r)
+			return
+		}
+		withAuthentication(authorized)(w, r)
+	}
+}
+
+// previewClient reports whether the client may preview drafts
+func previewClient(subject string) bool {
+	for _, key := range []string{config.PreviewClientsKey, config.AdminClientsKey} {
+		for _, client := range config.ListValue(key) {
+			if client == subject {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// previewing reports whether the request may be served drafts
+func previewing(ctx context.Context) bool {
+	preview, _ := ctx.Value(previewKey{}).(bool)
+	return preview
+}
+
+// policyVersionAt of the document for the locale in force at t, or its draft when previewing
+func policyVersionAt(ctx context.Context, locale string, t time.Time) (policy.Version, error) {
+	if previewing(ctx) {
+		draft, err := policyStore.Draft(locale)
+		if err != policy.ErrNotFound {
+			return draft, err
+		}
+	}
+
+	return policyStore.At(locale, t)
+}
@@ -0,0 +1,166 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/policy"
+)
+
+// setupPreview with drafts of the fr and de documents, whose titles are prefixed with Draft
+func setupPreview(t *testing.T) (restore func()) {
+	_, restore = setupAdmin(t)
+
+	for _, locale := range []string{"fr", "de"} {
+		document := `[{"code": "US", "alert": "Alerte", "title": "Draft ` + locale + `", "body": ["Paragraphe un", "Paragraphe deux"]}]`
+		if err := policyStore.PutDraft(locale, []byte(document), func(previous []byte) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return restore
+}
+
+func servePreview(method string, path string, route string, apiKey string, body string, tag string, preview string) *httptest.ResponseRecorder {
+	r, _ := http.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if len(apiKey) != 0 {
+		r.Header.Set(APIKeyHeader, apiKey)
+	}
+	if len(tag) != 0 {
+		r.Header.Set("Accept-Language", tag)
+	}
+	if len(preview) != 0 {
+		r.Header.Set(PolicyPreviewHeader, preview)
+	}
+
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, r)
+	recordExchange(method, route, w)
+
+	return w
+}
+
+func TestPreview_French(t *testing.T) {
+	tag := "fr"
+	parsedTag := "fr"
+	expectedTitle := "Draft fr"
+
+	previewTest(t, tag, parsedTag, expectedTitle)
+}
+
+func TestPreview_English_NoDraft(t *testing.T) {
+	tag := "en-US"
+	parsedTag := "en-US"
+	expectedTitle := "Hazardous Materials Restrictions"
+
+	previewTest(t, tag, parsedTag, expectedTitle)
+}
+
+func previewTest(t *testing.T, tag string, parsedTag string, expectedTitle string) {
+	// The fake server resets the environment, so it is set up first
+	ts := setupFakeServerUSA()
+	defer ts.Close()
+	restore := setupPreview(t)
+	defer restore()
+
+	w := servePreview(http.MethodPost, EvaluateV1Path, EvaluateV1Path, "editor-secret", `["sea"]`, tag, "draft")
+	if w.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %v", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	if w.Header().Get("Content-Language") != parsedTag {
+		t.Errorf("handler returned wrong Content-Language: got %v want %v", w.Header().Get("Content-Language"), parsedTag)
+	}
+
+	parsedData := parseResponse(t, w)
+	if len(parsedData) != 1 || parsedData[0].Title != expectedTitle {
+		t.Errorf("handler returned unexpected preview: got %+v want title %v", parsedData, expectedTitle)
+	}
+
+	// The same request without the preview header is served the document in force
+	w = servePreview(http.MethodPost, EvaluateV1Path, EvaluateV1Path, "editor-secret", `["sea"]`, tag, "")
+	if parsedData := parseResponse(t, w); len(parsedData) != 1 || strings.HasPrefix(parsedData[0].Title, "Draft") {
+		t.Errorf("handler served a draft without the preview header: got %+v", parsedData)
+	}
+}
+
+func TestPreviewPolicyLocale(t *testing.T) {
+	restore := setupPreview(t)
+	defer restore()
+
+	w := servePreview(http.MethodGet, "/v1/policies/hazardousgoods/locales/fr", PolicyLocalePath, "editor-secret", "", "", "draft")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Draft fr") {
+		t.Fatalf("handler returned wrong preview: got %v %v", w.Code, w.Body.String())
+	}
+
+	if w.Header().Get(PolicyVersionHeader) != policy.DraftVersion || w.Header().Get("Cache-Control") != "no-store" || !strings.HasPrefix(w.Header().Get("ETag"), `"fr-draft-`) {
+		t.Errorf("handler returned wrong headers for a preview: got %v", w.Header())
+	}
+
+	// A draft of a locale that was never published is only visible to previews
+	if w := servePreview(http.MethodGet, "/v1/policies/hazardousgoods/locales/de", PolicyLocalePath, "editor-secret", "", "", "draft"); w.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+	}
+	if w := servePreview(http.MethodGet, "/v1/policies/hazardousgoods/locales/de", PolicyLocalePath, "editor-secret", "", "", ""); w.Code != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusNotFound)
+	}
+
+	w = servePreview(http.MethodGet, "/v1/policies/hazardousgoods/locales/fr", PolicyLocalePath, "", "", "", "")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "Draft fr") || w.Header().Get("Cache-Control") == "no-store" {
+		t.Errorf("handler served a draft to normal traffic: got %v %v", w.Code, w.Header())
+	}
+}
+
+func TestPreviewAuthorization(t *testing.T) {
+	restore := setupPreview(t)
+	defer restore()
+
+	path := "/v1/policies/hazardousgoods"
+	if w := servePreview(http.MethodGet, path, PolicyPath, "", "", "fr", "draft"); w.Code != http.StatusUnauthorized {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusUnauthorized)
+	}
+
+	w := servePreview(http.MethodGet, path, PolicyPath, "booking-secret", "", "fr", "draft")
+	if w.Code != http.StatusForbidden || !strings.HasPrefix(w.Body.String(), "Forbidden: client booking-engine may not preview drafts") {
+		t.Errorf("handler served a preview to a client that may not preview: got %v %v", w.Code, w.Body.String())
+	}
+
+	os.Setenv(config.PreviewClientsKey, "booking-engine")
+	defer os.Unsetenv(config.PreviewClientsKey)
+	if w := servePreview(http.MethodGet, path, PolicyPath, "booking-secret", "", "fr", "draft"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Draft fr") {
+		t.Errorf("handler returned wrong preview for a preview client: got %v %v", w.Code, w.Body.String())
+	}
+
+	if w := servePreview(http.MethodGet, path, PolicyPath, "booking-secret", "", "fr", "published"); w.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusBadRequest)
+	}
+
+	// Without AUTH_MODE drafts stay invisible
+	authenticator = nil
+	if w := servePreview(http.MethodGet, path, PolicyPath, "", "", "fr", "draft"); w.Code != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestPreviewEvaluateV2(t *testing.T) {
+	// The fake server resets the environment, so it is set up first
+	ts := setupFakeServerUSA()
+	defer ts.Close()
+	restore := setupPreview(t)
+	defer restore()
+
+	w := servePreview(http.MethodPost, EvaluateV2Path, EvaluateV2Path, "editor-secret", `{"airportCodes": ["sea"], "travelDate": "2027-01-15"}`, "fr", "draft")
+	response := decodeV2Response(t, w)
+	if response.PolicyVersion != policy.DraftVersion || !strings.Contains(string(response.Policy), "Draft fr") {
+		t.Errorf("handler returned wrong preview: got %v", w.Body.String())
+	}
+
+	if w := servePreview(http.MethodPost, EvaluateV2Path, EvaluateV2Path, "booking-secret", `{"airportCodes": ["sea"]}`, "fr", "draft"); w.Code != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,134 @@
+package web
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dukeluke16/sample-golang-webservice/auth"
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/logger"
+	"github.com/dukeluke16/sample-golang-webservice/metrics"
+	"github.com/dukeluke16/sample-golang-webservice/ratelimit"
+)
+
+// APIKeyHeader identifying a client
+const APIKeyHeader = auth.APIKeyHeader
+
+// Client kinds, each the default rate limit tier of the clients it identifies
+const (
+	ClientKindAPIKey      = "apikey"
+	ClientKindJWT         = "jwt"
+	ClientKindCertificate = "cert"
+	ClientKindIP          = "ip"
+)
+
+// defaultRateLimitTiers in requests per second and burst for each client kind
+const defaultRateLimitTiers = "ip=5:10,apikey=50:100,cert=50:100,jwt=50:100"
+
+// configureRateLimitTiers from RATE_LIMIT_TIERS, falling back to the defaults when invalid; client kinds it does not list keep their defaults
+func configureRateLimitTiers() map[string]ratelimit.Tier {
+	defaults, _ := ratelimit.ParseTiers(defaultRateLimitTiers)
+	spec := config.StringValue(config.RateLimitTiersKey, defaultRateLimitTiers)
+	tiers, err := ratelimit.ParseTiers(spec)
+	if err != nil {
+		logger.Warning("Rate limit tiers fall back to the defaults", "tiers", spec, "error", err)
+		return defaults
+	}
+
+	for kind, tier := range defaults {
+		if _, ok := tiers[kind]; !ok {
+			tiers[kind] = tier
+		}
+	}
+
+	return tiers
+}
+
+// rateLimitClient identifies the client by authenticated identity, client certificate subject, or IP, in that order, with the tier it is limited by and its kind
+func rateLimitClient(r *http.Request) (tier string, kind string, client string) {
+	if identity, ok := auth.FromContext(r.Context()); ok {
+		// Authenticated clients are limited by the tier of their identity
+		return identity.Tier, identity.Method, identity.Method + ":" + identity.Subject
+	}
+
+	// An unauthenticated X-Api-Key is not keyed on, or a client could send a new one for a fresh bucket on every request
+
+	if r.TLS != nil && len(r.TLS.PeerCertificates) != 0 {
+		return ClientKindCertificate, ClientKindCertificate, r.TLS.PeerCertificates[0].Subject.String()
+	}
+
+	return ClientKindIP, ClientKindIP, clientIP(r)
+}
+
+// withRateLimit responds Too Many Requests once the client has spent the token bucket of its tier
+func withRateLimit(limiter *ratelimit.Limiter, tiers map[string]ratelimit.Tier, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, kind, client := rateLimitClient(r)
+		// A tier missing from RATE_LIMIT_TIERS, e.g. a typo in the API key file, falls back to the tier of the client kind rather than lifting the limit
+		tier, ok := tiers[name]
+		if !ok {
+			tier = tiers[kind]
+		}
+
+		if !rateLimited(w, r, tier, limiter.Allow(client, tier)) {
+			handler(w, r)
+		}
+	}
+}
+
+// withAuthenticationLimit spends the ip tier bucket of the client on every failed authentication, and responds Too Many Requests once it is spent, so credentials cannot be guessed faster than the ip tier allows
+func withAuthenticationLimit(limiter *ratelimit.Limiter, tiers map[string]ratelimit.Tier, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if authenticator == nil {
+			handler(w, r)
+			return
+		}
+
+		tier, client := tiers[ClientKindIP], clientIP(r)
+		if decision := limiter.Peek(client, tier); !decision.Allowed {
+			rateLimited(w, r, tier, decision)
+			return
+		}
+
+		recorder := &authenticationRecorder{ResponseWriter: w}
+		handler(recorder, r)
+		if recorder.status == http.StatusUnauthorized {
+			limiter.Allow(client, tier)
+		}
+	}
+}
+
+// authenticationRecorder of the status, telling failed authentications apart
+type authenticationRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *authenticationRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// rateLimited sets the RateLimit headers of the decision and responds Too Many Requests when it does not allow the request
+func rateLimited(w http.ResponseWriter, r *http.Request, tier ratelimit.Tier, decision ratelimit.Decision) bool {
+	if decision.Limit != 0 {
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		w.Header().Set("RateLimit-Reset", ceilSeconds(decision.Reset))
+	}
+
+	if !decision.Allowed {
+		metrics.RateLimitRejections.WithLabelValues(tier.Name).Inc()
+		w.Header().Set("Retry-After", ceilSeconds(decision.RetryAfter))
+		genericStatusResponseError(w, r, http.StatusTooManyRequests)
+		return true
+	}
+
+	return false
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,176 @@
+package web
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/dukeluke16/sample-golang-webservice/auth"
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/metrics"
+	"github.com/dukeluke16/sample-golang-webservice/ratelimit"
+)
+
+func serveLimited(handler http.Handler, apiKey string) *httptest.ResponseRecorder {
+	r, _ := http.NewRequest(http.MethodPost, EvaluateV1Path, strings.NewReader(`[]`))
+	r.Header.Set("Content-Type", "application/json")
+	r.RemoteAddr = "192.0.2.1:54321"
+	if len(apiKey) != 0 {
+		r.Header.Set(APIKeyHeader, apiKey)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	recordExchange(http.MethodPost, EvaluateV1Path, w)
+
+	return w
+}
+
+func TestRateLimit(t *testing.T) {
+	os.Setenv(config.RateLimitTiersKey, "ip=1:2,apikey=1:5")
+	defer os.Unsetenv(config.RateLimitTiersKey)
+	before := testutil.ToFloat64(metrics.RateLimitRejections.WithLabelValues(ClientKindIP))
+
+	handler := NewRouter()
+	for i := 0; i < 2; i++ {
+		if w := serveLimited(handler, ""); w.Code != http.StatusNoContent {
+			t.Errorf("handler limited a request within the burst: got %v want %v", w.Code, http.StatusNoContent)
+		}
+	}
+
+	w := serveLimited(handler, "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusTooManyRequests)
+	}
+
+	expected := map[string]string{
+		"Retry-After":         "1",
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "2",
+	}
+	for header, value := range expected {
+		if w.Header().Get(header) != value {
+			t.Errorf("handler returned wrong %v header: got %v want %v", header, w.Header().Get(header), value)
+		}
+	}
+
+	actual := testutil.ToFloat64(metrics.RateLimitRejections.WithLabelValues(ClientKindIP)) - before
+	if actual != 1 {
+		t.Errorf("RateLimitRejections does not match: got %v want %v", actual, 1)
+	}
+
+	// An API key that is not authenticated cannot buy a fresh bucket
+	if w := serveLimited(handler, "random-key"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("handler returned wrong status code for an unauthenticated API key: got %v want %v", w.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRateLimitAuthenticatedAPIKey(t *testing.T) {
+	defer setupAPIKeyAuthenticator(t)()
+	os.Setenv(config.RateLimitTiersKey, "ip=1:1,partner=1:5")
+	defer os.Unsetenv(config.RateLimitTiersKey)
+
+	handler := NewRouter()
+	if w := serveLimited(handler, "booking-secret"); w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Limit") != "5" {
+		t.Errorf("handler limited an API key client by its IP: got %v %v", w.Code, w.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestRateLimitExemptsHealth(t *testing.T) {
+	os.Setenv(config.RateLimitTiersKey, "ip=1:1")
+	defer os.Unsetenv(config.RateLimitTiersKey)
+
+	handler := NewRouter()
+	for i := 0; i < 3; i++ {
+		r, _ := http.NewRequest(http.MethodGet, HealthPath, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("handler rate limited %v: got %v", HealthPath, w.Code)
+		}
+	}
+}
+
+func TestRateLimitFailedAuthentication(t *testing.T) {
+	defer setupAPIKeyAuthenticator(t)()
+	os.Setenv(config.RateLimitTiersKey, "ip=1:2")
+	defer os.Unsetenv(config.RateLimitTiersKey)
+
+	handler := NewRouter()
+	for i := 0; i < 2; i++ {
+		if w := serveLimited(handler, "guessed-key"); w.Code != http.StatusUnauthorized {
+			t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusUnauthorized)
+		}
+	}
+
+	// Guessing is cut off once the failures have spent the bucket of the IP
+	w := serveLimited(handler, "guessed-key")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("handler did not limit failed authentications: got %v %v", w.Code, w.Header())
+	}
+}
+
+func TestRateLimitClient(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodPost, EvaluateV1Path, nil)
+	r.RemoteAddr = "192.0.2.1:54321"
+	if tier, kind, client := rateLimitClient(r); tier != ClientKindIP || kind != ClientKindIP || client != "192.0.2.1" {
+		t.Errorf("rateLimitClient returned wrong client: got %v %v %v", tier, kind, client)
+	}
+
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "booking-engine"}}}}
+	if tier, kind, client := rateLimitClient(r); tier != ClientKindCertificate || kind != ClientKindCertificate || client != "CN=booking-engine" {
+		t.Errorf("rateLimitClient returned wrong client: got %v %v %v", tier, kind, client)
+	}
+
+	r.TLS = nil
+	r.Header.Set(APIKeyHeader, "secret-api-key")
+	if tier, _, client := rateLimitClient(r); tier != ClientKindIP || client != "192.0.2.1" {
+		t.Errorf("rateLimitClient keyed on an unauthenticated API key: got %v %v", tier, client)
+	}
+
+	r = r.WithContext(auth.NewContext(r.Context(), auth.Identity{Subject: "booking-engine", Method: auth.MethodAPIKey, Tier: "partner"}))
+	if tier, kind, client := rateLimitClient(r); tier != "partner" || kind != ClientKindAPIKey || client != "apikey:booking-engine" {
+		t.Errorf("rateLimitClient returned wrong client: got %v %v %v", tier, kind, client)
+	}
+}
+
+func TestRateLimitUnlistedTier(t *testing.T) {
+	defer setupAPIKeyAuthenticator(t)()
+	os.Setenv(config.RateLimitTiersKey, "ip=1:1,apikey=1:3")
+	defer os.Unsetenv(config.RateLimitTiersKey)
+
+	// The partner tier of the API key is not listed, so the apikey tier limits it
+	handler := NewRouter()
+	if w := serveLimited(handler, "booking-secret"); w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Limit") != "3" {
+		t.Errorf("handler did not limit an unlisted tier by its client kind: got %v %v", w.Code, w.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestConfigureRateLimitTiersDefaults(t *testing.T) {
+	os.Setenv(config.RateLimitTiersKey, "partner=1:7")
+	defer os.Unsetenv(config.RateLimitTiersKey)
+
+	tiers := configureRateLimitTiers()
+	if tiers[ClientKindIP] != (ratelimit.Tier{Name: ClientKindIP, Rate: 5, Burst: 10}) || tiers[ClientKindJWT].Unlimited() || tiers["partner"].Burst != 7 {
+		t.Errorf("configureRateLimitTiers dropped the defaults of unlisted client kinds: got %+v", tiers)
+	}
+}
+
+func TestConfigureRateLimitTiersFallback(t *testing.T) {
+	os.Setenv(config.RateLimitTiersKey, "ip=fast")
+	defer os.Unsetenv(config.RateLimitTiersKey)
+
+	tiers := configureRateLimitTiers()
+	if tiers[ClientKindIP] != (ratelimit.Tier{Name: ClientKindIP, Rate: 5, Burst: 10}) {
+		t.Errorf("configureRateLimitTiers did not fall back to the defaults: got %+v", tiers)
+	}
+}
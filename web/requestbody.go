@@ -0,0 +1,69 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/dukeluke16/sample-golang-webservice/config"
+)
+
+// errEmptyBody returned by decodeJSONBody, without responding, for a request without a body
+var errEmptyBody = errors.New("empty request body")
+
+// errBodyRejected returned by decodeJSONBody once it has responded with the error status
+var errBodyRejected = errors.New("request body rejected")
+
+// decodeJSONBody streams the JSON body into v, limited to MAX_REQUEST_BODY_BYTES; strict rejects unknown fields
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, v interface{}, strict bool) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return errEmptyBody
+	}
+
+	if !jsonContentType(r.Header.Get("Content-Type")) && config.StringValue(config.RequireJSONContentTypeKey, "true") == "true" {
+		statusResponseError(w, r, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+		return errBodyRejected
+	}
+
+	limit := int64(config.IntValue(config.MaxRequestBodyBytesKey, 64*1024))
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+
+	var tooLarge *http.MaxBytesError
+	err := decoder.Decode(v)
+	if err == nil {
+		switch trailing := decoder.Decode(&json.RawMessage{}); {
+		case errors.As(trailing, &tooLarge):
+			err = trailing
+		case trailing != io.EOF:
+			err = errors.New("unexpected data after the JSON value")
+		}
+	}
+
+	switch {
+	case err == nil:
+		return nil
+	case err == io.EOF:
+		return errEmptyBody
+	case errors.As(err, &tooLarge):
+		statusResponseError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("body exceeds %d bytes", limit))
+	case strict && strings.HasPrefix(err.Error(), "json: unknown field"):
+		statusResponseError(w, r, http.StatusBadRequest, strings.TrimPrefix(err.Error(), "json: "))
+	default:
+		genericStatusResponseError(w, r, http.StatusBadRequest)
+	}
+
+	return errBodyRejected
+}
+
+// jsonContentType reports whether the media type is application/json or a +json suffix type
+func jsonContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
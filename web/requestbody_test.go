@@ -0,0 +1,123 @@
+package web
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dukeluke16/sample-golang-webservice/config"
+)
+
+func serveBody(path string, handler http.HandlerFunc, contentType string, body io.Reader) *httptest.ResponseRecorder {
+	r, _ := http.NewRequest(http.MethodPost, path, body)
+	if len(contentType) != 0 {
+		r.Header.Set("Content-Type", contentType)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	recordExchange(http.MethodPost, path, w)
+
+	return w
+}
+
+func TestJSONContentType(t *testing.T) {
+	expected := map[string]bool{
+		"application/json":                  true,
+		"application/json; charset=UTF-8":   true,
+		"application/problem+json":          true,
+		"text/plain":                        false,
+		"application/x-www-form-urlencoded": false,
+		"":                                  false,
+	}
+	for contentType, value := range expected {
+		if jsonContentType(contentType) != value {
+			t.Errorf("jsonContentType returned wrong value for %v: got %v want %v", contentType, !value, value)
+		}
+	}
+}
+
+func TestUnsupportedMediaType(t *testing.T) {
+	for _, path := range []string{EvaluatePath, EvaluateV2Path} {
+		handler := EvaluatePostHandler
+		if path == EvaluateV2Path {
+			handler = EvaluateV2PostHandler
+		}
+
+		w := serveBody(path, handler, "text/plain", strings.NewReader(`["sea"]`))
+		if w.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("handler returned wrong status code for %v: got %v want %v", path, w.Code, http.StatusUnsupportedMediaType)
+		}
+	}
+}
+
+func TestUnsupportedMediaTypeCompatibility(t *testing.T) {
+	ts := setupFakeServerUSA()
+	defer ts.Close()
+	os.Setenv(config.RequireJSONContentTypeKey, "false")
+	defer os.Unsetenv(config.RequireJSONContentTypeKey)
+
+	w := serveBody(EvaluatePath, EvaluatePostHandler, "", strings.NewReader(`["sea"]`))
+	if w.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
+	}
+}
+
+func TestEmptyBodyWithoutContentType(t *testing.T) {
+	w := serveBody(EvaluatePath, EvaluatePostHandler, "", nil)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusNoContent)
+	}
+}
+
+func TestRequestEntityTooLarge(t *testing.T) {
+	os.Setenv(config.MaxRequestBodyBytesKey, "16")
+	defer os.Unsetenv(config.MaxRequestBodyBytesKey)
+
+	w := serveBody(EvaluatePath, EvaluatePostHandler, "application/json", strings.NewReader(`["sea", "lax", "jfk", "ord"]`))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	expected := "Request Entity Too Large: body exceeds 16 bytes\n"
+	if w.Body.String() != expected {
+		t.Errorf("handler returned unexpected body: got %v want %v", w.Body.String(), expected)
+	}
+}
+
+func TestRequestEntityTooLargeAfterJSONValue(t *testing.T) {
+	os.Setenv(config.MaxRequestBodyBytesKey, "16")
+	defer os.Unsetenv(config.MaxRequestBodyBytesKey)
+
+	w := serveBody(EvaluatePath, EvaluatePostHandler, "application/json", strings.NewReader(`[]`+strings.Repeat(" ", 32)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	expected := "Request Entity Too Large: body exceeds 16 bytes\n"
+	if w.Body.String() != expected {
+		t.Errorf("handler returned unexpected body: got %v want %v", w.Body.String(), expected)
+	}
+}
+
+func TestStrictDecoding(t *testing.T) {
+	w := serveBody(EvaluateV2Path, EvaluateV2PostHandler, "application/json", strings.NewReader(`{"airportCodes": ["sea"], "airports": ["lax"]}`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusBadRequest)
+	}
+
+	expected := `Bad Request: unknown field "airports"` + "\n"
+	if w.Body.String() != expected {
+		t.Errorf("handler returned unexpected body: got %v want %v", w.Body.String(), expected)
+	}
+}
+
+func TestTrailingData(t *testing.T) {
+	w := serveBody(EvaluatePath, EvaluatePostHandler, "application/json", strings.NewReader(`["sea"] ["lax"]`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusBadRequest)
+	}
+}
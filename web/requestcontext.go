@@ -0,0 +1,144 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/dukeluke16/sample-golang-webservice/accesslog"
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/logger"
+)
+
+// RequestIDHeader accepted from clients, echoed in responses, and sent upstream
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength accepted from clients before a new ID is generated
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// withRequestContext accepts or generates the request ID and stores a request-scoped logger in the context
+func withRequestContext(route string, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		requestLogger := logger.With(
+			"requestId", requestID,
+			"route", route,
+			"clientIp", clientIP(r))
+
+		ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
+		ctx = logger.NewContext(ctx, requestLogger)
+		handler(w, r.WithContext(ctx))
+	}
+}
+
+// withAccessLog records the request in the access log, tagged with the request ID
+func withAccessLog(_ string, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accessLog.Middleware(func(w http.ResponseWriter, r *http.Request) {
+			accesslog.FromContext(r.Context()).RequestID = RequestIDFromContext(r.Context())
+			handler(w, r)
+		})(w, r)
+	}
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, if any
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// withLogFields adds key value pairs to the request-scoped logger
+func withLogFields(r *http.Request, keyvals ...interface{}) *http.Request {
+	requestLogger := logger.FromContext(r.Context()).With(keyvals...)
+	return r.WithContext(logger.NewContext(r.Context(), requestLogger))
+}
+
+// validRequestID limits client supplied IDs to printable ASCII so they are safe to log and echo
+func validRequestID(requestID string) bool {
+	if len(requestID) == 0 || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < '!' || requestID[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// trustedProxies whose X-Forwarded-For is believed, configured by Start from TRUSTED_PROXIES
+var trustedProxies []*net.IPNet
+
+// configureTrustedProxies from the IPs and CIDRs listed in TRUSTED_PROXIES, skipping invalid entries
+func configureTrustedProxies() []*net.IPNet {
+	var proxies []*net.IPNet
+	for _, entry := range config.ListValue(config.TrustedProxiesKey) {
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			logger.Warning("Trusted proxy is invalid", "proxy", entry, "error", err)
+			continue
+		}
+		proxies = append(proxies, network)
+	}
+
+	return proxies
+}
+
+// clientIP of the peer, or behind trusted proxies the last address in X-Forwarded-For that is not a trusted proxy
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	// Addresses left of the nearest untrusted one could have been sent by the client, so they are never used
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0 && trustedProxy(host); i-- {
+		if address := strings.TrimSpace(forwarded[i]); net.ParseIP(address) != nil {
+			host = address
+		}
+	}
+
+	return host
+}
+
+func trustedProxy(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
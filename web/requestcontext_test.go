@@ -0,0 +1,176 @@
+package web
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dukeluke16/sample-golang-webservice/accesslog"
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/logger"
+)
+
+func serveWithRequestContext(r *http.Request) *httptest.ResponseRecorder {
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	withRequestContext(EvaluatePath, EvaluatePostHandler)(w, r)
+	recordExchange(r.Method, EvaluatePath, w)
+
+	return w
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, EvaluatePath, nil)
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, r)
+	recordExchange(http.MethodGet, EvaluatePath, w)
+
+	requestID := w.Header().Get(RequestIDHeader)
+	if len(requestID) != 32 {
+		t.Errorf("handler returned unexpected request ID: got %v", requestID)
+	}
+
+	expected := "Method Not Allowed (Request ID: " + requestID + ")\n"
+	if w.Body.String() != expected {
+		t.Errorf("handler returned unexpected body: got %v want %v", w.Body.String(), expected)
+	}
+}
+
+func TestRequestIDAccepted(t *testing.T) {
+	ts := setupFakeServerUSA()
+	defer ts.Close()
+
+	r, _ := http.NewRequest(http.MethodPost, EvaluatePath, strings.NewReader(`["sea"]`))
+	r.Header.Set(RequestIDHeader, "booking-123")
+	w := serveWithRequestContext(r)
+
+	if w.Header().Get(RequestIDHeader) != "booking-123" {
+		t.Errorf("handler returned wrong request ID: got %v want %v", w.Header().Get(RequestIDHeader), "booking-123")
+	}
+
+	if fakeServerRequestHeader.Get(RequestIDHeader) != "booking-123" {
+		t.Errorf("Location Services received wrong request ID: got %v want %v",
+			fakeServerRequestHeader.Get(RequestIDHeader), "booking-123")
+	}
+}
+
+func TestRequestIDInvalidReplaced(t *testing.T) {
+	invalid := []string{"has space", "line\nbreak", strings.Repeat("a", maxRequestIDLength+1)}
+
+	for _, requestID := range invalid {
+		r, _ := http.NewRequest(http.MethodPost, EvaluatePath, nil)
+		r.Header.Set(RequestIDHeader, requestID)
+		w := serveWithRequestContext(r)
+
+		if w.Header().Get(RequestIDHeader) == requestID || len(w.Header().Get(RequestIDHeader)) != 32 {
+			t.Errorf("handler echoed an invalid request ID: got %v", w.Header().Get(RequestIDHeader))
+		}
+	}
+}
+
+func TestRequestScopedLogger(t *testing.T) {
+	var output bytes.Buffer
+	logger.Init(&output, logger.LevelInfo, logger.FormatLogfmt)
+	defer logger.Init(os.Stderr, logger.LevelInfo, logger.FormatLogfmt)
+
+	tag := "fr"
+	r, _ := http.NewRequest(http.MethodPost, EvaluatePath, strings.NewReader(`["foo": "bar"]`))
+	r.Header.Set(RequestIDHeader, "booking-456")
+	r.Header.Set("Accept-Language", tag)
+	r.RemoteAddr = "192.0.2.1:54321"
+	serveWithRequestContext(r)
+
+	for _, expected := range []string{"requestId=booking-456", "route=" + EvaluatePath, "clientIp=192.0.2.1", "locale=fr", "status=400"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Logger wrote unexpected entry: missing %v in %v", expected, output.String())
+		}
+	}
+}
+
+func TestErrorLogRedactsSensitiveHeaders(t *testing.T) {
+	var output bytes.Buffer
+	logger.Init(&output, logger.LevelTrace, logger.FormatJSON)
+	defer logger.Init(os.Stderr, logger.LevelInfo, logger.FormatLogfmt)
+
+	r, _ := http.NewRequest(http.MethodPost, EvaluatePath, strings.NewReader(`["foo": "bar"]`))
+	r.Header.Set("Authorization", "Bearer secret-bearer-token")
+	r.Header.Set("Proxy-Authorization", "Basic c2VjcmV0LXByb3h5")
+	r.Header.Set("Cookie", "session=secret-cookie")
+	r.Header.Set("X-Api-Key", "secret-api-key")
+	r.Header.Set("X-Booking-Session", "secret-session")
+	r.Header.Set("Correlationid", "123456789")
+	serveWithRequestContext(r)
+
+	for _, secret := range []string{"secret-bearer-token", "c2VjcmV0LXByb3h5", "secret-cookie", "secret-api-key", "secret-session"} {
+		if strings.Contains(output.String(), secret) {
+			t.Errorf("Logger wrote a sensitive header value %v: %v", secret, output.String())
+		}
+	}
+
+	if !strings.Contains(output.String(), `"Correlationid":"123456789"`) || !strings.Contains(output.String(), `"Authorization":"Bearer [REDACTED]"`) {
+		t.Errorf("Logger wrote unexpected headers: %v", output.String())
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	ts := setupFakeServerUSA()
+	defer ts.Close()
+
+	var output bytes.Buffer
+	defer func(original *accesslog.Logger) { accessLog = original }(accessLog)
+	accessLog = accesslog.New(&output, accesslog.FormatCombined, 1, redactionPolicy)
+
+	r, _ := http.NewRequest(http.MethodPost, EvaluatePath, strings.NewReader(`["sea"]`))
+	r.Header.Set(RequestIDHeader, "booking-789")
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept-Language", "fr")
+	w := httptest.NewRecorder()
+	withRequestContext(EvaluatePath, withAccessLog(EvaluatePath, EvaluatePostHandler))(w, r)
+
+	expected := `"POST ` + EvaluatePath + ` HTTP/1.1" 200 ` + strconv.Itoa(w.Body.Len())
+	if !strings.Contains(output.String(), expected) {
+		t.Errorf("Access Log wrote unexpected request: got %v want %v", output.String(), expected)
+	}
+
+	expected = " locale=fr upstream_lookups=1 request_id=booking-789\n"
+	if !strings.HasSuffix(output.String(), expected) {
+		t.Errorf("Access Log wrote unexpected fields: got %v want %v", output.String(), expected)
+	}
+}
+
+func TestClientIPTrustedProxies(t *testing.T) {
+	os.Setenv(config.TrustedProxiesKey, "10.0.0.0/8, 192.0.2.7, not-an-ip")
+	defer os.Unsetenv(config.TrustedProxiesKey)
+	defer func(original []*net.IPNet) { trustedProxies = original }(trustedProxies)
+	trustedProxies = configureTrustedProxies()
+	if len(trustedProxies) != 2 {
+		t.Fatalf("configureTrustedProxies returned wrong proxies: got %v", trustedProxies)
+	}
+
+	expected := map[string]string{
+		// The peer is not a trusted proxy, so its X-Forwarded-For is ignored
+		"203.0.113.9:54321|198.51.100.1": "203.0.113.9",
+		// Behind trusted proxies, the nearest untrusted address is the client
+		"10.0.0.1:54321|198.51.100.1":                          "198.51.100.1",
+		"10.0.0.1:54321|198.51.100.66, 198.51.100.1, 10.0.0.2": "198.51.100.1",
+		"192.0.2.7:54321|198.51.100.1":                         "198.51.100.1",
+		"10.0.0.1:54321|":                                      "10.0.0.1",
+	}
+	for request, ip := range expected {
+		parts := strings.SplitN(request, "|", 2)
+		r, _ := http.NewRequest(http.MethodGet, HealthPath, nil)
+		r.RemoteAddr = parts[0]
+		if len(parts[1]) != 0 {
+			r.Header.Set("X-Forwarded-For", parts[1])
+		}
+
+		if actual := clientIP(r); actual != ip {
+			t.Errorf("clientIP returned wrong client for %v: got %v want %v", request, actual, ip)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dukeluke16/sample-golang-webservice/metrics"
+)
+
+// API Versions labelling the per-version metrics
+const (
+	APIVersionLegacy = "legacy"
+	APIVersionV1     = "v1"
+	APIVersionV2     = "v2"
+)
+
+// defaultLegacySunset HTTP-date after which the unversioned routes may be removed
+const defaultLegacySunset = "Thu, 01 Jul 2027 00:00:00 GMT"
+
+// legacyDeprecation when the unversioned routes were superseded by v1
+var legacyDeprecation = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// withAPIVersion counts every request of the handler against the API version
+func withAPIVersion(version string, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		metrics.ObserveAPIVersion(version)
+		handler(w, r)
+	}
+}
+
+// deprecated marks every response of a legacy route with the RFC 9745 Deprecation date, Sunset, and a Link to its successor
+func deprecated(since time.Time, sunset string, successor string, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	deprecation := "@" + strconv.FormatInt(since.Unix(), 10)
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", deprecation)
+		w.Header().Set("Sunset", sunset)
+		w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		handler(w, r)
+	}
+}
@@ -0,0 +1,73 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/dukeluke16/sample-golang-webservice/config"
+	"github.com/dukeluke16/sample-golang-webservice/metrics"
+)
+
+func serveVersion(path string, body string) *httptest.ResponseRecorder {
+	r, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, r)
+	recordExchange(http.MethodPost, path, w)
+
+	return w
+}
+
+func TestLegacyRouteDeprecated(t *testing.T) {
+	os.Setenv(config.LegacySunsetKey, "Fri, 31 Dec 2027 23:59:59 GMT")
+	defer os.Unsetenv(config.LegacySunsetKey)
+
+	w := serveVersion(EvaluatePath, `[]`)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusNoContent)
+	}
+
+	expected := map[string]string{
+		"Deprecation": "@1792368000",
+		"Sunset":      "Fri, 31 Dec 2027 23:59:59 GMT",
+		"Link":        "<" + EvaluateV1Path + `>; rel="successor-version"`,
+	}
+	for header, value := range expected {
+		if w.Header().Get(header) != value {
+			t.Errorf("handler returned wrong %v header: got %v want %v", header, w.Header().Get(header), value)
+		}
+	}
+}
+
+func TestVersionedRoutesNotDeprecated(t *testing.T) {
+	for _, path := range []string{EvaluateV1Path, EvaluateV2Path} {
+		w := serveVersion(path, `[]`)
+
+		if len(w.Header().Get("Deprecation")) != 0 || len(w.Header().Get("Sunset")) != 0 {
+			t.Errorf("handler deprecated %v: got %v", path, w.Header())
+		}
+	}
+}
+
+func TestAPIVersionMetrics(t *testing.T) {
+	expected := map[string]string{
+		APIVersionLegacy: EvaluatePath,
+		APIVersionV1:     EvaluateV1Path,
+		APIVersionV2:     EvaluateV2Path,
+	}
+	for version, path := range expected {
+		before := testutil.ToFloat64(metrics.APIVersionRequests.WithLabelValues(version))
+		serveVersion(path, `[]`)
+
+		actual := testutil.ToFloat64(metrics.APIVersionRequests.WithLabelValues(version)) - before
+		if actual != 1 {
+			t.Errorf("APIVersionRequests for %v does not match: got %v want %v", version, actual, 1)
+		}
+	}
+}
@@ -1,18 +1,19 @@
 package web
 
 import (
-	"crypto/tls"
-	"crypto/x509"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
-	"path/filepath"
-	"strings"
 
-	"github.com/newrelic/go-agent"
+	"github.com/dukeluke16/sample-golang-webservice/accesslog"
+	"github.com/dukeluke16/sample-golang-webservice/apm"
+	"github.com/dukeluke16/sample-golang-webservice/audit"
 	"github.com/dukeluke16/sample-golang-webservice/config"
 	"github.com/dukeluke16/sample-golang-webservice/logger"
+	"github.com/dukeluke16/sample-golang-webservice/metrics"
+	"github.com/dukeluke16/sample-golang-webservice/ratelimit"
+	"github.com/dukeluke16/sample-golang-webservice/redact"
+	"github.com/dukeluke16/sample-golang-webservice/router"
 )
 
 // EnableNewRelic enivronment variable key
@@ -21,66 +22,149 @@ var EnableNewRelic string
 // listenAndServe for mocking out the http.ListenAndServe
 var listenAndServe = http.ListenAndServe
 
-// wrapHandleFunc for managing NewRelic bypass
-var wrapHandleFunc = newrelic.WrapHandleFunc
+// tracer for the configured APM provider
+var tracer = apm.NewNoopTracer()
 
 var certDirectory = "./certs/"
 
-// bypassNewRelic for environments not configured for NewRelic
-func bypassNewRelic(app newrelic.Application, pattern string, handler func(http.ResponseWriter, *http.Request)) (string, func(http.ResponseWriter, *http.Request)) {
-	return pattern, func(w http.ResponseWriter, r *http.Request) { http.HandlerFunc(handler).ServeHTTP(w, r) }
-}
+// redactionPolicy applied to every header and query parameter that is logged
+var redactionPolicy = redact.NewPolicy(nil, nil)
 
-func configureNewRelic() newrelic.Application {
-	if EnableNewRelic != "true" {
-		wrapHandleFunc = bypassNewRelic
-		logger.Warning.Println("New Relic is bypassed!")
-	}
+// accessLog written for every request, discarded until Start configures it
+var accessLog = accesslog.New(ioutil.Discard, accesslog.FormatCombined, 1, redactionPolicy)
 
-	datacenter := strings.ToUpper(os.Getenv("NEW_RELIC_DATACENTER"))
-	environment := strings.ToUpper(os.Getenv("NEW_RELIC_ENVIRONMENT"))
-	roletypeid := strings.ToUpper(os.Getenv("NEW_RELIC_ROLETYPEID"))
-	appname := fmt.Sprintf("%v-%v1-%v", datacenter, environment, roletypeid)
+// MetricsPath for endpoint
+var MetricsPath = "/metrics"
 
-	newRelicConfig := newrelic.NewConfig(appname, os.Getenv("NEW_RELIC_LICENSE_KEY"))
+// configureAPM for the provider selected by APM_PROVIDER, defaulting to New Relic when enabled at build time
+func configureAPM() apm.Tracer {
+	provider := apm.ProviderNone
+	if EnableNewRelic == "true" {
+		provider = apm.ProviderNewRelic
+	}
+	provider = config.StringValue(config.APMProviderKey, provider)
+
+	configured, err := apm.New(apm.Options{
+		Provider:      provider,
+		ServiceName:   config.StringValue(config.APMServiceNameKey, "sample-golang-webservice"),
+		CertDirectory: certDirectory,
+		Exporter:      config.StringValue(config.OTelExporterKey, apm.ExporterOTLP),
+		OTLPEndpoint:  config.StringValue(config.OTelEndpointKey, "http://localhost:4318"),
+	})
+	if err != nil {
+		logger.Warning("APM is bypassed", "provider", provider, "error", err)
+		return apm.NewNoopTracer()
+	}
 
-	// Configure Certificate Trust Chain for New Relic
-	certFile, _ := os.Open(filepath.Join(certDirectory, "newrelic.pem"))
-	certData, _ := ioutil.ReadAll(certFile)
-	roots := x509.NewCertPool()
-	roots.AppendCertsFromPEM(certData)
-	tlsConfig := &tls.Config{
-		RootCAs: roots,
+	if provider == apm.ProviderNone {
+		logger.Warning("APM is bypassed")
 	}
 
-	// Configure Transport
-	newRelicProxy := config.ProxyURI("NEW_RELIC_PROXY")
-	logger.Info.Println("Configuring New Relic for Certificate Trust Chain & Proxy.")
-	newRelicConfig.Transport = &http.Transport{
-		Proxy:           http.ProxyURL(newRelicProxy),
-		TLSClientConfig: tlsConfig,
+	return configured
+}
+
+// configureAccessLog for the destination, format, and 2xx sampling rate from the environment
+func configureAccessLog() *accesslog.Logger {
+	destination := config.StringValue(config.AccessLogOutputKey, accesslog.DestinationStdout)
+	out, err := accesslog.Open(destination)
+	if err != nil {
+		logger.Warning("Access Log falls back to stdout", "destination", destination, "error", err)
+		out = os.Stdout
 	}
 
-	newRelicConfig.Labels["Application"] = os.Getenv("NEW_RELIC_APP_NAME")
-	newRelicConfig.Labels["Datacenter"] = datacenter
-	newRelicConfig.Labels["Envrionment"] = environment
-	newRelicConfig.Labels["RoleTypeID"] = roletypeid
+	return accesslog.New(out,
+		accesslog.Format(config.StringValue(config.AccessLogFormatKey, string(accesslog.FormatCombined))),
+		config.FloatValue(config.AccessLogSample2xxKey, 1),
+		redactionPolicy)
+}
 
-	app, _ := newrelic.NewApplication(newRelicConfig)
+// withTracing wraps the handler for the configured APM provider
+func withTracing(route string, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	_, wrapped := tracer.WrapHandleFunc(route, handler)
+	return wrapped
+}
 
-	return app
+// NewRouter for every endpoint, each given a request context, access logged, instrumented for metrics, compressed, CORS checked, and traced
+func NewRouter() http.Handler {
+	rt := router.New(withRequestContext, withAccessLog, metrics.Instrument, withCompression, withCORS, withTracing)
+	rt.NotFound = func(w http.ResponseWriter, r *http.Request) {
+		genericStatusResponseError(w, r, http.StatusNotFound)
+	}
+	rt.MethodNotAllowed = func(w http.ResponseWriter, r *http.Request) {
+		genericStatusResponseError(w, r, http.StatusMethodNotAllowed)
+	}
+
+	rt.Handle(http.MethodGet, HealthPath, HealthGetHandler)
+	rt.Handle(http.MethodGet, HealthLivePath, HealthLiveGetHandler)
+	rt.Handle(http.MethodGet, HealthReadyPath, HealthReadyGetHandler)
+	rt.Handle(http.MethodGet, InfoPath, InfoGetHandler)
+	rt.Handle(http.MethodGet, MetricsPath, metrics.Handler().ServeHTTP)
+	rt.Handle(http.MethodGet, OpenAPIPath, OpenAPIGetHandler)
+	rt.Handle(http.MethodGet, PoliciesPath, PoliciesGetHandler)
+	// Policy documents are public, but authenticate the clients asking to preview drafts
+	rt.Handle(http.MethodGet, PolicyPath, withPreview(PolicyGetHandler))
+	rt.Handle(http.MethodGet, PolicyVersionsPath, PolicyVersionsGetHandler)
+	rt.Handle(http.MethodGet, PolicyLocalePath, withPreview(PolicyLocaleGetHandler))
+
+	// Evaluations fan out to the Location Services, so they are authenticated and then rate limited per client; failed authentications spend the bucket of the client IP
+	limiter := ratelimit.NewLimiter()
+	tiers := configureRateLimitTiers()
+	protected := func(handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+		return withAuthenticationLimit(limiter, tiers, withAuthentication(withRateLimit(limiter, tiers, withPreview(handler))))
+	}
+
+	sunset := config.StringValue(config.LegacySunsetKey, defaultLegacySunset)
+	rt.Handle(http.MethodPost, EvaluatePath, deprecated(legacyDeprecation, sunset, EvaluateV1Path, withAPIVersion(APIVersionLegacy, protected(EvaluatePostHandler))))
+	rt.Handle(http.MethodPost, EvaluateV1Path, withAPIVersion(APIVersionV1, protected(EvaluatePostHandler)))
+	rt.Handle(http.MethodPost, EvaluateV2Path, withAPIVersion(APIVersionV2, protected(EvaluateV2PostHandler)))
+	rt.Handle(http.MethodPost, EvaluateBatchPath, withAPIVersion(APIVersionV2, protected(EvaluateBatchPostHandler)))
+
+	// The admin API changes the policy documents, so it is only open to the authenticated clients in ADMIN_CLIENTS
+	rt.Handle(http.MethodGet, AdminPolicyLocalesPath, withAdmin(AdminPolicyLocalesGetHandler))
+	rt.Handle(http.MethodGet, AdminPolicyLocalePath, withAdmin(AdminPolicyLocaleGetHandler))
+	rt.Handle(http.MethodPut, AdminPolicyLocalePath, withAdmin(AdminPolicyLocalePutHandler))
+	rt.Handle(http.MethodDelete, AdminPolicyLocalePath, withAdmin(AdminPolicyLocaleDeleteHandler))
+	rt.Handle(http.MethodGet, AdminPolicyDraftPath, withAdmin(AdminPolicyDraftGetHandler))
+	rt.Handle(http.MethodPut, AdminPolicyDraftPath, withAdmin(AdminPolicyDraftPutHandler))
+	rt.Handle(http.MethodDelete, AdminPolicyDraftPath, withAdmin(AdminPolicyDraftDeleteHandler))
+	rt.Handle(http.MethodPost, AdminPolicyPublishPath, withAdmin(AdminPolicyPublishPostHandler))
+
+	return rt
 }
 
 // Start the web service and return the error if any
 func Start() error {
-	newRelicApp := configureNewRelic()
+	tracer = configureAPM()
+	// Export the Spans still buffered once the server stops
+	defer func(tracer apm.Tracer) {
+		if err := tracer.Shutdown(); err != nil {
+			logger.Warning("APM failed to flush on shutdown", "error", err)
+		}
+	}(tracer)
+	// The access log redacts with the policy, so it is configured first
+	redactionPolicy = redact.NewPolicy(
+		config.ListValue(config.RedactHeadersAllowKey),
+		config.ListValue(config.RedactHeadersDenyKey))
+	accessLog = configureAccessLog()
+	corsPolicy = configureCORS()
+	trustedProxies = configureTrustedProxies()
+	auditLog = audit.NewLog(config.StringValue(config.AuditLogFileKey, defaultAuditLogFile))
+
+	configured, err := configureAuthenticator()
+	if err != nil {
+		return err
+	}
+	authenticator = configured
 
-	http.HandleFunc(wrapHandleFunc(newRelicApp, HealthPath, HealthGetHandler))
-	http.HandleFunc(wrapHandleFunc(newRelicApp, EvaluatePath, EvaluatePostHandler))
+	if err := policyStore.Load(); err != nil {
+		logger.Warning("Policy Store failed to load", "error", err)
+	}
+	registerReadinessChecks()
+	handler := NewRouter()
 
-	logger.Info.Println("Application Version: ", config.BinaryVersion)
+	logger.Info("Application started", "version", config.BinaryVersion)
 
 	port := ":4001"
-	logger.Info.Println("Starting server on ", port)
-	return listenAndServe(port, nil)
+	logger.Info("Starting server", "port", port)
+	return listenAndServe(port, handler)
 }
@@ -3,12 +3,16 @@ package web
 import (
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 
-	newrelic "github.com/newrelic/go-agent"
+	"github.com/dukeluke16/sample-golang-webservice/accesslog"
+	"github.com/dukeluke16/sample-golang-webservice/apm"
+	"github.com/dukeluke16/sample-golang-webservice/config"
 )
 
 func TestStart(t *testing.T) {
+	defer func(original *accesslog.Logger) { accessLog = original }(accessLog)
 	certDirectory = "../certs/"
 	listenAndServe = func(addr string, handler http.Handler) error {
 		return nil
@@ -23,7 +27,7 @@ func TestStart(t *testing.T) {
 func testHandler(w http.ResponseWriter, r *http.Request) {
 }
 
-func TestBypassNewRelic(t *testing.T) {
+func TestBypassAPM(t *testing.T) {
 	expectedPath := "/testPath"
 	r, err := http.NewRequest(http.MethodGet, expectedPath, nil)
 	r.Header.Set("Authorization", "Bearer abc123")
@@ -34,10 +38,7 @@ func TestBypassNewRelic(t *testing.T) {
 
 	w := httptest.NewRecorder()
 
-	newRelicConfig := newrelic.NewConfig("Your_App_Name", "__YOUR_NEW_RELIC_LICENSE_KEY__")
-	app, _ := newrelic.NewApplication(newRelicConfig)
-
-	path, bypassHandler := bypassNewRelic(app, expectedPath, testHandler)
+	path, bypassHandler := configureAPM().WrapHandleFunc(expectedPath, testHandler)
 
 	if path != expectedPath {
 		t.Errorf("handler returned wrong path: got %v want %v", path, expectedPath)
@@ -51,3 +52,76 @@ func TestBypassNewRelic(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", w.Code, http.StatusOK)
 	}
 }
+
+func TestConfigureAPMProvider(t *testing.T) {
+	os.Setenv(config.APMProviderKey, apm.ProviderOpenTelemetry)
+	defer os.Unsetenv(config.APMProviderKey)
+
+	configured := configureAPM()
+	if configured == apm.NewNoopTracer() {
+		t.Errorf("configureAPM ignored the %v provider!", apm.ProviderOpenTelemetry)
+	}
+}
+
+func TestConfigureAPMUnknownProvider(t *testing.T) {
+	os.Setenv(config.APMProviderKey, "appdynamics")
+	defer os.Unsetenv(config.APMProviderKey)
+
+	configured := configureAPM()
+	if configured != apm.NewNoopTracer() {
+		t.Errorf("configureAPM should bypass an unknown provider!")
+	}
+}
+
+func serveRouter(method string, path string) *httptest.ResponseRecorder {
+	r, _ := http.NewRequest(method, path, nil)
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, r)
+	recordExchange(method, path, w)
+
+	return w
+}
+
+func TestRouterRoutes(t *testing.T) {
+	expected := map[string]int{
+		HealthPath:       http.StatusOK,
+		HealthLivePath:   http.StatusOK,
+		InfoPath:         http.StatusOK,
+		MetricsPath:      http.StatusOK,
+		"/unknown":       http.StatusNotFound,
+		HealthPath + "/": http.StatusNotFound,
+	}
+	for path, status := range expected {
+		if w := serveRouter(http.MethodGet, path); w.Code != status {
+			t.Errorf("router returned wrong status code for %v: got %v want %v", path, w.Code, status)
+		}
+	}
+}
+
+func TestRouterMethodNotAllowed(t *testing.T) {
+	w := serveRouter(http.MethodPost, HealthPath)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("router returned wrong status code: got %v want %v", w.Code, http.StatusMethodNotAllowed)
+	}
+
+	if w.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("router returned wrong Allow header: got %v want %v", w.Header().Get("Allow"), "GET, HEAD, OPTIONS")
+	}
+
+	if len(w.Header().Get(RequestIDHeader)) == 0 {
+		t.Errorf("router should run the middleware for rejected methods!")
+	}
+}
+
+func TestRouterOptions(t *testing.T) {
+	w := serveRouter(http.MethodOptions, EvaluatePath)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("router returned wrong status code: got %v want %v", w.Code, http.StatusNoContent)
+	}
+
+	if w.Header().Get("Allow") != "OPTIONS, POST" {
+		t.Errorf("router returned wrong Allow header: got %v want %v", w.Header().Get("Allow"), "OPTIONS, POST")
+	}
+}